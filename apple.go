@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlRowRegexp  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	htmlCellRegexp = regexp.MustCompile(`(?is)<t([dh])[^>]*>(.*?)</t[dh]>`)
+	htmlTagRegexp  = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// ParseAppleTrustStoreHTML parses Apple's trust store from the HTML page on which
+// Apple publishes the list of trusted root certificates for its operating systems.
+// Each table row containing a SHA-256 fingerprint becomes a root; the name is taken
+// from the "Certificate name" column if there is one, or else the first column.
+// Apple does not publish per-root trust bits in this list, so the returned
+// RootStore has no Purposes and only membership can be compared.
+func ParseAppleTrustStoreHTML(r io.Reader) (*RootStore, error) {
+	page, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Apple trust store: %w", err)
+	}
+	store := &RootStore{Name: "Apple"}
+	nameColumn := 0
+	for _, row := range htmlRowRegexp.FindAllStringSubmatch(string(page), -1) {
+		var cells []string
+		isHeader := false
+		for _, cell := range htmlCellRegexp.FindAllStringSubmatch(row[1], -1) {
+			isHeader = isHeader || strings.EqualFold(cell[1], "h")
+			text := html.UnescapeString(htmlTagRegexp.ReplaceAllString(cell[2], " "))
+			cells = append(cells, strings.Join(strings.Fields(text), " "))
+		}
+		if isHeader {
+			for i, cell := range cells {
+				if strings.EqualFold(cell, "Certificate name") {
+					nameColumn = i
+				}
+			}
+			continue
+		}
+		for _, cell := range cells {
+			fingerprint, ok := parseFingerprintText(cell)
+			if !ok {
+				continue
+			}
+			root := StoreRoot{SHA256: fingerprint}
+			if nameColumn < len(cells) {
+				root.Name = cells[nameColumn]
+			}
+			store.Roots = append(store.Roots, root)
+			break
+		}
+	}
+	if len(store.Roots) == 0 {
+		return nil, fmt.Errorf("Apple trust store does not contain any SHA-256 fingerprints")
+	}
+	return store, nil
+}
+
+// parseFingerprintText parses a SHA-256 fingerprint written in hex, possibly
+// with spaces or colons between the bytes
+func parseFingerprintText(text string) (SHA256Fingerprint, bool) {
+	text = strings.NewReplacer(" ", "", ":", "").Replace(text)
+	if len(text) != 64 {
+		return SHA256Fingerprint{}, false
+	}
+	fingerprint, err := ParseSHA256Fingerprint(text)
+	return fingerprint, err == nil
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ArchiveFile is an STL or CAB file from an archive of historical trust lists
+type ArchiveFile struct {
+	Name string // used to identify the file in errors and results
+	Data []byte
+}
+
+// ArchivedCTL is a CTL parsed from an ArchiveFile
+type ArchivedCTL struct {
+	Name string
+	CTL  *CTL
+}
+
+// ParseArchive parses every file yielded by files, each of which may be an STL
+// file or a CAB file containing one, using up to workers goroutines (or
+// runtime.GOMAXPROCS(0) if workers is not positive).  The CTLs are returned in
+// order of effective date, then sequence number.  Files which can't be parsed
+// are left out, and their errors are joined into the returned error, so that one
+// bad file doesn't prevent the rest of an archive from being processed.  Files are
+// parsed concurrently, so a function passed with WithEntryFunc is called from
+// several goroutines at once and must be safe for concurrent use.
+func ParseArchive(files iter.Seq[ArchiveFile], workers int, opts ...ParseOption) ([]ArchivedCTL, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var (
+		mu     sync.Mutex
+		result []ArchivedCTL
+		errs   []error
+		wg     sync.WaitGroup
+		queue  = make(chan ArchiveFile)
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range queue {
+				ctl, err := parseArchiveFile(file.Data, opts)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", file.Name, err))
+				} else {
+					result = append(result, ArchivedCTL{Name: file.Name, CTL: ctl})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for file := range files {
+		queue <- file
+	}
+	close(queue)
+	wg.Wait()
+
+	slices.SortFunc(result, func(a, b ArchivedCTL) int {
+		if c := a.CTL.EffectiveDate.Compare(b.CTL.EffectiveDate); c != 0 {
+			return c
+		}
+		if c := a.CTL.SequenceNumber.Cmp(&b.CTL.SequenceNumber); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return result, errors.Join(errs...)
+}
+
+// ParseArchiveDir is like ParseArchive, but parses every file in dir and its
+// subdirectories with a .stl or .cab extension.  Names in the result are
+// relative to dir.  Files and directories which can't be read are left out,
+// and their errors are joined into the returned error along with the parse errors.
+func ParseArchiveDir(dir string, workers int, opts ...ParseOption) ([]ArchivedCTL, error) {
+	var readErrs []error
+	files := func(yield func(ArchiveFile) bool) {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				readErrs = append(readErrs, err)
+				return nil
+			}
+			if ext := strings.ToLower(filepath.Ext(path)); entry.IsDir() || (ext != ".stl" && ext != ".cab") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				readErrs = append(readErrs, err)
+				return nil
+			}
+			name, err := filepath.Rel(dir, path)
+			if err != nil {
+				name = path
+			}
+			if !yield(ArchiveFile{Name: name, Data: data}) {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+	}
+	result, err := ParseArchive(files, workers, opts...)
+	return result, errors.Join(append(readErrs, err)...)
+}
+
+// parseArchiveFile parses data as a CAB file if it starts with the CAB signature,
+// and as an STL file otherwise
+func parseArchiveFile(data []byte, opts []ParseOption) (*CTL, error) {
+	if bytes.HasPrefix(data, []byte("MSCF")) {
+		return ParseSTLCab(bytes.NewReader(data), opts...)
+	}
+	return ParseAuthrootstl(data, append(slices.Clip(opts), WithZeroCopy())...)
+}
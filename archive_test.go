@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseArchiveDirKeepsParsedCTLs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"1.stl":       signTestCTL(t, newTestCTL(1, nil), nil),
+		"sub/2.cab":   newTestCAB("authroot.stl", signTestCTL(t, newTestCTL(2, nil), nil)),
+		"garbage.stl": []byte("not an STL file"),
+		"ignored.txt": []byte("not an STL file"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a dangling symlink can't be read
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "unreadable.stl")); err != nil {
+		t.Skip(err)
+	}
+
+	result, err := ParseArchiveDir(dir, 2)
+	if err == nil {
+		t.Error("ParseArchiveDir returned no error")
+	}
+	if len(result) != 2 || result[0].Name != "1.stl" || result[1].Name != filepath.Join("sub", "2.cab") {
+		t.Fatalf("ParseArchiveDir returned %v", result)
+	}
+	for _, name := range []string{"garbage.stl", "unreadable.stl"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q doesn't mention %s", err, name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Observation records what a mirror or monitor saw when it downloaded a CAB file
+// containing a CTL
+type Observation struct {
+	FetchedAt      time.Time         `json:"fetched_at"`
+	URL            string            `json:"url"`
+	CabSHA256      SHA256Fingerprint `json:"cab_sha256"`
+	STLSHA256      SHA256Fingerprint `json:"stl_sha256"`
+	ContentDigest  SHA256Fingerprint `json:"content_digest"`  // CTL.Digest
+	SequenceNumber string            `json:"sequence_number"` // hex
+	EffectiveDate  time.Time         `json:"effective_date"`
+}
+
+// Attestation is an Observation signed by the observer's key.  The signature covers
+// attestationContext followed by Observation, which is the Observation's JSON
+// encoding, exactly as signed.  (Encoding an Attestation with json.MarshalIndent
+// reformats Observation and so invalidates the signature; use json.Marshal.)
+type Attestation struct {
+	Observation json.RawMessage `json:"observation"`
+	PublicKey   []byte          `json:"public_key"` // PKIX, DER-encoded
+	Signature   []byte          `json:"signature"`
+}
+
+// attestationContext is prepended to the observation before signing, so that
+// attestation signatures can't be confused with signatures over other data
+const attestationContext = "authrootstl observation v1\n"
+
+// NewObservation returns an observation of the CTL in cab, which was downloaded
+// from url at fetchedAt.  The CTL's signature is not verified.
+func NewObservation(url string, fetchedAt time.Time, cab []byte) (*Observation, error) {
+	der, err := ExtractSTL(bytes.NewReader(cab))
+	if err != nil {
+		return nil, err
+	}
+	ctl, err := ParseAuthrootstl(der, WithZeroCopy())
+	if err != nil {
+		return nil, err
+	}
+	return newObservation(url, fetchedAt, cab, der, ctl), nil
+}
+
+func newObservation(url string, fetchedAt time.Time, cab []byte, der []byte, ctl *CTL) *Observation {
+	return &Observation{
+		FetchedAt:      fetchedAt.UTC(),
+		URL:            url,
+		CabSHA256:      sha256.Sum256(cab),
+		STLSHA256:      sha256.Sum256(der),
+		ContentDigest:  ctl.Digest(),
+		SequenceNumber: fmt.Sprintf("%X", &ctl.SequenceNumber),
+		EffectiveDate:  ctl.EffectiveDate,
+	}
+}
+
+// Observe downloads the named CAB file, such as authrootstl.cab, and returns its
+// contents along with an Observation of it.  The CTL is verified and checked
+// against the integrity policy, as by FetchCAB.
+func (client *Client) Observe(ctx context.Context, name string) ([]byte, *Observation, error) {
+	ctl, dl, err := client.fetchCTLDownload(ctx, name, ParseSTLCab)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := ExtractSTL(bytes.NewReader(dl.body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", dl.url, err)
+	}
+	return dl.body, newObservation(dl.url, time.Now(), dl.body, der, ctl), nil
+}
+
+// Sign signs the observation with signer, which must be an ECDSA, Ed25519, or RSA
+// key.  ECDSA and RSA (PKCS #1 v1.5) signatures use SHA-256.
+func (observation *Observation) Sign(signer crypto.Signer) (*Attestation, error) {
+	observationJSON, err := json.Marshal(observation)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	message := append([]byte(attestationContext), observationJSON...)
+	var signature []byte
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		signature, err = signer.Sign(rand.Reader, message, crypto.Hash(0))
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", signer.Public())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{Observation: observationJSON, PublicKey: publicKey, Signature: signature}, nil
+}
+
+// Verify verifies the attestation's signature using its public key, and returns
+// the observation.  It is up to the caller to decide whether to trust the key.
+func (attestation *Attestation) Verify() (*Observation, error) {
+	publicKey, err := x509.ParsePKIXPublicKey(attestation.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing attestation public key: %w", err)
+	}
+	message := append([]byte(attestationContext), attestation.Observation...)
+	digest := sha256.Sum256(message)
+	var valid bool
+	switch publicKey := publicKey.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(publicKey, message, attestation.Signature)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(publicKey, digest[:], attestation.Signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], attestation.Signature) == nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: attestation signature does not verify", ErrSignatureInvalid)
+	}
+	observation := new(Observation)
+	if err := json.Unmarshal(attestation.Observation, observation); err != nil {
+		return nil, fmt.Errorf("error parsing attested observation: %w", err)
+	}
+	return observation, nil
+}
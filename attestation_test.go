@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestObserveVerifies(t *testing.T) {
+	chain := newTestChain(t, nil, oidTrustListSigning)
+	der := signTestCTL(t, newTestCTL(1, nil), chain.signer, chain.signer.cert, chain.intermediate.cert, chain.root.cert)
+	modified := bytes.Replace(der, newTestCTL(1, nil), newTestCTL(2, nil), 1)
+
+	for _, test := range []struct {
+		name    string
+		stl     []byte
+		wantErr error
+	}{
+		{name: "signed", stl: der},
+		{name: "modified", stl: modified, wantErr: ErrSignatureInvalid},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cab := newTestCAB("authroot.stl", test.stl)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(cab)
+			}))
+			defer server.Close()
+			client := &Client{
+				BaseURL:       server.URL + "/",
+				VerifyOptions: VerifyOptions{Roots: chain.roots(), CurrentTime: testTime},
+			}
+			body, observation, err := client.Observe(context.Background(), "authrootstl.cab")
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("Observe returned %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(body, cab) {
+				t.Error("Observe returned the wrong CAB file")
+			}
+			if observation.URL != server.URL+"/authrootstl.cab" {
+				t.Errorf("URL is %q", observation.URL)
+			}
+			if observation.CabSHA256 != sha256.Sum256(cab) || observation.STLSHA256 != sha256.Sum256(der) {
+				t.Error("observation has the wrong hashes")
+			}
+			if observation.SequenceNumber != "1" {
+				t.Errorf("sequence number is %q", observation.SequenceNumber)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"sync"
+)
+
+// AttributeDecoder decodes one value of an entry attribute.  raw is the contents
+// of the value's OCTET STRING, and must not be modified.
+type AttributeDecoder func(raw []byte) (any, error)
+
+var (
+	attributeDecodersMu sync.RWMutex
+	attributeDecoders   = make(map[string]AttributeDecoder)
+)
+
+// RegisterAttributeDecoder makes the parser decode each value of entry attributes of
+// type oid with decoder, storing the results in Attribute.Decoded.  This lets
+// applications support properties which this package does not yet understand.
+// Attributes with a registered decoder are not reported as unrecognized.  If decoder
+// returns an error, parsing fails.  Registering a nil decoder removes the decoder for oid.
+// Decoders for attributes which this package decodes itself run in addition to the
+// built-in decoding.  Only CTLs parsed after the call are affected.
+func RegisterAttributeDecoder(oid asn1.ObjectIdentifier, decoder AttributeDecoder) {
+	attributeDecodersMu.Lock()
+	defer attributeDecodersMu.Unlock()
+	if decoder == nil {
+		delete(attributeDecoders, oid.String())
+	} else {
+		attributeDecoders[oid.String()] = decoder
+	}
+}
+
+func lookupAttributeDecoder(oid asn1.ObjectIdentifier) AttributeDecoder {
+	attributeDecodersMu.RLock()
+	defer attributeDecodersMu.RUnlock()
+	if len(attributeDecoders) == 0 {
+		return nil
+	}
+	return attributeDecoders[oid.String()]
+}
+
+// decodeRegistered decodes attribute's values with the registered decoder, if any
+func (attribute *Attribute) decodeRegistered() error {
+	decoder := lookupAttributeDecoder(attribute.Type)
+	if decoder == nil {
+		return nil
+	}
+	attribute.Decoded = make([]any, len(attribute.Values))
+	for i, value := range attribute.Values {
+		decoded, err := decoder(value)
+		if err != nil {
+			return err
+		}
+		attribute.Decoded[i] = decoded
+	}
+	return nil
+}
+
+// Decoded returns the values of the entry's first attribute of type oid, as decoded
+// by the decoder registered with RegisterAttributeDecoder.  It returns nil if the
+// entry has no such attribute or no decoder was registered when the entry was parsed.
+func (entry *Entry) Decoded(oid asn1.ObjectIdentifier) []any {
+	for _, attribute := range entry.Attributes {
+		if attribute.Type.Equal(oid) {
+			return attribute.Decoded
+		}
+	}
+	return nil
+}
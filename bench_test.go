@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// benchmarkSTL returns an STL file with 600 entries, each with the attributes
+// commonly found in authroot.stl, which is roughly the size of the real one
+func benchmarkSTL(b *testing.B) []byte {
+	var ekus cryptobyte.Builder
+	ekus.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1})
+		b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4})
+	})
+	disallowedDate := binary.LittleEndian.AppendUint64(nil, uint64(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Unix()+11644473600)*10000000)
+	entries := make([]testEntry, 600)
+	for i := range entries {
+		identifier := make([]byte, 20)
+		binary.BigEndian.PutUint16(identifier, uint16(i))
+		sha256 := make([]byte, 32)
+		binary.BigEndian.PutUint16(sha256, uint16(i))
+		entries[i] = testEntry{
+			identifier: identifier,
+			properties: map[int][]byte{
+				9:   ekus.BytesOrPanic(),
+				11:  utf16LE(fmt.Sprintf("Example Root CA %d", i)),
+				20:  identifier,
+				29:  sha256[:16],
+				83:  {1, 2, 3, 4},
+				98:  sha256,
+				104: disallowedDate,
+			},
+		}
+	}
+	return signTestCTL(b, newTestCTL(99, entries), nil)
+}
+
+// utf16LE returns s, NUL-terminated, in UTF-16LE, as in a friendly name attribute
+func utf16LE(s string) []byte {
+	var out []byte
+	for _, unit := range utf16.Encode([]rune(s + "\x00")) {
+		out = binary.LittleEndian.AppendUint16(out, unit)
+	}
+	return out
+}
+
+func BenchmarkParseAuthrootstl(b *testing.B) {
+	der := benchmarkSTL(b)
+	for _, bench := range []struct {
+		name string
+		opts []ParseOption
+	}{
+		{"Default", nil},
+		{"ZeroCopy", []ParseOption{WithZeroCopy()}},
+		{"WithoutEntries", []ParseOption{WithoutEntries()}},
+		{"HeaderOnly", []ParseOption{WithHeaderOnly()}},
+		{"EntryFields", []ParseOption{WithEntryFields(0)}},
+		{"Concurrency4", []ParseOption{WithConcurrency(4)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(der)))
+			for b.Loop() {
+				if _, err := ParseAuthrootstl(der, bench.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseAuthrootstlCab(b *testing.B) {
+	cab := newTestCAB("authroot.stl", benchmarkSTL(b))
+	b.ReportAllocs()
+	b.SetBytes(int64(len(cab)))
+	for b.Loop() {
+		if _, err := ParseAuthrootstlCab(bytes.NewReader(cab)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -30,11 +30,16 @@ package authrootstl
 import (
 	"fmt"
 	"io"
+	"path"
+	"strings"
 
 	"github.com/google/go-cabfile/cabfile"
 )
 
-func ParseAuthrootstlCab(cabReader io.ReadSeeker) (*CTL, error) {
+// ParseAuthrootstlCab extracts authroot.stl from a CAB file and parses it.  The
+// CTL refers to a freshly-allocated buffer, so no copy is necessary.
+func ParseAuthrootstlCab(cabReader io.ReadSeeker, opts ...ParseOption) (*CTL, error) {
+	options := newParseOptions(opts)
 	cab, err := cabfile.New(cabReader)
 	if err != nil {
 		return nil, fmt.Errorf("error opening CAB file: %w", err)
@@ -43,9 +48,72 @@ func ParseAuthrootstlCab(cabReader io.ReadSeeker) (*CTL, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error getting authroot.stl from CAB file: %w", err)
 	}
-	der, err := io.ReadAll(file)
+	der, err := readLimited(file, options.limits.MaxSize)
 	if err != nil {
 		return nil, fmt.Errorf("error reading authroot.stl from CAB file: %w", err)
 	}
-	return ParseAuthrootstl(der)
+	options.zeroCopy = true
+	return parseAuthrootstl(der, options)
+}
+
+// ParseSTLCab extracts the STL file from a CAB file, as ExtractSTL does, and
+// parses it.  This works for authrootstl.cab, disallowedcertstl.cab, and pinrulesstl.cab.
+func ParseSTLCab(cabReader io.ReadSeeker, opts ...ParseOption) (*CTL, error) {
+	options := newParseOptions(opts)
+	der, err := extractSTL(cabReader, options.limits.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	options.zeroCopy = true
+	return parseAuthrootstl(der, options)
+}
+
+// ExtractSTL returns the contents of the STL file contained in a CAB file such as
+// authrootstl.cab, disallowedcertstl.cab, or pinrulesstl.cab.  The CAB file must
+// contain exactly one file with a .stl extension.
+func ExtractSTL(cabReader io.ReadSeeker) ([]byte, error) {
+	return extractSTL(cabReader, 0)
+}
+
+func extractSTL(cabReader io.ReadSeeker, maxSize int) ([]byte, error) {
+	cab, err := cabfile.New(cabReader)
+	if err != nil {
+		return nil, fmt.Errorf("error opening CAB file: %w", err)
+	}
+	var stlName string
+	for _, name := range cab.FileList() {
+		if strings.EqualFold(path.Ext(name), ".stl") {
+			if stlName != "" {
+				return nil, fmt.Errorf("CAB file contains more than one STL file")
+			}
+			stlName = name
+		}
+	}
+	if stlName == "" {
+		return nil, fmt.Errorf("CAB file does not contain an STL file")
+	}
+	file, err := cab.Content(stlName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s from CAB file: %w", stlName, err)
+	}
+	der, err := readLimited(file, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s from CAB file: %w", stlName, err)
+	}
+	return der, nil
+}
+
+// readLimited reads all of r, failing if it is longer than maxSize bytes (unless maxSize is 0)
+func readLimited(r io.Reader, maxSize int) ([]byte, error) {
+	if maxSize == 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("file exceeds the limit of %d bytes", maxSize)
+	}
+	return data, nil
 }
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+)
+
+// ParseCache stores parsed CTLs, keyed by a SHA-256 hash of the STL file they
+// were parsed from and the parse options, so that parsing the same file again
+// can return the earlier result.  Implementations must be safe for concurrent use.
+type ParseCache interface {
+	// Get returns the CTL stored under key, if any
+	Get(key SHA256Fingerprint) (*CTL, bool)
+
+	// Put stores ctl under key
+	Put(key SHA256Fingerprint, ctl *CTL)
+}
+
+// LRUCache is an in-memory ParseCache which holds a bounded number of CTLs,
+// discarding the least recently used first
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   list.List // of *lruEntry, most recently used first
+	entries map[SHA256Fingerprint]*list.Element
+}
+
+type lruEntry struct {
+	key SHA256Fingerprint
+	ctl *CTL
+}
+
+// NewLRUCache returns an LRUCache which holds up to size CTLs
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		entries: make(map[SHA256Fingerprint]*list.Element),
+	}
+}
+
+// Get returns the CTL stored under key, if any
+func (cache *LRUCache) Get(key SHA256Fingerprint) (*CTL, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return element.Value.(*lruEntry).ctl, true
+}
+
+// Put stores ctl under key, discarding the least recently used CTL if the cache is full
+func (cache *LRUCache) Put(key SHA256Fingerprint, ctl *CTL) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if element, ok := cache.entries[key]; ok {
+		element.Value.(*lruEntry).ctl = ctl
+		cache.order.MoveToFront(element)
+		return
+	}
+	if cache.size <= 0 {
+		return
+	}
+	if cache.order.Len() >= cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*lruEntry).key)
+	}
+	cache.entries[key] = cache.order.PushFront(&lruEntry{key: key, ctl: ctl})
+}
+
+// loggingCache wraps a ParseCache to log each lookup
+type loggingCache struct {
+	ParseCache
+	logger *slog.Logger
+}
+
+func (cache *loggingCache) Get(key SHA256Fingerprint) (*CTL, bool) {
+	ctl, ok := cache.ParseCache.Get(key)
+	cache.logger.Debug("parse cache lookup", "key", key.Hex(), "hit", ok)
+	return ctl, ok
+}
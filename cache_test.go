@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testCacheCTL() []byte {
+	return newTestCTL(1, []testEntry{
+		{identifier: bytes.Repeat([]byte{1}, 20)},
+		{identifier: bytes.Repeat([]byte{2}, 20)},
+	})
+}
+
+func TestCacheVerifiesHits(t *testing.T) {
+	der := signTestCTL(t, testCacheCTL(), nil)
+	cache := NewLRUCache(1)
+	if _, err := ParseAuthrootstl(der, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAuthrootstl(der, WithCache(cache), WithVerification(VerifyOptions{})); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("cached unsigned CTL: ParseAuthrootstl returned %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func TestCacheKeyIncludesOptions(t *testing.T) {
+	der := signTestCTL(t, testCacheCTL(), nil)
+	cache := NewLRUCache(4)
+
+	header, err := ParseAuthrootstl(der, WithCache(cache), WithHeaderOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(header.Entries) != 0 {
+		t.Fatalf("WithHeaderOnly: got %d entries, want 0", len(header.Entries))
+	}
+	full, err := ParseAuthrootstl(der, WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(full.Entries) != 2 {
+		t.Errorf("after caching WithHeaderOnly: got %d entries, want 2", len(full.Entries))
+	}
+	if again, err := ParseAuthrootstl(der, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	} else if again != full {
+		t.Error("parse with the same options was not served from the cache")
+	}
+
+	var called int
+	countEntries := WithEntryFunc(func(*Entry) error { called++; return nil })
+	for range 2 {
+		if _, err := ParseAuthrootstl(der, WithCache(cache), countEntries); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if called != 4 {
+		t.Errorf("WithEntryFunc: fn called %d times, want 4", called)
+	}
+}
+
+func TestCacheCopiesZeroCopyCTLs(t *testing.T) {
+	der := signTestCTL(t, testCacheCTL(), nil)
+	cache := NewLRUCache(1)
+	buffer := bytes.Clone(der)
+	if _, err := ParseAuthrootstl(buffer, WithCache(cache), WithZeroCopy()); err != nil {
+		t.Fatal(err)
+	}
+	clear(buffer)
+	ctl, err := ParseAuthrootstl(der, WithCache(cache), WithZeroCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ctl.Entries[0].SubjectIdentifier, bytes.Repeat([]byte{1}, 20)) || !bytes.Equal(ctl.Raw, der) {
+		t.Error("cached CTL refers to the buffer it was parsed from")
+	}
+}
+
+func TestCacheSetsIntegrityPerCall(t *testing.T) {
+	der := signTestCTL(t, testCacheCTL(), nil)
+	cache := NewLRUCache(1)
+	first := &Integrity{URL: "https://first.example/authrootstl.cab"}
+	second := &Integrity{URL: "https://second.example/authrootstl.cab"}
+	if ctl, err := ParseAuthrootstl(der, WithCache(cache), withIntegrity(first)); err != nil {
+		t.Fatal(err)
+	} else if ctl.Integrity != first {
+		t.Errorf("first parse: Integrity is %v", ctl.Integrity)
+	}
+	if ctl, err := ParseAuthrootstl(der, WithCache(cache), withIntegrity(second)); err != nil {
+		t.Fatal(err)
+	} else if ctl.Integrity != second {
+		t.Errorf("cache hit: Integrity is %v, want the second download's", ctl.Integrity)
+	}
+	if ctl, err := ParseAuthrootstl(der, WithCache(cache)); err != nil {
+		t.Fatal(err)
+	} else if ctl.Integrity != nil {
+		t.Errorf("cache hit without a download: Integrity is %v", ctl.Integrity)
+	}
+}
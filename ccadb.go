@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CCADBRecord is the CCADB's information about a certificate, from the AllCertificateRecords report
+type CCADBRecord struct {
+	SHA256           SHA256Fingerprint
+	CAOwner          string
+	CertificateName  string
+	RecordType       string // e.g. "Root Certificate"
+	RevocationStatus string
+
+	Auditor                        string
+	StandardAudit                  string // URL of the standard audit statement
+	StandardAuditType              string
+	StandardAuditStatementDate     string
+	StandardAuditPeriodEndDate     string
+	BRAudit                        string // URL of the Baseline Requirements audit statement
+	BRAuditPeriodEndDate           string
+	EVSSLAudit                     string // URL of the EV SSL audit statement
+	EVSSLAuditPeriodEndDate        string
+	CertificatePolicy              string // URL of the CP
+	CertificationPracticeStatement string // URL of the CPS
+	PolicyDocumentation            string
+}
+
+// AnnotatedEntry is an entry in the CTL together with the CCADB's information about it
+type AnnotatedEntry struct {
+	Entry
+	CCADB *CCADBRecord // nil if the CCADB has no record of the certificate
+}
+
+// ParseCCADBAllCertificateRecords parses the CCADB's AllCertificateRecords CSV report,
+// returning the records keyed by the SHA-256 hash of the certificate
+func ParseCCADBAllCertificateRecords(r io.Reader) (map[SHA256Fingerprint]*CCADBRecord, error) {
+	reader := csv.NewReader(r)
+	header, err := readCSVHeader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CCADB CSV header: %w", err)
+	}
+	if !header.has("SHA-256 Fingerprint") {
+		return nil, fmt.Errorf("CCADB CSV does not contain a SHA-256 Fingerprint column")
+	}
+	records := make(map[SHA256Fingerprint]*CCADBRecord)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading CCADB CSV: %w", err)
+		}
+		fingerprint, err := ParseSHA256Fingerprint(header.field(row, "SHA-256 Fingerprint"))
+		if err != nil {
+			return nil, fmt.Errorf("CCADB CSV contains malformed SHA-256 fingerprint %q", header.field(row, "SHA-256 Fingerprint"))
+		}
+		records[fingerprint] = &CCADBRecord{
+			SHA256:                         fingerprint,
+			CAOwner:                        header.field(row, "CA Owner"),
+			CertificateName:                header.field(row, "Certificate Name"),
+			RecordType:                     header.field(row, "Certificate Record Type"),
+			RevocationStatus:               header.field(row, "Revocation Status"),
+			Auditor:                        header.field(row, "Auditor"),
+			StandardAudit:                  header.field(row, "Standard Audit"),
+			StandardAuditType:              header.field(row, "Standard Audit Type"),
+			StandardAuditStatementDate:     header.field(row, "Standard Audit Statement Date"),
+			StandardAuditPeriodEndDate:     header.field(row, "Standard Audit Period End Date"),
+			BRAudit:                        header.field(row, "BR Audit"),
+			BRAuditPeriodEndDate:           header.field(row, "BR Audit Period End Date"),
+			EVSSLAudit:                     header.field(row, "EV SSL Audit"),
+			EVSSLAuditPeriodEndDate:        header.field(row, "EV SSL Audit Period End Date"),
+			CertificatePolicy:              header.field(row, "Certificate Policy (CP)"),
+			CertificationPracticeStatement: header.field(row, "Certification Practice Statement (CPS)"),
+			PolicyDocumentation:            header.field(row, "Policy Documentation"),
+		}
+	}
+	return records, nil
+}
+
+// AnnotateEntries pairs each entry in the CTL with its CCADB record, matched by
+// the SHA-256 hash of the certificate
+func AnnotateEntries(ctl *CTL, records map[SHA256Fingerprint]*CCADBRecord) []AnnotatedEntry {
+	annotated := make([]AnnotatedEntry, len(ctl.Entries))
+	for i, entry := range ctl.Entries {
+		annotated[i].Entry = entry
+		if !entry.SHA256.IsZero() {
+			annotated[i].CCADB = records[entry.SHA256]
+		}
+	}
+	return annotated
+}
+
+// csvHeader maps the column names of a CSV file to their indices
+type csvHeader map[string]int
+
+func readCSVHeader(reader *csv.Reader) (csvHeader, error) {
+	names, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	header := make(csvHeader)
+	for i, name := range names {
+		header[strings.TrimSpace(name)] = i
+	}
+	return header, nil
+}
+
+func (header csvHeader) has(name string) bool {
+	_, ok := header[name]
+	return ok
+}
+
+// field returns the value of the named column in row, or the empty string
+// if there is no such column
+func (header csvHeader) field(row []string, name string) string {
+	if i, ok := header[name]; ok && i < len(row) {
+		return strings.TrimSpace(row[i])
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertPoolLoader loads a set of roots, for RootPool
+type CertPoolLoader func(ctx context.Context) (*x509.CertPool, error)
+
+// CertPoolFromFile returns a loader which reads a PEM bundle, such as one written
+// by ExportPEM or mounted from a ConfigMap written by ExportKubernetes.  The file
+// is only re-read when its size or modification time changes.
+func CertPoolFromFile(filename string) CertPoolLoader {
+	var mu sync.Mutex
+	var lastInfo os.FileInfo
+	var lastPool *x509.CertPool
+	return func(ctx context.Context) (*x509.CertPool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		info, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		if lastInfo != nil && info.Size() == lastInfo.Size() && info.ModTime().Equal(lastInfo.ModTime()) {
+			return lastPool, nil
+		}
+		pemBytes, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("%s: no certificates found", filename)
+		}
+		lastInfo, lastPool = info, pool
+		return pool, nil
+	}
+}
+
+// CertPoolLoader returns a loader which downloads the CTL and the certificates of
+// the roots trusted for the given usage (e.g. server authentication, 1.3.6.1.5.5.7.3.1)
+// at the time of loading.  Certificates are remembered between loads, so only
+// roots which are new to the CTL are downloaded.
+func (client *Client) CertPoolLoader(usage asn1.ObjectIdentifier) CertPoolLoader {
+	var mu sync.Mutex
+	certificates := make(map[SHA1Fingerprint]*x509.Certificate)
+	return func(ctx context.Context) (*x509.CertPool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ctl, err := client.FetchCTL(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		for _, entry := range FilterEntries(ctl.Entries, TrustedForAt(usage, time.Now())) {
+			cert, ok := certificates[entry.SHA1]
+			if !ok {
+				certBytes, err := client.FetchCertificate(ctx, &entry)
+				if err != nil {
+					return nil, fmt.Errorf("error downloading certificate for %s: %w", &entry, err)
+				}
+				if cert, err = x509.ParseCertificate(certBytes); err != nil {
+					return nil, fmt.Errorf("error parsing certificate for %s: %w", &entry, err)
+				}
+				certificates[entry.SHA1] = cert
+			}
+			pool.AddCert(cert)
+		}
+		return pool, nil
+	}
+}
+
+// RootPool keeps an *x509.CertPool of Microsoft's roots up to date, for use in
+// place of x509.SystemCertPool, e.g. by Windows-targeted services running in Linux
+// containers.  Since a tls.Config's RootCAs can't change, call CertPool each time
+// a tls.Config is built, or verify in tls.Config.VerifyConnection.
+type RootPool struct {
+	load    CertPoolLoader
+	onError func(error)
+	pool    atomic.Pointer[x509.CertPool]
+}
+
+// NewRootPool loads the roots with load, failing if that fails.  If interval is
+// non-zero, the roots are reloaded every interval until ctx is done; a failed
+// reload keeps the previous roots and is passed to onError, if it is non-nil.
+func NewRootPool(ctx context.Context, load CertPoolLoader, interval time.Duration, onError func(error)) (*RootPool, error) {
+	rootPool := &RootPool{load: load, onError: onError}
+	if err := rootPool.Reload(ctx); err != nil {
+		return nil, err
+	}
+	if interval != 0 {
+		go rootPool.refresh(ctx, interval)
+	}
+	return rootPool, nil
+}
+
+// CertPool returns the most recently loaded roots.  The returned pool must not
+// be modified.
+func (rootPool *RootPool) CertPool() *x509.CertPool {
+	return rootPool.pool.Load()
+}
+
+// Reload loads the roots immediately, keeping the previous roots if it fails
+func (rootPool *RootPool) Reload(ctx context.Context) error {
+	pool, err := rootPool.load(ctx)
+	if err != nil {
+		return err
+	}
+	rootPool.pool.Store(pool)
+	return nil
+}
+
+func (rootPool *RootPool) refresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := rootPool.Reload(ctx); err != nil && rootPool.onError != nil && ctx.Err() == nil {
+			rootPool.onError(err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type changelogRoot struct {
+	Name    string // friendly name, or the subject identifier if there is none
+	SHA1    string // upper-case hex subject identifier
+	Changes []string
+}
+
+type changelogSection struct {
+	SequenceNumber string
+	EffectiveDate  string
+	AddedRoots     []changelogRoot
+	RemovedRoots   []changelogRoot
+	ChangedRoots   []changelogRoot
+	AddedCTLogs    []string
+	RemovedCTLogs  []string
+}
+
+func newChangelogRoot(entry *Entry, changes []string) changelogRoot {
+	root := changelogRoot{Name: entry.FriendlyName, SHA1: fmt.Sprintf("%X", entry.SubjectIdentifier), Changes: changes}
+	if root.Name == "" {
+		root.Name = root.SHA1
+	}
+	return root
+}
+
+func newChangelogSections(diffs []*CTLDiff) []changelogSection {
+	sections := make([]changelogSection, 0, len(diffs))
+	for _, diff := range diffs {
+		section := changelogSection{
+			SequenceNumber: fmt.Sprintf("%X", diff.NewSequenceNumber),
+			EffectiveDate:  diff.NewEffectiveDate.Format(time.DateOnly),
+		}
+		for i := range diff.AddedRoots {
+			section.AddedRoots = append(section.AddedRoots, newChangelogRoot(&diff.AddedRoots[i], nil))
+		}
+		for i := range diff.RemovedRoots {
+			section.RemovedRoots = append(section.RemovedRoots, newChangelogRoot(&diff.RemovedRoots[i], nil))
+		}
+		for i := range diff.ChangedRoots {
+			section.ChangedRoots = append(section.ChangedRoots, newChangelogRoot(&diff.ChangedRoots[i].New, diff.ChangedRoots[i].Changes))
+		}
+		for _, logKey := range diff.AddedCTLogs {
+			section.AddedCTLogs = append(section.AddedCTLogs, logKey.String())
+		}
+		for _, logKey := range diff.RemovedCTLogs {
+			section.RemovedCTLogs = append(section.RemovedCTLogs, logKey.String())
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+var markdownChangelogTemplate = template.Must(template.New("changelog").Funcs(template.FuncMap{"md": escapeMarkdown}).Parse(`# Microsoft root program changes
+{{range .}}
+## Sequence number {{.SequenceNumber}} (effective {{.EffectiveDate}})
+{{if .AddedRoots}}
+### Added roots
+{{range .AddedRoots}}
+- {{md .Name}} ` + "(`{{.SHA1}}`)" + `{{end}}
+{{end}}{{if .RemovedRoots}}
+### Removed roots
+{{range .RemovedRoots}}
+- {{md .Name}} ` + "(`{{.SHA1}}`)" + `{{end}}
+{{end}}{{if .ChangedRoots}}
+### Changed roots
+{{range .ChangedRoots}}
+- {{md .Name}} ` + "(`{{.SHA1}}`)" + `{{range .Changes}}
+  - {{md .}}{{end}}{{end}}
+{{end}}{{if .AddedCTLogs}}
+### Added CT logs
+{{range .AddedCTLogs}}
+- ` + "`{{.}}`" + `{{end}}
+{{end}}{{if .RemovedCTLogs}}
+### Removed CT logs
+{{range .RemovedCTLogs}}
+- ` + "`{{.}}`" + `{{end}}
+{{end}}{{if not (or .AddedRoots .RemovedRoots .ChangedRoots .AddedCTLogs .RemovedCTLogs)}}
+No changes to roots or CT logs.
+{{end}}{{end}}`))
+
+var htmlChangelogTemplate = htmltemplate.Must(htmltemplate.New("changelog").Parse(`<h1>Microsoft root program changes</h1>
+{{range .}}
+<h2>Sequence number {{.SequenceNumber}} (effective {{.EffectiveDate}})</h2>
+{{if .AddedRoots}}<h3>Added roots</h3>
+<ul>
+{{range .AddedRoots}}<li>{{.Name}} (<code>{{.SHA1}}</code>)</li>
+{{end}}</ul>
+{{end}}{{if .RemovedRoots}}<h3>Removed roots</h3>
+<ul>
+{{range .RemovedRoots}}<li>{{.Name}} (<code>{{.SHA1}}</code>)</li>
+{{end}}</ul>
+{{end}}{{if .ChangedRoots}}<h3>Changed roots</h3>
+<ul>
+{{range .ChangedRoots}}<li>{{.Name}} (<code>{{.SHA1}}</code>)<ul>{{range .Changes}}<li>{{.}}</li>{{end}}</ul></li>
+{{end}}</ul>
+{{end}}{{if .AddedCTLogs}}<h3>Added CT logs</h3>
+<ul>
+{{range .AddedCTLogs}}<li><code>{{.}}</code></li>
+{{end}}</ul>
+{{end}}{{if .RemovedCTLogs}}<h3>Removed CT logs</h3>
+<ul>
+{{range .RemovedCTLogs}}<li><code>{{.}}</code></li>
+{{end}}</ul>
+{{end}}{{if not (or .AddedRoots .RemovedRoots .ChangedRoots .AddedCTLogs .RemovedCTLogs)}}<p>No changes to roots or CT logs.</p>
+{{end}}{{end}}`))
+
+// WriteMarkdownChangelog writes a Markdown changelog with a section for each diff,
+// in the order given, headed by the new sequence number and effective date
+func WriteMarkdownChangelog(w io.Writer, diffs []*CTLDiff) error {
+	return markdownChangelogTemplate.Execute(w, newChangelogSections(diffs))
+}
+
+// WriteHTMLChangelog writes an HTML fragment containing the same changelog as WriteMarkdownChangelog
+func WriteHTMLChangelog(w io.Writer, diffs []*CTLDiff) error {
+	return htmlChangelogTemplate.Execute(w, newChangelogSections(diffs))
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Dates outside of this range are implausible for a trust list published by Microsoft
+var (
+	minPlausibleTime = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxPlausibleTime = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// singleValuedAttributes are decoded into Entry fields only if they have exactly one value
+var singleValuedAttributes = []asn1.ObjectIdentifier{
+	oidEKUProperty,
+	oidFriendlyNameProperty,
+	oidKeyIDProperty,
+	oidSubjectNameMD5Property,
+	oidSHA256Property,
+	oidDisallowedFiletimeProperty,
+	oidDisallowedEKUProperty,
+	oidNotBeforeFiletimeProperty,
+	oidNotBeforeEKUProperty,
+}
+
+// knownAttributes are the attributes which are not reported as unrecognized
+var knownAttributes = append(slices.Clip(singleValuedAttributes),
+	asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 83},  // root program certificate policies
+	asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 105}, // root program chain policies
+	oidPinSHA256Property,
+	oidPinRulesDomainName,
+)
+
+// checker performs the checks whose failures are reported in CTL.Warnings, or
+// returned as errors with WithStrictChecks
+type checker struct {
+	strict   bool
+	warnings []error
+	seen     map[string]struct{} // subject identifiers of the entries checked so far
+}
+
+// report records err as a warning, or returns it if checking is strict
+func (c *checker) report(err error) error {
+	if c.strict {
+		return err
+	}
+	c.warnings = append(c.warnings, err)
+	return nil
+}
+
+func (c *checker) checkDate(description string, t time.Time) error {
+	if t.Before(minPlausibleTime) || !t.Before(maxPlausibleTime) {
+		return c.report(fmt.Errorf("%w: %s is %s", ErrImplausibleDate, description, t.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+func (c *checker) checkHeader(ctl *CTL) error {
+	if err := c.checkDate("effective date", ctl.EffectiveDate); err != nil {
+		return err
+	}
+	if !ctl.NextUpdate.IsZero() {
+		if err := c.checkDate("next update", ctl.NextUpdate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *checker) checkEntry(entry *Entry) error {
+	if c.seen == nil {
+		c.seen = make(map[string]struct{})
+	}
+	if _, duplicate := c.seen[string(entry.SubjectIdentifier)]; duplicate {
+		if err := c.report(fmt.Errorf("%w: entry %X appears more than once", ErrDuplicate, entry.SubjectIdentifier)); err != nil {
+			return err
+		}
+	} else {
+		c.seen[string(entry.SubjectIdentifier)] = struct{}{}
+	}
+	for i, attribute := range entry.Attributes {
+		if err := c.checkAttribute(entry, i, attribute); err != nil {
+			return err
+		}
+	}
+	if !entry.DisallowedDate.IsZero() {
+		if err := c.checkDate(fmt.Sprintf("disallowed date of entry %X", entry.SubjectIdentifier), entry.DisallowedDate); err != nil {
+			return err
+		}
+	}
+	if !entry.NotBeforeDate.IsZero() {
+		if err := c.checkDate(fmt.Sprintf("not before date of entry %X", entry.SubjectIdentifier), entry.NotBeforeDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *checker) checkAttribute(entry *Entry, i int, attribute Attribute) error {
+	if slices.ContainsFunc(entry.Attributes[:i], func(other Attribute) bool { return other.Type.Equal(attribute.Type) }) {
+		return c.report(fmt.Errorf("%w: entry %X has attribute %s more than once", ErrDuplicate, entry.SubjectIdentifier, attribute.Type))
+	}
+	if !containsOID(knownAttributes, attribute.Type) && lookupAttributeDecoder(attribute.Type) == nil {
+		return c.report(fmt.Errorf("%w: entry %X has attribute %s", ErrUnrecognized, entry.SubjectIdentifier, attribute.Type))
+	}
+	if len(attribute.Values) != 1 && containsOID(singleValuedAttributes, attribute.Type) {
+		return c.report(fmt.Errorf("%w: attribute %s of entry %X has %d values, so it is ignored", ErrUnusualEncoding, attribute.Type, entry.SubjectIdentifier, len(attribute.Values)))
+	}
+	return nil
+}
+
+// checkExtension checks the extension which follows extensions
+func (c *checker) checkExtension(extensions []Extension, extension *Extension) error {
+	if slices.ContainsFunc(extensions, func(other Extension) bool { return other.ID.Equal(extension.ID) }) {
+		return c.report(fmt.Errorf("%w: extension %s appears more than once", ErrDuplicate, extension.ID))
+	}
+	if _, ok := LookupOID(extension.ID); !ok {
+		if extension.Critical {
+			return c.report(fmt.Errorf("%w: critical extension %s", ErrUnrecognized, extension.ID))
+		}
+		return c.report(fmt.Errorf("%w: extension %s", ErrUnrecognized, extension.ID))
+	}
+	return nil
+}
+
+func (c *checker) checkCTLogs(logs []CTLogKey) error {
+	seen := make(map[string]struct{}, len(logs))
+	for _, key := range logs {
+		if _, duplicate := seen[string(key)]; duplicate {
+			if err := c.report(fmt.Errorf("%w: CT log %s appears more than once", ErrDuplicate, key)); err != nil {
+				return err
+			}
+		}
+		seen[string(key)] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var chromePurposes = []asn1.ObjectIdentifier{oidServerAuth}
+
+type chromeTrustAnchor struct {
+	SHA256Hex   string                `json:"sha256Hex"`
+	DisplayName string                `json:"displayName"`
+	Constraints []chromeConstraintSet `json:"constraints"`
+}
+
+type chromeConstraintSet struct {
+	SCTNotAfterSec      *protoInt64 `json:"sctNotAfterSec"`
+	SCTAllAfterSec      *protoInt64 `json:"sctAllAfterSec"`
+	MinVersion          string      `json:"minVersion"`
+	MaxVersionExclusive string      `json:"maxVersionExclusive"`
+	PermittedDNSNames   []string    `json:"permittedDnsNames"`
+}
+
+// protoInt64 is an int64 which, per the protobuf JSON mapping, may be encoded as a number or a string
+type protoInt64 int64
+
+func (i *protoInt64) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = protoInt64(value)
+	return nil
+}
+
+// ParseChromeRootStoreJSON parses the Chrome Root Store from the protobuf JSON
+// encoding of a chrome_root_store.RootStore message
+func ParseChromeRootStoreJSON(data []byte) (*RootStore, error) {
+	var rootStore struct {
+		TrustAnchors []chromeTrustAnchor `json:"trustAnchors"`
+	}
+	if err := json.Unmarshal(data, &rootStore); err != nil {
+		return nil, fmt.Errorf("error parsing Chrome Root Store: %w", err)
+	}
+	return newChromeRootStore(rootStore.TrustAnchors)
+}
+
+// ParseChromeRootStoreTextproto parses the Chrome Root Store from Chromium's root_store.textproto file
+func ParseChromeRootStoreTextproto(data []byte) (*RootStore, error) {
+	message, err := parseTextproto(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Chrome Root Store: %w", err)
+	}
+	var trustAnchors []chromeTrustAnchor
+	for _, field := range message {
+		if field.name != "trust_anchors" {
+			continue
+		}
+		var anchor chromeTrustAnchor
+		for _, anchorField := range field.message {
+			switch anchorField.name {
+			case "sha256_hex":
+				anchor.SHA256Hex = anchorField.value
+			case "display_name":
+				anchor.DisplayName = anchorField.value
+			case "constraints":
+				var constraintSet chromeConstraintSet
+				for _, constraintField := range anchorField.message {
+					switch constraintField.name {
+					case "sct_not_after_sec", "sct_all_after_sec":
+						value, err := strconv.ParseInt(constraintField.value, 10, 64)
+						if err != nil {
+							return nil, fmt.Errorf("error parsing Chrome Root Store: malformed %s", constraintField.name)
+						}
+						if constraintField.name == "sct_not_after_sec" {
+							constraintSet.SCTNotAfterSec = (*protoInt64)(&value)
+						} else {
+							constraintSet.SCTAllAfterSec = (*protoInt64)(&value)
+						}
+					case "min_version":
+						constraintSet.MinVersion = constraintField.value
+					case "max_version_exclusive":
+						constraintSet.MaxVersionExclusive = constraintField.value
+					case "permitted_dns_names":
+						constraintSet.PermittedDNSNames = append(constraintSet.PermittedDNSNames, constraintField.value)
+					}
+				}
+				anchor.Constraints = append(anchor.Constraints, constraintSet)
+			}
+		}
+		trustAnchors = append(trustAnchors, anchor)
+	}
+	return newChromeRootStore(trustAnchors)
+}
+
+func newChromeRootStore(trustAnchors []chromeTrustAnchor) (*RootStore, error) {
+	store := &RootStore{Name: "Chrome", Purposes: chromePurposes}
+	for _, anchor := range trustAnchors {
+		if anchor.SHA256Hex == "" {
+			// Trust anchors may also be specified by certificate file name, which we can't resolve
+			continue
+		}
+		fingerprint, err := ParseSHA256Fingerprint(anchor.SHA256Hex)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Chrome Root Store: malformed sha256_hex %q", anchor.SHA256Hex)
+		}
+		root := StoreRoot{
+			SHA256: fingerprint,
+			Name:   anchor.DisplayName,
+			EKUs:   []asn1.ObjectIdentifier{oidServerAuth},
+		}
+		if len(anchor.Constraints) == 1 && anchor.Constraints[0].onlySCTNotAfter() {
+			root.DistrustAfter = time.Unix(int64(*anchor.Constraints[0].SCTNotAfterSec), 0).UTC()
+			root.DistrustAfterEKUs = []asn1.ObjectIdentifier{oidServerAuth}
+		} else {
+			for _, constraintSet := range anchor.Constraints {
+				root.Constraints = append(root.Constraints, constraintSet.String())
+			}
+		}
+		store.Roots = append(store.Roots, root)
+	}
+	return store, nil
+}
+
+func (constraintSet *chromeConstraintSet) onlySCTNotAfter() bool {
+	return constraintSet.SCTNotAfterSec != nil &&
+		constraintSet.SCTAllAfterSec == nil &&
+		constraintSet.MinVersion == "" &&
+		constraintSet.MaxVersionExclusive == "" &&
+		len(constraintSet.PermittedDNSNames) == 0
+}
+
+func (constraintSet *chromeConstraintSet) String() string {
+	var parts []string
+	if constraintSet.SCTNotAfterSec != nil {
+		parts = append(parts, fmt.Sprintf("sct_not_after_sec: %d", *constraintSet.SCTNotAfterSec))
+	}
+	if constraintSet.SCTAllAfterSec != nil {
+		parts = append(parts, fmt.Sprintf("sct_all_after_sec: %d", *constraintSet.SCTAllAfterSec))
+	}
+	if constraintSet.MinVersion != "" {
+		parts = append(parts, fmt.Sprintf("min_version: %q", constraintSet.MinVersion))
+	}
+	if constraintSet.MaxVersionExclusive != "" {
+		parts = append(parts, fmt.Sprintf("max_version_exclusive: %q", constraintSet.MaxVersionExclusive))
+	}
+	for _, name := range constraintSet.PermittedDNSNames {
+		parts = append(parts, fmt.Sprintf("permitted_dns_names: %q", name))
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// textprotoField is a field of a message in the protobuf text format.
+// Exactly one of value and message is meaningful.
+type textprotoField struct {
+	name    string
+	value   string
+	message []textprotoField
+}
+
+// maxTextprotoDepth bounds the nesting of messages accepted by parseTextproto, which
+// recurses once per level.  root_store.textproto nests three levels deep.
+const maxTextprotoDepth = 32
+
+// parseTextproto parses the subset of the protobuf text format used by
+// root_store.textproto: scalar fields, string literals, and nested messages.
+func parseTextproto(input string) ([]textprotoField, error) {
+	p := &textprotoParser{input: input}
+	message, err := p.parseMessage(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos != len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.input[p.pos], p.pos)
+	}
+	return message, nil
+}
+
+type textprotoParser struct {
+	input string
+	pos   int
+}
+
+func (p *textprotoParser) skipSpace() {
+	for p.pos < len(p.input) {
+		switch c := p.input[p.pos]; {
+		case c == '#':
+			for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *textprotoParser) parseMessage(depth int) ([]textprotoField, error) {
+	if depth > maxTextprotoDepth {
+		return nil, fmt.Errorf("messages nested more than %d deep at offset %d", maxTextprotoDepth, p.pos)
+	}
+	var fields []textprotoField
+	for {
+		p.skipSpace()
+		if p.pos == len(p.input) || p.input[p.pos] == '}' {
+			return fields, nil
+		}
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+		if start == p.pos {
+			return nil, fmt.Errorf("expected field name at offset %d", p.pos)
+		}
+		field := textprotoField{name: p.input[start:p.pos]}
+		p.skipSpace()
+		if p.pos < len(p.input) && p.input[p.pos] == ':' {
+			p.pos++
+			p.skipSpace()
+		}
+		if p.pos < len(p.input) && p.input[p.pos] == '{' {
+			p.pos++
+			var err error
+			if field.message, err = p.parseMessage(depth + 1); err != nil {
+				return nil, err
+			}
+			if p.pos == len(p.input) {
+				return nil, fmt.Errorf("unterminated message %s", field.name)
+			}
+			p.pos++
+		} else {
+			var err error
+			if field.value, err = p.parseScalar(); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+		}
+		fields = append(fields, field)
+	}
+}
+
+func (p *textprotoParser) parseScalar() (string, error) {
+	if p.pos < len(p.input) && (p.input[p.pos] == '"' || p.input[p.pos] == '\'') {
+		var value strings.Builder
+		// Adjacent string literals are concatenated
+		for p.pos < len(p.input) && (p.input[p.pos] == '"' || p.input[p.pos] == '\'') {
+			quote := p.input[p.pos]
+			end := p.pos + 1
+			for end < len(p.input) && p.input[end] != quote {
+				if p.input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(p.input) {
+				return "", fmt.Errorf("unterminated string literal")
+			}
+			literal := p.input[p.pos : end+1]
+			if quote == '\'' {
+				literal = `"` + strings.ReplaceAll(literal[1:len(literal)-1], `"`, `\"`) + `"`
+			}
+			unquoted, err := strconv.Unquote(literal)
+			if err != nil {
+				return "", fmt.Errorf("malformed string literal")
+			}
+			value.WriteString(unquoted)
+			p.pos = end + 1
+			p.skipSpace()
+		}
+		return value.String(), nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) && strings.IndexByte(" \t\r\n#{}", p.input[p.pos]) == -1 {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", fmt.Errorf("expected value at offset %d", p.pos)
+	}
+	return p.input[start:p.pos], nil
+}
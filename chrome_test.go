@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTextproto(t *testing.T) {
+	message, err := parseTextproto(`
+# comment
+trust_anchors {
+  sha256_hex: "00ff"
+  display_name: 'A' "B"
+  constraints { sct_not_after_sec: 1 }
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message) != 1 || message[0].name != "trust_anchors" || len(message[0].message) != 3 {
+		t.Fatalf("unexpected message %+v", message)
+	}
+	if name := message[0].message[1].value; name != "AB" {
+		t.Errorf("display_name is %q, want %q", name, "AB")
+	}
+}
+
+func TestParseTextprotoDepth(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("a {", depth) + strings.Repeat("}", depth)
+	}
+	if _, err := parseTextproto(nested(maxTextprotoDepth)); err != nil {
+		t.Errorf("depth %d: %v", maxTextprotoDepth, err)
+	}
+	if _, err := parseTextproto(nested(maxTextprotoDepth + 1)); err == nil {
+		t.Errorf("depth %d: parseTextproto succeeded, want error", maxTextprotoDepth+1)
+	}
+	if _, err := parseTextproto(nested(1_000_000)); err == nil {
+		t.Error("depth 1000000: parseTextproto succeeded, want error")
+	}
+}
+
+const testChromeSHA256 = "df545bf919a2439c36983b54cdfc903dfa4f37d3996d8d84b4c31eec6f3c163e"
+
+const testChromeTextproto = `
+trust_anchors {
+  sha256_hex: "` + testChromeSHA256 + `"
+  display_name: "Distrusted Root"
+  constraints { sct_not_after_sec: 1700000000 }
+}
+trust_anchors {
+  sha256_hex: "0000000000000000000000000000000000000000000000000000000000000001"
+  display_name: "Constrained Root"
+  constraints { min_version: "120" permitted_dns_names: "example.com" }
+}
+trust_anchors {
+  certificate_file: "root.pem"
+}
+`
+
+const testChromeJSON = `{"trustAnchors": [
+  {"sha256Hex": "` + testChromeSHA256 + `", "displayName": "Distrusted Root", "constraints": [{"sctNotAfterSec": "1700000000"}]},
+  {"sha256Hex": "0000000000000000000000000000000000000000000000000000000000000001", "displayName": "Constrained Root",
+   "constraints": [{"minVersion": "120", "permittedDnsNames": ["example.com"]}]},
+  {"certificateFile": "root.pem"}
+]}`
+
+func checkChromeRootStore(t *testing.T, store *RootStore) {
+	t.Helper()
+	if store.Name != "Chrome" {
+		t.Errorf("store name is %q", store.Name)
+	}
+	if len(store.Roots) != 2 {
+		t.Fatalf("store has %d roots, want 2", len(store.Roots))
+	}
+	distrusted, constrained := store.Roots[0], store.Roots[1]
+	if fmt.Sprintf("%x", distrusted.SHA256[:]) != testChromeSHA256 || distrusted.Name != "Distrusted Root" {
+		t.Errorf("first root is %x %q", distrusted.SHA256[:], distrusted.Name)
+	}
+	if !distrusted.DistrustAfter.Equal(time.Unix(1700000000, 0)) || len(distrusted.DistrustAfterEKUs) != 1 || !distrusted.DistrustAfterEKUs[0].Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}) {
+		t.Errorf("first root is distrusted after %s for %v", distrusted.DistrustAfter, distrusted.DistrustAfterEKUs)
+	}
+	if len(distrusted.Constraints) != 0 {
+		t.Errorf("first root has constraints %q", distrusted.Constraints)
+	}
+	if !constrained.DistrustAfter.IsZero() {
+		t.Errorf("second root is distrusted after %s", constrained.DistrustAfter)
+	}
+	if want := `{min_version: "120" permitted_dns_names: "example.com"}`; len(constrained.Constraints) != 1 || constrained.Constraints[0] != want {
+		t.Errorf("second root has constraints %q, want %q", constrained.Constraints, want)
+	}
+}
+
+func TestParseChromeRootStoreTextproto(t *testing.T) {
+	store, err := ParseChromeRootStoreTextproto([]byte(testChromeTextproto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChromeRootStore(t, store)
+}
+
+func TestParseChromeRootStoreJSON(t *testing.T) {
+	store, err := ParseChromeRootStoreJSON([]byte(testChromeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChromeRootStore(t, store)
+}
+
+func TestParseChromeRootStoreErrors(t *testing.T) {
+	for _, input := range []string{
+		`trust_anchors { sha256_hex: "00ff" }`,
+		`trust_anchors { sha256_hex: "` + testChromeSHA256 + `" constraints { sct_not_after_sec: soon } }`,
+		`trust_anchors { sha256_hex: "` + testChromeSHA256 + `"`,
+		`trust_anchors { display_name: "unterminated }`,
+	} {
+		if _, err := ParseChromeRootStoreTextproto([]byte(input)); err == nil {
+			t.Errorf("ParseChromeRootStoreTextproto(%q) succeeded", input)
+		}
+	}
+	if _, err := ParseChromeRootStoreJSON([]byte(`{"trustAnchors": [{"sha256Hex": "zz"}]}`)); err == nil {
+		t.Errorf("ParseChromeRootStoreJSON accepted a malformed sha256Hex")
+	}
+}
@@ -0,0 +1,443 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the location from which Windows downloads the trust lists
+const DefaultBaseURL = "http://ctldl.windowsupdate.com/msdownload/update/v3/static/trustedr/en/"
+
+// DefaultTimeout is the default time limit for each download attempt
+const DefaultTimeout = 2 * time.Minute
+
+// Client downloads trust lists from Microsoft or a mirror.  The zero value
+// is ready to use.  Trust lists are downloaded over plain HTTP, so FetchCTL,
+// FetchDisallowedCTL, and FetchPinRulesCTL verify their signatures unless
+// InsecureSkipVerify is set.
+type Client struct {
+	HTTPClient *http.Client  // if nil, http.DefaultClient is used
+	BaseURL    string        // URL of the directory containing authrootstl.cab; if empty, DefaultBaseURL is used
+	Timeout    time.Duration // time limit for each attempt; if zero, DefaultTimeout is used
+	Retries    int           // number of times to retry a failed download
+
+	// VerifyOptions are used to verify the signatures of trust lists.  If Roots
+	// is nil, Microsoft's root is downloaded with FetchMicrosoftRoot.
+	VerifyOptions VerifyOptions
+
+	// InsecureSkipVerify disables signature verification, so that a network
+	// attacker or a compromised mirror can supply any trust list
+	InsecureSkipVerify bool
+
+	// IntegrityPolicy specifies whether FetchCTL, FetchDisallowedCTL, and
+	// FetchPinRulesCTL require HTTPS, a verified signature, or both before
+	// returning a trust list.  The outcome is recorded in CTL.Integrity.
+	IntegrityPolicy IntegrityPolicy
+
+	// Cache, if non-nil, is used by FetchCTL, FetchDisallowedCTL, and
+	// FetchPinRulesCTL to avoid re-parsing a file which has not changed
+	Cache ParseCache
+
+	// TLSRoots, if non-nil, is used instead of the system roots to verify the
+	// server's certificate when BaseURL is an HTTPS URL (e.g. behind a TLS-inspecting
+	// proxy).  It has nothing to do with the roots in the trust list or the
+	// roots used to verify its signature.  If HTTPClient is set, its Transport
+	// must be nil or an *http.Transport, which is copied.
+	TLSRoots *x509.CertPool
+
+	// Logger, if non-nil, receives debug records for each download attempt, parse
+	// cache lookup, and signature verification, and a warning for each failed
+	// attempt which will be retried.  Errors which are returned are not logged
+	// at a higher level than debug.
+	Logger *slog.Logger
+
+	microsoftRootMu sync.Mutex
+	microsoftRoot   *x509.CertPool
+
+	tlsClientOnce sync.Once
+	tlsClient     *http.Client
+	tlsClientErr  error
+}
+
+// statusError is returned when the server responds with a non-200 status
+type statusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (err *statusError) Error() string {
+	return err.url + ": " + err.status
+}
+
+// FetchCTL downloads and parses authrootstl.cab
+func (client *Client) FetchCTL(ctx context.Context) (*CTL, error) {
+	return client.fetchCTL(ctx, "authrootstl.cab", ParseAuthrootstlCab)
+}
+
+// FetchDisallowedCTL downloads and parses disallowedcertstl.cab, which lists
+// certificates that Microsoft has explicitly distrusted
+func (client *Client) FetchDisallowedCTL(ctx context.Context) (*CTL, error) {
+	return client.fetchCTL(ctx, "disallowedcertstl.cab", ParseSTLCab)
+}
+
+// FetchCAB downloads the named CAB file, such as authrootstl.cab or
+// disallowedcertstl.cab, and returns its contents along with the CTL it contains.
+// The CTL is verified and checked against the integrity policy, as by FetchCTL.
+func (client *Client) FetchCAB(ctx context.Context, name string) ([]byte, *CTL, error) {
+	ctl, dl, err := client.fetchCTLDownload(ctx, name, ParseSTLCab)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dl.body, ctl, nil
+}
+
+// fetchCTL downloads the named CAB file and, if it satisfies the integrity
+// policy, parses it with parse
+func (client *Client) fetchCTL(ctx context.Context, name string, parse func(io.ReadSeeker, ...ParseOption) (*CTL, error)) (*CTL, error) {
+	ctl, _, err := client.fetchCTLDownload(ctx, name, parse)
+	return ctl, err
+}
+
+// fetchCTLDownload is like fetchCTL, but also returns the download
+func (client *Client) fetchCTLDownload(ctx context.Context, name string, parse func(io.ReadSeeker, ...ParseOption) (*CTL, error)) (*CTL, *download, error) {
+	dl, err := client.fetch(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	integrity := &Integrity{
+		URL:               dl.url,
+		HTTPS:             dl.https,
+		SignatureVerified: !client.InsecureSkipVerify, // parsing fails if the signature is invalid
+		Policy:            client.IntegrityPolicy,
+	}
+	if err := integrity.Check(client.IntegrityPolicy); err != nil {
+		client.logger().Debug("trust list rejected", "url", dl.url, "error", err)
+		return nil, dl, err
+	}
+	ctl, err := parse(bytes.NewReader(dl.body), client.parseOptions(ctx, integrity)...)
+	if err != nil {
+		client.logger().Debug("trust list rejected", "url", dl.url, "error", err)
+		return nil, dl, err
+	}
+	client.logger().Debug("trust list accepted", "url", dl.url, "sequence_number", fmt.Sprintf("%X", &ctl.SequenceNumber),
+		"signature_verified", integrity.SignatureVerified, "https", integrity.HTTPS)
+	return ctl, dl, nil
+}
+
+func (client *Client) parseOptions(ctx context.Context, integrity *Integrity) []ParseOption {
+	opts := []ParseOption{withIntegrity(integrity)}
+	if !client.InsecureSkipVerify {
+		verifyOptions := client.VerifyOptions
+		if verifyOptions.Roots == nil {
+			// if this fails, Verify falls back to a copy of the root in the SignedData
+			roots, err := client.FetchMicrosoftRoot(ctx)
+			if err != nil {
+				client.logger().Debug("Microsoft root unavailable", "error", err)
+			}
+			verifyOptions.Roots = roots
+		}
+		opts = append(opts, WithVerification(verifyOptions))
+	}
+	if client.Cache != nil && client.Logger != nil {
+		opts = append(opts, WithCache(&loggingCache{ParseCache: client.Cache, logger: client.Logger}))
+	} else if client.Cache != nil {
+		opts = append(opts, WithCache(client.Cache))
+	}
+	return opts
+}
+
+// FetchCertificate downloads the certificate for the given entry, and verifies that
+// it matches the entry's hashes
+func (client *Client) FetchCertificate(ctx context.Context, entry *Entry) ([]byte, error) {
+	certBytes, err := client.Fetch(ctx, CertificateFilename(entry))
+	if err != nil {
+		return nil, err
+	}
+	if err := entry.CheckCertificate(certBytes); err != nil {
+		return nil, err
+	}
+	return certBytes, nil
+}
+
+// CertificateFilename returns the name of the file, relative to the base URL,
+// containing the DER-encoded certificate for the given entry
+func CertificateFilename(entry *Entry) string {
+	return strings.ToUpper(entry.SHA1.Hex()) + ".crt"
+}
+
+// Fetch downloads the file with the given name, which is resolved relative to
+// the base URL (and may therefore also be an absolute URL).  Failed attempts
+// are retried, except when the server responds with a 4xx status.  If an attempt
+// is interrupted and the server supports range requests, the retry resumes
+// where the attempt left off, provided the file has not changed.
+func (client *Client) Fetch(ctx context.Context, name string) ([]byte, error) {
+	dl, err := client.fetch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return dl.body, nil
+}
+
+func (client *Client) fetch(ctx context.Context, name string) (*download, error) {
+	fileURL, err := client.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	logger := client.logger()
+	var dl download
+	for attempt := 0; ; attempt++ {
+		logger.Debug("download attempt", "url", fileURL, "attempt", attempt+1, "resume_from", len(dl.body))
+		err := client.fetchOnce(ctx, fileURL, &dl)
+		if err == nil {
+			logger.Debug("download complete", "url", dl.url, "bytes", len(dl.body), "https", dl.https)
+			return &dl, nil
+		}
+		if attempt >= client.Retries || ctx.Err() != nil || isClientError(err) {
+			logger.Debug("download failed", "url", fileURL, "attempt", attempt+1, "error", err)
+			return nil, err
+		}
+		delay := retryDelay(attempt)
+		logger.Warn("download attempt failed, retrying", "url", fileURL, "attempt", attempt+1, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func (client *Client) logger() *slog.Logger {
+	if client.Logger == nil {
+		return discardLogger
+	}
+	return client.Logger
+}
+
+func (client *Client) resolve(name string) (string, error) {
+	baseURL := client.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	ref, err := url.Parse(name)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// download is the progress of a download across attempts
+type download struct {
+	body      []byte // bytes received so far
+	validator string // ETag or Last-Modified of the file, for If-Range
+	resumable bool   // whether the server accepts range requests for the file
+	url       string // URL of the file, after any redirects
+	https     bool   // whether every byte was received over HTTPS with a verified certificate
+}
+
+// fetchOnce makes one attempt to download fileURL, resuming dl if possible.
+// Whatever is received is added to dl, even if the attempt fails.
+func (client *Client) fetchOnce(ctx context.Context, fileURL string, dl *download) error {
+	timeout := client.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return err
+	}
+	resuming := dl.resumable && len(dl.body) > 0
+	if resuming {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(dl.body)))
+		request.Header.Set("If-Range", dl.validator)
+	}
+	httpClient, err := client.httpClient()
+	if err != nil {
+		return err
+	}
+	everyHopHTTPS := true
+	httpClient = client.trackRedirects(httpClient, &everyHopHTTPS)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	https := request.URL.Scheme == "https" && everyHopHTTPS && verifiedTLS(response.TLS)
+	switch {
+	case response.StatusCode == http.StatusPartialContent && resuming:
+		if start, ok := contentRangeStart(response.Header.Get("Content-Range")); !ok || start != len(dl.body) {
+			dl.body, dl.resumable = nil, false
+			return fmt.Errorf("%s: server responded with unexpected Content-Range %q", fileURL, response.Header.Get("Content-Range"))
+		}
+		dl.https = dl.https && https
+	case response.StatusCode == http.StatusOK:
+		dl.body = nil
+		dl.validator = response.Header.Get("ETag")
+		if dl.validator == "" || strings.HasPrefix(dl.validator, "W/") {
+			dl.validator = response.Header.Get("Last-Modified")
+		}
+		dl.resumable = dl.validator != "" && response.Header.Get("Accept-Ranges") == "bytes"
+		dl.url = response.Request.URL.String()
+		dl.https = https
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && resuming:
+		dl.body, dl.resumable = nil, false
+		return fmt.Errorf("%s: %s when resuming download", fileURL, response.Status)
+	default:
+		return &statusError{url: fileURL, status: response.Status, code: response.StatusCode}
+	}
+	dl.body, err = readAllAppend(dl.body, response.Body)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%s: %w: %w", fileURL, ErrTruncated, err)
+	} else if err != nil {
+		return fmt.Errorf("%s: %w", fileURL, err)
+	}
+	return nil
+}
+
+// readAllAppend is like io.ReadAll, but appends to buf, which it returns even if
+// there is an error
+func readAllAppend(buf []byte, r io.Reader) ([]byte, error) {
+	for {
+		if len(buf) == cap(buf) {
+			buf = slices.Grow(buf, 512)
+		}
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			return buf, nil
+		} else if err != nil {
+			return buf, err
+		}
+	}
+}
+
+// contentRangeStart returns the first byte position in a Content-Range header
+// value of the form "bytes START-END/LENGTH"
+func contentRangeStart(contentRange string) (int, bool) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(start)
+	return n, err == nil && n >= 0
+}
+
+func (client *Client) httpClient() (*http.Client, error) {
+	baseClient := client.HTTPClient
+	if baseClient == nil {
+		baseClient = http.DefaultClient
+	}
+	if client.TLSRoots == nil {
+		return baseClient, nil
+	}
+	client.tlsClientOnce.Do(func() {
+		var transport *http.Transport
+		switch t := baseClient.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			client.tlsClientErr = fmt.Errorf("TLSRoots can't be used with an HTTP client whose transport is a %T", t)
+			return
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = new(tls.Config)
+		}
+		transport.TLSClientConfig.RootCAs = client.TLSRoots
+		tlsClient := *baseClient
+		tlsClient.Transport = transport
+		client.tlsClient = &tlsClient
+	})
+	return client.tlsClient, client.tlsClientErr
+}
+
+// trackRedirects returns a copy of httpClient which clears *everyHopHTTPS if a
+// redirect is received other than over HTTPS with a verified certificate.  It refuses
+// to follow a redirect to a plain HTTP URL from an HTTPS URL, or at all if the
+// integrity policy requires HTTPS.
+func (client *Client) trackRedirects(httpClient *http.Client, everyHopHTTPS *bool) *http.Client {
+	tracking := *httpClient
+	tracking.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if request.Response == nil || !verifiedTLS(request.Response.TLS) {
+			*everyHopHTTPS = false
+		}
+		if request.URL.Scheme != "https" && (via[0].URL.Scheme == "https" || client.IntegrityPolicy.requiresHTTPS()) {
+			return fmt.Errorf("refusing to follow redirect from %s to non-HTTPS URL %s", via[len(via)-1].URL, request.URL)
+		}
+		if httpClient.CheckRedirect != nil {
+			return httpClient.CheckRedirect(request, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &tracking
+}
+
+func verifiedTLS(state *tls.ConnectionState) bool {
+	return state != nil && len(state.VerifiedChains) > 0
+}
+
+func isClientError(err error) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.code >= 400 && statusErr.code < 500
+}
+
+func retryDelay(attempt int) time.Duration {
+	return min(time.Second<<attempt, time.Minute)
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testFile = bytes.Repeat([]byte("0123456789"), 100)
+
+// newResumeServer returns a server which truncates the first response for
+// testFile halfway through, and answers range requests with a Content-Range
+// starting at the requested offset plus skew
+func newResumeServer(t *testing.T, skew int) (*httptest.Server, *[]string) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Accept-Ranges", "bytes")
+		if len(ranges) == 1 {
+			w.Header().Set("Content-Length", fmt.Sprint(len(testFile)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(testFile[:len(testFile)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err != nil {
+			w.Write(testFile)
+			return
+		}
+		if r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("If-Range is %q", r.Header.Get("If-Range"))
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start+skew, len(testFile)-1, len(testFile)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(testFile[start+skew:])
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+func TestFetchResumes(t *testing.T) {
+	server, ranges := newResumeServer(t, 0)
+	client := &Client{BaseURL: server.URL + "/", Retries: 1}
+	body, err := client.Fetch(context.Background(), "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, testFile) {
+		t.Errorf("resumed download is wrong (%d bytes)", len(body))
+	}
+	if want := []string{"", fmt.Sprintf("bytes=%d-", len(testFile)/2)}; strings.Join(*ranges, ",") != strings.Join(want, ",") {
+		t.Errorf("requested ranges %q, want %q", *ranges, want)
+	}
+}
+
+func TestFetchRejectsMismatchedContentRange(t *testing.T) {
+	server, _ := newResumeServer(t, 1)
+	client := &Client{BaseURL: server.URL + "/", Retries: 1}
+	_, err := client.Fetch(context.Background(), "file")
+	if err == nil || !strings.Contains(err.Error(), "unexpected Content-Range") {
+		t.Fatalf("Fetch returned %v, want an unexpected Content-Range error", err)
+	}
+}
+
+func TestFetchRestartsAfterMismatchedContentRange(t *testing.T) {
+	server, ranges := newResumeServer(t, 1)
+	client := &Client{BaseURL: server.URL + "/", Retries: 2}
+	body, err := client.Fetch(context.Background(), "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, testFile) {
+		t.Errorf("restarted download is wrong (%d bytes)", len(body))
+	}
+	if len(*ranges) != 3 || (*ranges)[2] != "" {
+		t.Errorf("requested ranges %q, want the third request to be for the whole file", *ranges)
+	}
+}
+
+// newRedirectServers returns an HTTPS server which serves authrootstl.cab, and a
+// plain HTTP server which redirects to it, along with roots which trust the HTTPS server
+func newRedirectServers(t *testing.T) (httpsServer, httpServer *httptest.Server, roots *x509.CertPool) {
+	httpsServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/redirect/") {
+			http.Redirect(w, r, httpServer.URL+"/"+strings.TrimPrefix(r.URL.Path, "/redirect/"), http.StatusFound)
+			return
+		}
+		w.Write(testFile)
+	}))
+	t.Cleanup(httpsServer.Close)
+	httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/redirect/") {
+			http.Redirect(w, r, httpsServer.URL+"/"+strings.TrimPrefix(r.URL.Path, "/redirect/"), http.StatusFound)
+			return
+		}
+		w.Write(testFile)
+	}))
+	t.Cleanup(httpServer.Close)
+	roots = x509.NewCertPool()
+	roots.AddCert(httpsServer.Certificate())
+	return httpsServer, httpServer, roots
+}
+
+func TestFetchOverHTTPS(t *testing.T) {
+	httpsServer, _, roots := newRedirectServers(t)
+	client := &Client{BaseURL: httpsServer.URL + "/", TLSRoots: roots}
+	dl, err := client.fetch(context.Background(), "authrootstl.cab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dl.https {
+		t.Errorf("download over HTTPS was not recorded as HTTPS")
+	}
+}
+
+func TestRedirectFromHTTPFailsRequireHTTPS(t *testing.T) {
+	_, httpServer, roots := newRedirectServers(t)
+	client := &Client{BaseURL: httpServer.URL + "/redirect/", TLSRoots: roots, IntegrityPolicy: RequireHTTPS}
+	dl, err := client.fetch(context.Background(), "authrootstl.cab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.https {
+		t.Errorf("download redirected from plain HTTP was recorded as HTTPS")
+	}
+	if _, err := client.FetchCTL(context.Background()); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("FetchCTL returned %v, want ErrIntegrity", err)
+	}
+}
+
+func TestRedirectToHTTPIsRefused(t *testing.T) {
+	httpsServer, _, roots := newRedirectServers(t)
+	client := &Client{BaseURL: httpsServer.URL + "/redirect/", TLSRoots: roots}
+	if _, err := client.fetch(context.Background(), "authrootstl.cab"); err == nil {
+		t.Errorf("redirect from HTTPS to plain HTTP was followed")
+	}
+}
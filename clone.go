@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"slices"
+)
+
+// Clone returns a deep copy of the CTL, which shares no memory with the original
+// and can be modified without affecting goroutines using the original
+func (ctl *CTL) Clone() *CTL {
+	clone := &CTL{
+		Raw:              bytes.Clone(ctl.Raw),
+		Version:          ctl.Version,
+		SubjectUsage:     cloneOIDs(ctl.SubjectUsage),
+		ListIdentifier:   bytes.Clone(ctl.ListIdentifier),
+		EffectiveDate:    ctl.EffectiveDate,
+		NextUpdate:       ctl.NextUpdate,
+		SubjectAlgorithm: slices.Clone(ctl.SubjectAlgorithm),
+		CTLogsVersion:    slices.Clone(ctl.CTLogsVersion),
+		Warnings:         slices.Clone(ctl.Warnings),
+		Integrity:        cloneIntegrity(ctl.Integrity),
+		rawEntries:       bytes.Clone(ctl.rawEntries),
+	}
+	clone.SequenceNumber.Set(&ctl.SequenceNumber)
+	if ctl.Entries != nil {
+		clone.Entries = make([]Entry, len(ctl.Entries))
+		for i := range ctl.Entries {
+			clone.Entries[i] = ctl.Entries[i].Clone()
+		}
+	}
+	if ctl.Extensions != nil {
+		clone.Extensions = make([]Extension, len(ctl.Extensions))
+		for i, extension := range ctl.Extensions {
+			clone.Extensions[i] = Extension{
+				ID:       slices.Clone(extension.ID),
+				Critical: extension.Critical,
+				Value:    bytes.Clone(extension.Value),
+			}
+		}
+	}
+	if ctl.CTLogs != nil {
+		clone.CTLogs = make([]CTLogKey, len(ctl.CTLogs))
+		for i, key := range ctl.CTLogs {
+			clone.CTLogs[i] = bytes.Clone(key)
+		}
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the entry, which shares no memory with the original,
+// except for the values in Attribute.Decoded, which are copied shallowly
+func (entry *Entry) Clone() Entry {
+	clone := *entry
+	clone.SubjectIdentifier = bytes.Clone(entry.SubjectIdentifier)
+	clone.KeyID = bytes.Clone(entry.KeyID)
+	clone.SubjectNameMD5 = bytes.Clone(entry.SubjectNameMD5)
+	clone.EKUs = cloneOIDs(entry.EKUs)
+	clone.DisallowedEKUs = cloneOIDs(entry.DisallowedEKUs)
+	clone.NotBeforeEKUs = cloneOIDs(entry.NotBeforeEKUs)
+	if entry.Attributes != nil {
+		clone.Attributes = make([]Attribute, len(entry.Attributes))
+		for i, attribute := range entry.Attributes {
+			clone.Attributes[i].Type = slices.Clone(attribute.Type)
+			if attribute.Values != nil {
+				clone.Attributes[i].Values = make([][]byte, len(attribute.Values))
+				for j, value := range attribute.Values {
+					clone.Attributes[i].Values[j] = bytes.Clone(value)
+				}
+			}
+			clone.Attributes[i].Decoded = slices.Clone(attribute.Decoded)
+		}
+	}
+	return clone
+}
+
+func cloneOIDs(oids []asn1.ObjectIdentifier) []asn1.ObjectIdentifier {
+	if oids == nil {
+		return nil
+	}
+	clone := make([]asn1.ObjectIdentifier, len(oids))
+	for i, oid := range oids {
+		clone[i] = slices.Clone(oid)
+	}
+	return clone
+}
+
+func cloneIntegrity(integrity *Integrity) *Integrity {
+	if integrity == nil {
+		return nil
+	}
+	clone := *integrity
+	return &clone
+}
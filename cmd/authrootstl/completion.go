@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const bashCompletion = `_authrootstl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+	elif [ "${COMP_WORDS[1]}" = completion ]; then
+		COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+	elif [ "${COMP_WORDS[1]}" = help ]; then
+		COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+	elif [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$("${COMP_WORDS[0]}" __flags "${COMP_WORDS[1]}" 2>/dev/null)" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -f -- "$cur"))
+	fi
+}
+complete -o filenames -F _authrootstl authrootstl
+`
+
+const zshCompletion = `#compdef authrootstl
+autoload -U +X bashcompinit && bashcompinit
+`
+
+func writeCompletion(w io.Writer, shell string) error {
+	names := subcommandNames()
+	switch shell {
+	case "bash":
+		_, err := fmt.Fprintf(w, bashCompletion, strings.Join(append(names, "completion", "help"), " "))
+		return err
+	case "zsh":
+		if _, err := io.WriteString(w, zshCompletion); err != nil {
+			return err
+		}
+		return writeCompletion(w, "bash")
+	case "fish":
+		var b strings.Builder
+		for _, name := range names {
+			fmt.Fprintf(&b, "complete -c authrootstl -n __fish_use_subcommand -f -a %s -d %s\n", name, fishQuote(subcommands[name].description))
+		}
+		b.WriteString("complete -c authrootstl -n __fish_use_subcommand -f -a completion -d 'Print a shell completion script'\n")
+		b.WriteString("complete -c authrootstl -n __fish_use_subcommand -f -a help -d 'Show usage'\n")
+		b.WriteString("complete -c authrootstl -n '__fish_seen_subcommand_from completion' -f -a 'bash zsh fish'\n")
+		fmt.Fprintf(&b, "complete -c authrootstl -n '__fish_seen_subcommand_from help' -f -a %s\n", fishQuote(strings.Join(names, " ")))
+		b.WriteString("complete -c authrootstl -n 'not __fish_use_subcommand; and string match -q -- \"-*\" (commandline -ct)' -a '(authrootstl __flags (commandline -opc)[2] 2>/dev/null)'\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	default:
+		return fmt.Errorf("unknown shell %q (expected bash, zsh, or fish)", shell)
+	}
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
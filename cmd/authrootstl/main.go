@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Run the authrootstl commands as subcommands of a single binary
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"software.sslmate.com/src/authrootstl/internal/cli/msftcdn"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftcerts"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftexport"
+	"software.sslmate.com/src/authrootstl/internal/cli/msfthistory"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftlint"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftlogs"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftmirror"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftpins"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftquery"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftreport"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftroots"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftserve"
+	"software.sslmate.com/src/authrootstl/internal/cli/msftwatch"
+	"software.sslmate.com/src/authrootstl/internal/cli/stldiff"
+	"software.sslmate.com/src/authrootstl/internal/cli/stldump"
+	"software.sslmate.com/src/authrootstl/internal/cli/stlverify"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+type subcommand struct {
+	command     string   // standalone command which implements the subcommand, for the configuration file
+	args        []string // arguments inserted before those on the command line
+	main        func()
+	description string
+}
+
+var subcommands = map[string]subcommand{
+	"cdn":        {"msftcdn", nil, msftcdn.Main, "Check that Microsoft's CDN locales and mirrors serve the same trust list"},
+	"certs":      {"msftcerts", nil, msftcerts.Main, "Download the root certificates trusted by Microsoft"},
+	"diff":       {"stldiff", nil, stldiff.Main, "Show the differences between two trust lists"},
+	"disallowed": {"msftroots", []string{"-list", "disallowed"}, msftroots.Main, "List the certificates distrusted by Microsoft"},
+	"dump":       {"stldump", nil, stldump.Main, "Decode an STL file in human-readable form"},
+	"export":     {"msftexport", nil, msftexport.Main, "Export the root certificates trusted by Microsoft in various formats"},
+	"history":    {"msfthistory", nil, msfthistory.Main, "Record the history of Microsoft's trust list and query past changes"},
+	"lint":       {"msftlint", nil, msftlint.Main, "Lint the root certificates trusted by Microsoft"},
+	"logs":       {"msftlogs", nil, msftlogs.Main, "List the CT logs recognized by Microsoft"},
+	"mirror":     {"msftmirror", nil, msftmirror.Main, "Maintain a local mirror of Microsoft's trust lists and root certificates"},
+	"pins":       {"msftpins", nil, msftpins.Main, "List Microsoft's certificate pin rules"},
+	"query":      {"msftquery", nil, msftquery.Main, "Look up a certificate's status in Microsoft's trust lists"},
+	"report":     {"msftreport", nil, msftreport.Main, "Report on the root certificates trusted by Microsoft"},
+	"roots":      {"msftroots", nil, msftroots.Main, "List the root certificates trusted by Microsoft"},
+	"serve":      {"msftserve", nil, msftserve.Main, "Serve Microsoft's trust lists as JSON over HTTP, keeping them up to date"},
+	"verify":     {"stlverify", nil, stlverify.Main, "Verify the signature and freshness of an STL file"},
+	"watch":      {"msftwatch", nil, msftwatch.Main, "Watch for changes to Microsoft's trust list and send notifications"},
+}
+
+func subcommandNames() []string {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s SUBCOMMAND [flags] [args]\n\nSubcommands:\n", os.Args[0])
+	for _, name := range subcommandNames() {
+		fmt.Fprintf(w, "  %-12s%s\n", name, subcommands[name].description)
+	}
+	fmt.Fprintf(w, "  %-12s%s\n", "completion", "Print a shell completion script (bash, zsh, or fish)")
+	fmt.Fprintf(w, "\nRun '%s help SUBCOMMAND' for the flags accepted by each subcommand.\n", os.Args[0])
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) == 0 {
+			usage(os.Stdout)
+			return
+		}
+		name, args = args[0], []string{"-h"}
+	case "completion":
+		if len(args) != 1 {
+			log.Fatal("usage: completion bash|zsh|fish")
+		}
+		if err := writeCompletion(os.Stdout, args[0]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "__flags":
+		// Used by the completion scripts to list the flags of a subcommand
+		if len(args) != 1 {
+			os.Exit(2)
+		}
+		name, args = args[0], nil
+		cmdutil.ListFlags = true
+	}
+
+	sub, ok := subcommands[name]
+	if !ok {
+		log.Printf("unknown subcommand %q", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	os.Args = append([]string{os.Args[0] + " " + name}, append(sub.args, args...)...)
+	cmdutil.Command = sub.command
+	sub.main()
+}
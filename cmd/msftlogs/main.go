@@ -28,55 +28,8 @@
 // List the CT logs recognized by Microsoft
 package main
 
-import (
-	"bytes"
-	"context"
-	"crypto/sha256"
-	"encoding/base64"
-	"fmt"
-	"io"
-	"log"
-	"net/http"
-	"os"
-	"time"
-
-	"software.sslmate.com/src/authrootstl"
-)
+import "software.sslmate.com/src/authrootstl/internal/cli/msftlogs"
 
 func main() {
-	log.SetFlags(0)
-	log.SetPrefix(os.Args[0] + ": ")
-
-	ctl, err := fetchCTL(context.Background())
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	for _, logKey := range ctl.CTLogs {
-		keyID := sha256.Sum256(logKey)
-		fmt.Println(base64.StdEncoding.EncodeToString(keyID[:]))
-	}
-}
-
-func fetchCTL(ctx context.Context) (*authrootstl.CTL, error) {
-	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(2*time.Minute))
-	defer cancel()
-
-	request, err := http.NewRequestWithContext(ctx, "GET", "http://ctldl.windowsupdate.com/msdownload/update/v3/static/trustedr/en/authrootstl.cab", nil)
-	if err != nil {
-		return nil, err
-	}
-	response, err := http.DefaultClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer response.Body.Close()
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("%s: %s", request.URL, response.Status)
-	}
-	bodyBytes, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", request.URL, err)
-	}
-	return authrootstl.ParseAuthrootstlCab(bytes.NewReader(bodyBytes))
+	msftlogs.Main()
 }
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Parse trust lists with a JSON-in, JSON-out interface.  Built with GOOS=js
+// GOARCH=wasm, it defines a global JavaScript function authrootstlParse, which
+// takes a request as a JSON string and returns the response as a JSON string,
+// for browser-based trust list viewers.  Built for any other platform, it reads
+// a request from standard input and writes the response to standard output.
+//
+// A request is an object with a "data" member containing the base64-encoded CAB
+// or STL file.  The response has "ctl", "entries", "ct_logs", and "warnings"
+// members on success, and an "error" member on failure.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/ctljson"
+)
+
+type request struct {
+	Data []byte `json:"data"` // CAB or STL file
+}
+
+type response struct {
+	CTL      *ctljson.CTL    `json:"ctl,omitempty"`
+	Entries  []ctljson.Entry `json:"entries,omitempty"`
+	CTLogs   []ctljson.Log   `json:"ct_logs,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// handle parses the request and returns the response, as JSON
+func handle(requestJSON []byte) []byte {
+	resp, err := parse(requestJSON)
+	if err != nil {
+		resp = &response{Error: err.Error()}
+	}
+	responseJSON, err := json.Marshal(resp)
+	if err != nil {
+		responseJSON, _ = json.Marshal(&response{Error: err.Error()})
+	}
+	return responseJSON
+}
+
+func parse(requestJSON []byte) (*response, error) {
+	var req request
+	if err := json.Unmarshal(requestJSON, &req); err != nil {
+		return nil, err
+	}
+	var ctl *authrootstl.CTL
+	var err error
+	if bytes.HasPrefix(req.Data, []byte("MSCF")) {
+		ctl, err = authrootstl.ParseSTLCab(bytes.NewReader(req.Data))
+	} else {
+		ctl, err = authrootstl.ParseAuthrootstl(req.Data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary := ctljson.NewCTL(ctl)
+	resp := &response{
+		CTL:     &summary,
+		Entries: ctljson.NewEntries(ctl.Entries),
+		CTLogs:  ctljson.NewLogs(ctl.CTLogs),
+	}
+	for _, warning := range ctl.Warnings {
+		resp.Warnings = append(resp.Warnings, warning.Error())
+	}
+	return resp, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"slices"
+)
+
+// EntryFields selects decoded fields of Entry for WithEntryFields
+type EntryFields uint
+
+const (
+	EntrySHA256         EntryFields = 1 << iota // SHA256
+	EntryFriendlyName                           // FriendlyName
+	EntryKeyID                                  // KeyID
+	EntrySubjectNameMD5                         // SubjectNameMD5
+	EntryEKUs                                   // EKUs
+	EntryDisallowed                             // DisallowedDate and DisallowedEKUs
+	EntryNotBefore                              // NotBeforeDate and NotBeforeEKUs
+)
+
+// compact clears the fields of entry which are not selected by fields, along with
+// Attributes, and copies the byte slices which remain so they don't refer to the input
+func (entry *Entry) compact(fields EntryFields) {
+	compacted := Entry{
+		SubjectIdentifier: bytes.Clone(entry.SubjectIdentifier),
+		SHA1:              entry.SHA1,
+	}
+	if fields&EntrySHA256 != 0 {
+		compacted.SHA256 = entry.SHA256
+	}
+	if fields&EntryFriendlyName != 0 {
+		compacted.FriendlyName = entry.FriendlyName
+	}
+	if fields&EntryKeyID != 0 {
+		compacted.KeyID = bytes.Clone(entry.KeyID)
+	}
+	if fields&EntrySubjectNameMD5 != 0 {
+		compacted.SubjectNameMD5 = bytes.Clone(entry.SubjectNameMD5)
+	}
+	if fields&EntryEKUs != 0 {
+		compacted.EKUs = entry.EKUs
+	}
+	if fields&EntryDisallowed != 0 {
+		compacted.DisallowedDate = entry.DisallowedDate
+		compacted.DisallowedEKUs = entry.DisallowedEKUs
+	}
+	if fields&EntryNotBefore != 0 {
+		compacted.NotBeforeDate = entry.NotBeforeDate
+		compacted.NotBeforeEKUs = entry.NotBeforeEKUs
+	}
+	*entry = compacted
+}
+
+// compact copies the byte slices of the CTL outside of its entries, so they
+// don't refer to the input, and clears Raw
+func (ctl *CTL) compact() {
+	ctl.Raw = nil
+	ctl.rawEntries = nil
+	ctl.ListIdentifier = bytes.Clone(ctl.ListIdentifier)
+	ctl.Extensions = slices.Clone(ctl.Extensions)
+	for i := range ctl.Extensions {
+		ctl.Extensions[i].Value = bytes.Clone(ctl.Extensions[i].Value)
+	}
+	for i := range ctl.CTLogs {
+		ctl.CTLogs[i] = bytes.Clone(ctl.CTLogs[i])
+	}
+}
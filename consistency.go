@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import "time"
+
+// AnomalyKind is the kind of a ConsistencyAnomaly
+type AnomalyKind int
+
+const (
+	// The same certificate is listed in both CTLs
+	AnomalySameCertificate AnomalyKind = iota
+	// Different certificates with the same subject key identifier are listed in both CTLs
+	AnomalySameKey
+)
+
+func (kind AnomalyKind) String() string {
+	switch kind {
+	case AnomalySameCertificate:
+		return "same-certificate"
+	case AnomalySameKey:
+		return "same-key"
+	default:
+		return "unknown"
+	}
+}
+
+// ConsistencyAnomaly is a root in the authroot list which matches an entry in
+// the disallowed list
+type ConsistencyAnomaly struct {
+	Kind       AnomalyKind
+	Root       *Entry // from the authroot list
+	Disallowed *Entry // from the disallowed list
+
+	// StillTrusted is true if, at the time passed to CheckConsistency, the authroot
+	// list neither disallows the root nor restricts it with a NotBefore date.  Such
+	// a root is trusted by the authroot list despite being disallowed.
+	StillTrusted bool
+}
+
+// CheckConsistency compares the authroot list with the disallowed list
+// (disallowedcert.stl) and returns the roots which are listed in both, by
+// certificate hash or by subject key identifier, in the order of authroot's entries
+func CheckConsistency(authroot, disallowed *CTL, at time.Time) []ConsistencyAnomaly {
+	byKeyID := make(map[string]*Entry)
+	for i := range disallowed.Entries {
+		if entry := &disallowed.Entries[i]; len(entry.KeyID) > 0 {
+			byKeyID[string(entry.KeyID)] = entry
+		}
+	}
+	var anomalies []ConsistencyAnomaly
+	stillTrusted := func(root *Entry) bool {
+		return NotDisallowedAt(at)(root) && root.NotBeforeDate.IsZero()
+	}
+	for i := range authroot.Entries {
+		root := &authroot.Entries[i]
+		var match *Entry
+		if !root.SHA1.IsZero() {
+			match = disallowed.FindBySHA1(root.SHA1)
+		}
+		if match == nil && !root.SHA256.IsZero() {
+			match = disallowed.FindBySHA256(root.SHA256)
+		}
+		if match != nil {
+			anomalies = append(anomalies, ConsistencyAnomaly{Kind: AnomalySameCertificate, Root: root, Disallowed: match, StillTrusted: stillTrusted(root)})
+			continue
+		}
+		if match := byKeyID[string(root.KeyID)]; len(root.KeyID) > 0 && match != nil {
+			anomalies = append(anomalies, ConsistencyAnomaly{Kind: AnomalySameKey, Root: root, Disallowed: match, StillTrusted: stillTrusted(root)})
+		}
+	}
+	return anomalies
+}
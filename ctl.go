@@ -29,6 +29,7 @@
 package authrootstl
 
 import (
+	"bytes"
 	"encoding/asn1"
 	"fmt"
 	"math/big"
@@ -38,86 +39,238 @@ import (
 	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
 )
 
+// CTL is a parsed certificate trust list.  Nothing in this package modifies a CTL
+// after parsing it, so a CTL may be shared by any number of goroutines
+// provided none of them modify it.  To modify a CTL which may be shared (such as one
+// from a ParseCache), modify a Clone instead.
 type CTL struct {
-	SequenceNumber big.Int
-	EffectiveDate  time.Time
-	CTLogsVersion  []int32
-	CTLogs         [][]byte
+	Raw              []byte // DER encoding of the complete STL file, including the signature
+	Version          int
+	SubjectUsage     []asn1.ObjectIdentifier
+	ListIdentifier   []byte
+	SequenceNumber   big.Int
+	EffectiveDate    time.Time
+	NextUpdate       time.Time // zero if not present
+	SubjectAlgorithm asn1.ObjectIdentifier
+	Entries          []Entry
+	Extensions       []Extension // all extensions, including unrecognized ones
+	CTLogsVersion    []int32
+	CTLogs           []CTLogKey
+
+	// Warnings describes anomalies which did not prevent parsing, such as
+	// implausible dates, duplicate entries, unrecognized attributes and
+	// extensions, and trailing bytes.  Test for their kind with errors.Is
+	// (ErrImplausibleDate, ErrDuplicate, ErrUnrecognized, ErrUnusualEncoding).
+	// See WithStrictChecks.
+	Warnings []error
+
+	// Integrity records how the integrity of the trust list was established
+	// when it was downloaded by a Client, and is nil otherwise.  With Client.Cache,
+	// it describes the current download even if the CTL came from the cache.
+	Integrity *Integrity
+
+	rawEntries cryptobyte.String // contents of the entries SEQUENCE, for AllEntries
+	index      entryIndex
 }
 
-func ParseAuthrootstl(der cryptobyte.String) (*CTL, error) {
-	_, content, err := parsePKCS7(der)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing PKCS#7: %w", err)
-	}
-	ctl, err := parseCTL(content)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing CTL: %w", err)
-	}
-	return ctl, nil
+// Extension is an extension of the CTL
+type Extension struct {
+	ID       asn1.ObjectIdentifier
+	Critical bool
+	Value    []byte // contents of the OCTET STRING
 }
 
-func parsePKCS7(der cryptobyte.String) (asn1.ObjectIdentifier, []byte, error) {
-	var sequence cryptobyte.String
-	if !der.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
-		return nil, nil, fmt.Errorf("malformed SEQUENCE")
+var (
+	oidCTL             = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 1}
+	oidCTLogsExtension = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 52}
+)
+
+// ParseAuthrootstl parses a DER-encoded STL file.  Unless the WithZeroCopy option is
+// given, the returned CTL refers to a copy of der rather than to der itself.
+func ParseAuthrootstl(der cryptobyte.String, opts ...ParseOption) (*CTL, error) {
+	return parseAuthrootstl(der, newParseOptions(opts))
+}
+
+// ParseAuthrootstlWithoutEntries is equivalent to ParseAuthrootstl with the WithoutEntries option.
+//
+// Deprecated: use ParseAuthrootstl(der, WithoutEntries()).
+func ParseAuthrootstlWithoutEntries(der cryptobyte.String, opts ...ParseOption) (*CTL, error) {
+	return ParseAuthrootstl(der, append(opts, WithoutEntries())...)
+}
+
+func parseAuthrootstl(der cryptobyte.String, opts *parseOptions) (*CTL, error) {
+	if opts.limits.MaxSize != 0 && len(der) > opts.limits.MaxSize {
+		return nil, fmt.Errorf("STL file is %d bytes, which exceeds the limit of %d", len(der), opts.limits.MaxSize)
 	}
-	if !sequence.SkipASN1(cryptobyte_asn1.OBJECT_IDENTIFIER) {
-		return nil, nil, fmt.Errorf("malformed OBJECT IDENTIFIER")
+	// with WithEntryFunc, the entries must be decoded so fn can be called
+	useCache := opts.cache != nil && opts.entryFunc == nil
+	var cacheKey SHA256Fingerprint
+	if useCache {
+		cacheKey = opts.cacheKey(der)
+		if ctl, ok := opts.cache.Get(cacheKey); ok {
+			if opts.verify != nil {
+				// the signature is checked again, since the verification options
+				// (or the current time) may differ from those of the cached parse
+				signedData, err := parseSignedData(der, false, nil)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing PKCS#7: %w", err)
+				}
+				if _, err := signedData.Verify(*opts.verify); err != nil {
+					return nil, err
+				}
+			}
+			if opts.integrity != nil {
+				// the cached CTL is shared, and Integrity describes only this download
+				ctl = ctl.Clone()
+				ctl.Integrity = opts.integrity
+			}
+			return ctl, nil
+		}
 	}
-	if !sequence.ReadASN1(&sequence, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
-		return nil, nil, fmt.Errorf("malformed SEQUENCE 2")
+	if err := checkASN1Depth(der, opts.limits.maxDepth()); err != nil {
+		return nil, err
 	}
-	if !sequence.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
-		return nil, nil, fmt.Errorf("malformed SEQUENCE 3")
+	if !opts.zeroCopy && !opts.compact {
+		der = bytes.Clone(der)
 	}
-	if !sequence.SkipASN1(cryptobyte_asn1.INTEGER) {
-		return nil, nil, fmt.Errorf("malformed INTEGER")
+	var encodingWarnings []error
+	signedData, err := parseSignedData(der, opts.strictDER, &encodingWarnings)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PKCS#7: %w", err)
 	}
-	if !sequence.SkipASN1(cryptobyte_asn1.SET) {
-		return nil, nil, fmt.Errorf("malformed SET")
+	if opts.strictChecks && len(encodingWarnings) > 0 {
+		return nil, fmt.Errorf("error parsing PKCS#7: %w", encodingWarnings[0])
 	}
-	if !sequence.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
-		return nil, nil, fmt.Errorf("malformed SEQUENCE 4")
+	if !signedData.ContentType.Equal(oidCTL) {
+		return nil, &UnexpectedContentTypeError{ContentType: signedData.ContentType}
+	} else if signedData.Content == nil {
+		return nil, fmt.Errorf("%w: SignedData has no content", ErrNotCTL)
 	}
-	var oid asn1.ObjectIdentifier
-	if !sequence.ReadASN1ObjectIdentifier(&oid) {
-		return nil, nil, fmt.Errorf("malformed content OBJECT IDENTIFIER")
+	if opts.verify != nil {
+		if _, err := signedData.Verify(*opts.verify); err != nil {
+			return nil, err
+		}
 	}
-	if !sequence.ReadASN1(&sequence, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
-		return nil, nil, fmt.Errorf("malformed SEQUENCE 5")
+	ctl, err := parseCTL(signedData.Content, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CTL: %w", err)
+	}
+	if len(encodingWarnings) > 0 {
+		ctl.Warnings = append(encodingWarnings, ctl.Warnings...)
 	}
-	var content cryptobyte.String
-	var contentTag cryptobyte_asn1.Tag
-	if !sequence.ReadAnyASN1Element(&content, &contentTag) {
-		return nil, nil, fmt.Errorf("malformed content element")
+	if opts.compact {
+		ctl.compact()
+	} else {
+		ctl.Raw = der
+	}
+	ctl.Integrity = opts.integrity
+	if useCache {
+		cached := ctl
+		if opts.zeroCopy || ctl.Integrity != nil {
+			// a zero-copy CTL refers to der, which the caller may modify or free
+			// once this call returns, and Integrity describes only this download
+			cached = ctl.Clone()
+			cached.Integrity = nil
+		}
+		opts.cache.Put(cacheKey, cached)
 	}
-	return oid, content, nil
+	return ctl, nil
 }
 
-func parseCTL(der cryptobyte.String) (*CTL, error) {
+func parseCTL(der cryptobyte.String, opts *parseOptions) (*CTL, error) {
 	ctl := new(CTL)
+	check := &checker{strict: opts.strictChecks}
 	var sequence cryptobyte.String
 	if !der.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
 		return nil, fmt.Errorf("malformed SEQUENCE")
 	} else if !der.Empty() {
 		return nil, fmt.Errorf("trailing bytes after SEQUENCE")
 	}
-	if !sequence.SkipASN1(cryptobyte_asn1.SEQUENCE) {
-		return nil, fmt.Errorf("malformed signers SEQUENCE")
+	if !sequence.ReadOptionalASN1Integer(&ctl.Version, cryptobyte_asn1.INTEGER, 0) {
+		return nil, fmt.Errorf("malformed version INTEGER")
+	}
+	var subjectUsage cryptobyte.String
+	if !sequence.ReadASN1(&subjectUsage, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed subject usage SEQUENCE")
+	}
+	for !subjectUsage.Empty() {
+		var usage asn1.ObjectIdentifier
+		if !subjectUsage.ReadASN1ObjectIdentifier(&usage) {
+			return nil, fmt.Errorf("malformed subject usage OBJECT IDENTIFIER")
+		}
+		ctl.SubjectUsage = append(ctl.SubjectUsage, usage)
+	}
+	var listIdentifier cryptobyte.String
+	var hasListIdentifier bool
+	if !sequence.ReadOptionalASN1(&listIdentifier, &hasListIdentifier, cryptobyte_asn1.OCTET_STRING) {
+		return nil, fmt.Errorf("malformed list identifier OCTET STRING")
 	}
-	if !sequence.ReadASN1Integer(&ctl.SequenceNumber) {
+	if hasListIdentifier {
+		ctl.ListIdentifier = listIdentifier
+	}
+	if sequence.PeekASN1Tag(cryptobyte_asn1.INTEGER) && !sequence.ReadASN1Integer(&ctl.SequenceNumber) {
 		return nil, fmt.Errorf("malformed sequence number INTEGER")
 	}
-	if !sequence.ReadASN1UTCTime(&ctl.EffectiveDate) {
-		return nil, fmt.Errorf("malformed effective date UTCTIME")
+	if !readTime(&sequence, &ctl.EffectiveDate) {
+		return nil, fmt.Errorf("malformed effective date")
+	}
+	if (sequence.PeekASN1Tag(cryptobyte_asn1.UTCTime) || sequence.PeekASN1Tag(cryptobyte_asn1.GeneralizedTime)) && !readTime(&sequence, &ctl.NextUpdate) {
+		return nil, fmt.Errorf("malformed next update")
 	}
-	if !sequence.SkipASN1(cryptobyte_asn1.SEQUENCE) {
-		return nil, fmt.Errorf("malformed algorithm identifier SEQUENCE")
+	if err := check.checkHeader(ctl); err != nil {
+		return nil, err
 	}
-	if !sequence.SkipASN1(cryptobyte_asn1.SEQUENCE) {
+	if opts.headerOnly {
+		ctl.Warnings = check.warnings
+		return ctl, nil
+	}
+	var err error
+	if ctl.SubjectAlgorithm, err = readAlgorithmIdentifier(&sequence); err != nil {
+		return nil, fmt.Errorf("malformed subject algorithm: %w", err)
+	}
+	var entries cryptobyte.String
+	var hasEntries bool
+	if !sequence.ReadOptionalASN1(&entries, &hasEntries, cryptobyte_asn1.SEQUENCE) {
 		return nil, fmt.Errorf("malformed entries SEQUENCE")
 	}
+	ctl.rawEntries = entries
+	if opts.limits.MaxEntries != 0 {
+		if count := countElements(entries); count > opts.limits.MaxEntries {
+			return nil, fmt.Errorf("CTL has %d entries, which exceeds the limit of %d", count, opts.limits.MaxEntries)
+		}
+	}
+	if opts.entryFunc != nil {
+		if err := forEachEntry(entries, func(entry *Entry) error {
+			if err := check.checkEntry(entry); err != nil {
+				return err
+			}
+			return opts.entryFunc(entry)
+		}); err != nil {
+			return nil, fmt.Errorf("error parsing entries: %w", err)
+		}
+	} else if opts.compact && !opts.withoutEntries {
+		ctl.Entries = make([]Entry, 0, countElements(entries))
+		if err := forEachEntry(entries, func(entry *Entry) error {
+			if err := check.checkEntry(entry); err != nil {
+				return err
+			}
+			entry.compact(opts.entryFields)
+			ctl.Entries = append(ctl.Entries, *entry)
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("error parsing entries: %w", err)
+		}
+	} else if !opts.withoutEntries {
+		ctl.Entries, err = parseEntries(entries, opts.workers)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing entries: %w", err)
+		}
+		for i := range ctl.Entries {
+			if err := check.checkEntry(&ctl.Entries[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
 	var extensions cryptobyte.String
 	var hasExtensions bool
 	if !sequence.ReadOptionalASN1(&extensions, &hasExtensions, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
@@ -128,36 +281,51 @@ func parseCTL(der cryptobyte.String) (*CTL, error) {
 			return nil, fmt.Errorf("malformed inner extensions SEQUENCE")
 		}
 		for !extensions.Empty() {
-			var extension cryptobyte.String
-			if !extensions.ReadASN1(&extension, cryptobyte_asn1.SEQUENCE) {
+			var extensionBytes cryptobyte.String
+			if !extensions.ReadASN1(&extensionBytes, cryptobyte_asn1.SEQUENCE) {
 				return nil, fmt.Errorf("malformed extension SEQUENCE")
 			}
-			var id asn1.ObjectIdentifier
-			if !extension.ReadASN1ObjectIdentifier(&id) {
+			var extension Extension
+			if !extensionBytes.ReadASN1ObjectIdentifier(&extension.ID) {
 				return nil, fmt.Errorf("malformed extension OBJECT IDENTIFIER")
 			}
-			if !extension.SkipOptionalASN1(cryptobyte_asn1.BOOLEAN) {
+			if !extensionBytes.ReadOptionalASN1Boolean(&extension.Critical, cryptobyte_asn1.BOOLEAN, false) {
 				return nil, fmt.Errorf("malformed extension BOOLEAN")
 			}
 			var value cryptobyte.String
-			if !extension.ReadASN1(&value, cryptobyte_asn1.OCTET_STRING) {
+			if !extensionBytes.ReadASN1(&value, cryptobyte_asn1.OCTET_STRING) {
 				return nil, fmt.Errorf("malformed extension OCTET STRING")
 			}
+			extension.Value = value
+			if err := check.checkExtension(ctl.Extensions, &extension); err != nil {
+				return nil, err
+			}
 			switch {
-			case id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 52}):
-				var err error
+			case extension.ID.Equal(oidCTLogsExtension):
 				ctl.CTLogsVersion, ctl.CTLogs, err = parseCTLogs(value)
 				if err != nil {
 					return nil, fmt.Errorf("error parsing CT logs extension: %w", err)
 				}
+				if err := check.checkCTLogs(ctl.CTLogs); err != nil {
+					return nil, err
+				}
 			}
+			ctl.Extensions = append(ctl.Extensions, extension)
 		}
 	}
+	ctl.Warnings = check.warnings
 
 	return ctl, nil
 }
 
-func parseCTLogs(der cryptobyte.String) ([]int32, [][]byte, error) {
+func readTime(der *cryptobyte.String, out *time.Time) bool {
+	if der.PeekASN1Tag(cryptobyte_asn1.GeneralizedTime) {
+		return der.ReadASN1GeneralizedTime(out)
+	}
+	return der.ReadASN1UTCTime(out)
+}
+
+func parseCTLogs(der cryptobyte.String) ([]int32, []CTLogKey, error) {
 	var sequence cryptobyte.String
 	if !der.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
 		return nil, nil, fmt.Errorf("malformed SEQUENCE")
@@ -176,13 +344,13 @@ func parseCTLogs(der cryptobyte.String) ([]int32, [][]byte, error) {
 		}
 		version = append(version, i)
 	}
-	var pubkeys [][]byte
+	var pubkeys []CTLogKey
 	for !sequence.Empty() {
 		var spki cryptobyte.String
 		if !sequence.ReadASN1Element(&spki, cryptobyte_asn1.SEQUENCE) {
 			return nil, nil, fmt.Errorf("malformed SPKI SEQUENCE")
 		}
-		pubkeys = append(pubkeys, []byte(spki))
+		pubkeys = append(pubkeys, CTLogKey(spki))
 	}
 	return version, pubkeys, nil
 }
@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// CTLLint checks a CTL for a publication mistake.  Check returns a description of
+// each instance of the problem, or nothing if the CTL doesn't have it.
+type CTLLint struct {
+	Name        string
+	Description string
+	Check       func(ctl *CTL, at time.Time) []string
+}
+
+// DefaultCTLLints are the CTL lints provided by this package.  They flag unusual
+// combinations of attributes which may indicate a mistake by Microsoft.
+var DefaultCTLLints = []CTLLint{
+	{
+		Name:        "disallowed_entry_present",
+		Description: "Entry has been disallowed for all usages but is still listed",
+		Check: func(ctl *CTL, at time.Time) []string {
+			return checkEntries(ctl, func(entry *Entry) string {
+				if !entry.DisallowedDate.IsZero() && !at.Before(entry.DisallowedDate) && len(entry.DisallowedEKUs) == 0 {
+					return "disallowed since " + entry.DisallowedDate.Format(time.DateOnly)
+				}
+				return ""
+			})
+		},
+	},
+	{
+		Name:        "disallowed_eku_without_date",
+		Description: "Entry has disallowed EKUs but no disallowed date, so they have no effect",
+		Check: func(ctl *CTL, at time.Time) []string {
+			return checkEntries(ctl, func(entry *Entry) string {
+				if len(entry.DisallowedEKUs) > 0 && entry.DisallowedDate.IsZero() {
+					return fmt.Sprintf("%d disallowed EKUs", len(entry.DisallowedEKUs))
+				}
+				return ""
+			})
+		},
+	},
+	{
+		Name:        "not_before_eku_without_date",
+		Description: "Entry has NotBefore EKUs but no NotBefore date, so they have no effect",
+		Check: func(ctl *CTL, at time.Time) []string {
+			return checkEntries(ctl, func(entry *Entry) string {
+				if len(entry.NotBeforeEKUs) > 0 && entry.NotBeforeDate.IsZero() {
+					return fmt.Sprintf("%d NotBefore EKUs", len(entry.NotBeforeEKUs))
+				}
+				return ""
+			})
+		},
+	},
+	{
+		Name:        "empty_friendly_name",
+		Description: "Entry has no friendly name",
+		Check: func(ctl *CTL, at time.Time) []string {
+			return checkEntries(ctl, func(entry *Entry) string {
+				if entry.FriendlyName == "" {
+					return "no friendly name"
+				}
+				return ""
+			})
+		},
+	},
+	{
+		Name:        "unknown_critical_extension",
+		Description: "CTL has a critical extension which is not known to this package",
+		Check: func(ctl *CTL, at time.Time) []string {
+			var details []string
+			for _, extension := range ctl.Extensions {
+				if _, ok := LookupOID(extension.ID); !ok && extension.Critical {
+					details = append(details, "critical extension "+extension.ID.String())
+				}
+			}
+			return details
+		},
+	},
+	{
+		Name:        "parse_warning",
+		Description: "Parsing the CTL produced a warning (see CTL.Warnings)",
+		Check: func(ctl *CTL, at time.Time) []string {
+			var details []string
+			for _, warning := range ctl.Warnings {
+				details = append(details, warning.Error())
+			}
+			return details
+		},
+	},
+}
+
+// checkEntries returns the result of check for each entry for which it is non-empty,
+// prefixed by the entry
+func checkEntries(ctl *CTL, check func(*Entry) string) []string {
+	var details []string
+	for i := range ctl.Entries {
+		if detail := check(&ctl.Entries[i]); detail != "" {
+			details = append(details, ctl.Entries[i].String()+": "+detail)
+		}
+	}
+	return details
+}
+
+// LookupCTLLint returns the lint in DefaultCTLLints with the given name
+func LookupCTLLint(name string) (CTLLint, bool) {
+	i := slices.IndexFunc(DefaultCTLLints, func(lint CTLLint) bool { return lint.Name == name })
+	if i == -1 {
+		return CTLLint{}, false
+	}
+	return DefaultCTLLints[i], true
+}
+
+// RunCTLLints runs each lint over ctl as of the given time and returns the findings,
+// in the order of lints
+func RunCTLLints(ctl *CTL, lints []CTLLint, at time.Time) []LintFinding {
+	var findings []LintFinding
+	for _, lint := range lints {
+		for _, detail := range lint.Check(ctl, at) {
+			findings = append(findings, LintFinding{Lint: lint.Name, Detail: detail})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// CTPolicyOptions configures CTL.CheckCTPolicy
+type CTPolicyOptions struct {
+	// Issuer is the certificate's issuer, which is needed to verify embedded SCTs
+	Issuer *x509.Certificate
+
+	// Operators maps log IDs to the names of their operators, e.g. from
+	// ParseChromeLogList.  If nil, operator diversity is not evaluated, because
+	// the CTL does not say who operates each log.
+	Operators map[[32]byte]string
+
+	// CurrentTime is the time at which the policy is evaluated.  If zero, the
+	// current time is used.
+	CurrentTime time.Time
+}
+
+// SCTResult is the outcome of verifying one embedded SCT
+type SCTResult struct {
+	SCT      *SCT
+	Operator string // empty if unknown
+	Err      error  // nil if the SCT is from a log in the CTL and its signature is valid
+}
+
+// CTVerdict is the outcome of CTL.CheckCTPolicy
+type CTVerdict struct {
+	Compliant        bool
+	RequiredSCTs     int // number of valid SCTs required, based on the certificate's lifetime
+	ValidSCTs        int
+	SCTs             []SCTResult
+	Operators        []string // distinct operators of the logs which issued valid SCTs, if known
+	OperatorsChecked bool     // whether operator diversity was evaluated
+	Reasons          []string // why the certificate is not compliant
+}
+
+// CheckCTPolicy evaluates whether the SCTs embedded in an end-entity certificate
+// satisfy the CT requirements enforced by Microsoft Edge, which are the same as
+// Chrome's: at least 2 valid SCTs if the certificate's lifetime is at most 180 days,
+// and at least 3 otherwise, from logs recognized by the CTL and, if opts.Operators
+// is set, from at least 2 distinct log operators.  An error is returned only
+// if the certificate's SCTs can't be examined at all.
+func (ctl *CTL) CheckCTPolicy(cert *x509.Certificate, opts CTPolicyOptions) (*CTVerdict, error) {
+	if opts.Issuer == nil {
+		return nil, fmt.Errorf("the issuer is required to verify embedded SCTs")
+	}
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	scts, err := EmbeddedSCTs(cert)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded SCTs: %w", err)
+	}
+	entry, err := PrecertEntry(cert, opts.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	verifiers, err := ctl.SCTVerifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &CTVerdict{
+		RequiredSCTs:     3,
+		OperatorsChecked: opts.Operators != nil,
+	}
+	if cert.NotAfter.Sub(cert.NotBefore) <= 180*24*time.Hour {
+		verdict.RequiredSCTs = 2
+	}
+	for _, sct := range scts {
+		result := SCTResult{SCT: sct, Operator: opts.Operators[sct.LogID]}
+		if verifier, ok := verifiers[sct.LogID]; !ok {
+			result.Err = fmt.Errorf("log %x is not recognized by the CTL", sct.LogID)
+		} else if err := verifier.VerifySCTSignature(sct, entry); err != nil {
+			result.Err = err
+		} else if sct.Time().After(now) {
+			result.Err = fmt.Errorf("SCT timestamp %s is in the future", sct.Time().Format(time.RFC3339))
+		} else {
+			verdict.ValidSCTs++
+			if result.Operator != "" && !slices.Contains(verdict.Operators, result.Operator) {
+				verdict.Operators = append(verdict.Operators, result.Operator)
+			}
+		}
+		verdict.SCTs = append(verdict.SCTs, result)
+	}
+
+	if verdict.ValidSCTs < verdict.RequiredSCTs {
+		verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("%d valid SCTs, but %d are required", verdict.ValidSCTs, verdict.RequiredSCTs))
+	}
+	if verdict.OperatorsChecked && len(verdict.Operators) < 2 {
+		verdict.Reasons = append(verdict.Reasons, fmt.Sprintf("valid SCTs are from %d distinct log operators, but 2 are required", len(verdict.Operators)))
+	}
+	verdict.Compliant = len(verdict.Reasons) == 0
+	return verdict, nil
+}
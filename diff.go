@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CTLDiff describes how a CTL changed
+type CTLDiff struct {
+	OldVersion        int
+	NewVersion        int
+	OldSequenceNumber *big.Int
+	NewSequenceNumber *big.Int
+	OldEffectiveDate  time.Time
+	NewEffectiveDate  time.Time
+	OldNextUpdate     time.Time
+	NewNextUpdate     time.Time
+
+	AddedRoots   []Entry
+	RemovedRoots []Entry
+	ChangedRoots []RootChange
+
+	AddedCTLogs   []CTLogKey
+	RemovedCTLogs []CTLogKey
+}
+
+// RootChange describes how a root's entry changed
+type RootChange struct {
+	Old     Entry
+	New     Entry
+	Changes []string // human-readable descriptions of each change
+}
+
+// Empty returns true if the CTLs have the same roots and CT logs
+func (diff *CTLDiff) Empty() bool {
+	return len(diff.AddedRoots) == 0 && len(diff.RemovedRoots) == 0 && len(diff.ChangedRoots) == 0 &&
+		len(diff.AddedCTLogs) == 0 && len(diff.RemovedCTLogs) == 0
+}
+
+// LogValue summarizes the diff for slog as the old and new sequence numbers and
+// the number of roots and CT logs added, removed, and changed
+func (diff *CTLDiff) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("old_sequence_number", fmt.Sprintf("%X", diff.OldSequenceNumber)),
+		slog.String("new_sequence_number", fmt.Sprintf("%X", diff.NewSequenceNumber)),
+		slog.Int("roots_added", len(diff.AddedRoots)),
+		slog.Int("roots_removed", len(diff.RemovedRoots)),
+		slog.Int("roots_changed", len(diff.ChangedRoots)),
+		slog.Int("ct_logs_added", len(diff.AddedCTLogs)),
+		slog.Int("ct_logs_removed", len(diff.RemovedCTLogs)),
+	)
+}
+
+// Diff returns the differences between oldCTL and newCTL.  Roots are matched
+// by subject identifier (normally the SHA-1 hash) and CT logs by log ID.  Each
+// list of roots and logs in the result is in canonical order (see CompareEntries
+// and CompareCTLogKeys).
+func Diff(oldCTL, newCTL *CTL) *CTLDiff {
+	diff := &CTLDiff{
+		OldVersion:        oldCTL.Version,
+		NewVersion:        newCTL.Version,
+		OldSequenceNumber: &oldCTL.SequenceNumber,
+		NewSequenceNumber: &newCTL.SequenceNumber,
+		OldEffectiveDate:  oldCTL.EffectiveDate,
+		NewEffectiveDate:  newCTL.EffectiveDate,
+		OldNextUpdate:     oldCTL.NextUpdate,
+		NewNextUpdate:     newCTL.NextUpdate,
+	}
+
+	oldEntries := make(map[string]Entry)
+	for _, entry := range oldCTL.Entries {
+		oldEntries[string(entry.SubjectIdentifier)] = entry
+	}
+	newEntries := make(map[string]bool)
+	for _, entry := range newCTL.Entries {
+		newEntries[string(entry.SubjectIdentifier)] = true
+		oldEntry, existed := oldEntries[string(entry.SubjectIdentifier)]
+		if !existed {
+			diff.AddedRoots = append(diff.AddedRoots, entry)
+		} else if changes := compareEntries(&oldEntry, &entry); len(changes) > 0 {
+			diff.ChangedRoots = append(diff.ChangedRoots, RootChange{Old: oldEntry, New: entry, Changes: changes})
+		}
+	}
+	for _, entry := range oldCTL.Entries {
+		if !newEntries[string(entry.SubjectIdentifier)] {
+			diff.RemovedRoots = append(diff.RemovedRoots, entry)
+		}
+	}
+
+	diff.AddedCTLogs = ctLogsNotIn(newCTL.CTLogs, oldCTL.CTLogs)
+	diff.RemovedCTLogs = ctLogsNotIn(oldCTL.CTLogs, newCTL.CTLogs)
+
+	SortEntries(diff.AddedRoots)
+	SortEntries(diff.RemovedRoots)
+	slices.SortStableFunc(diff.ChangedRoots, func(a, b RootChange) int { return CompareEntries(&a.New, &b.New) })
+	SortCTLogs(diff.AddedCTLogs)
+	SortCTLogs(diff.RemovedCTLogs)
+	return diff
+}
+
+func ctLogsNotIn(logs, other []CTLogKey) []CTLogKey {
+	otherIDs := make(map[[32]byte]bool)
+	for _, spki := range other {
+		otherIDs[spki.LogID()] = true
+	}
+	var result []CTLogKey
+	for _, spki := range logs {
+		if !otherIDs[spki.LogID()] {
+			result = append(result, spki)
+		}
+	}
+	return result
+}
+
+func compareEntries(oldEntry, newEntry *Entry) []string {
+	var changes []string
+	if oldEntry.FriendlyName != newEntry.FriendlyName {
+		changes = append(changes, fmt.Sprintf("friendly name changed from %q to %q", oldEntry.FriendlyName, newEntry.FriendlyName))
+	}
+	if !slices.EqualFunc(oldEntry.EKUs, newEntry.EKUs, asn1.ObjectIdentifier.Equal) {
+		changes = append(changes, fmt.Sprintf("EKUs changed from %s to %s", ekusString(oldEntry.EKUs), ekusString(newEntry.EKUs)))
+	}
+	if !oldEntry.DisallowedDate.Equal(newEntry.DisallowedDate) || !slices.EqualFunc(oldEntry.DisallowedEKUs, newEntry.DisallowedEKUs, asn1.ObjectIdentifier.Equal) {
+		changes = append(changes, fmt.Sprintf("disallowed changed from %s to %s", restrictionString(oldEntry.DisallowedDate, oldEntry.DisallowedEKUs), restrictionString(newEntry.DisallowedDate, newEntry.DisallowedEKUs)))
+	}
+	if !oldEntry.NotBeforeDate.Equal(newEntry.NotBeforeDate) || !slices.EqualFunc(oldEntry.NotBeforeEKUs, newEntry.NotBeforeEKUs, asn1.ObjectIdentifier.Equal) {
+		changes = append(changes, fmt.Sprintf("NotBefore changed from %s to %s", restrictionString(oldEntry.NotBeforeDate, oldEntry.NotBeforeEKUs), restrictionString(newEntry.NotBeforeDate, newEntry.NotBeforeEKUs)))
+	}
+	if !slices.EqualFunc(oldEntry.Attributes, newEntry.Attributes, equalAttributes) && len(changes) == 0 {
+		changes = append(changes, "other attributes changed")
+	}
+	return changes
+}
+
+func equalAttributes(a, b Attribute) bool {
+	return a.Type.Equal(b.Type) && slices.EqualFunc(a.Values, b.Values, bytes.Equal)
+}
+
+func ekusString(ekus []asn1.ObjectIdentifier) string {
+	if len(ekus) == 0 {
+		return "(all usages)"
+	}
+	strs := make([]string, len(ekus))
+	for i, eku := range ekus {
+		strs[i] = eku.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+func restrictionString(date time.Time, ekus []asn1.ObjectIdentifier) string {
+	if date.IsZero() {
+		return "(none)"
+	}
+	return date.Format(time.RFC3339) + " for " + ekusString(ekus)
+}
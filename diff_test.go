@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"slices"
+	"testing"
+	"time"
+)
+
+var (
+	testEKUServerAuth = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}
+	testEKUClientAuth = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}
+)
+
+func testDiffEntry(b byte, name string, ekus ...asn1.ObjectIdentifier) Entry {
+	return Entry{SubjectIdentifier: bytes.Repeat([]byte{b}, 20), FriendlyName: name, EKUs: ekus}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	ctl := &CTL{
+		Entries: []Entry{testDiffEntry(1, "One", testEKUServerAuth), testDiffEntry(2, "Two")},
+		CTLogs:  []CTLogKey{CTLogKey("log one")},
+	}
+	reordered := &CTL{
+		Entries: []Entry{ctl.Entries[1], ctl.Entries[0]},
+		CTLogs:  ctl.CTLogs,
+	}
+	if diff := Diff(ctl, reordered); !diff.Empty() {
+		t.Errorf("Diff of CTLs with the same entries in a different order is not empty: %+v", diff)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldCTL := &CTL{
+		Version: 1,
+		Entries: []Entry{
+			testDiffEntry(1, "Unchanged", testEKUServerAuth),
+			testDiffEntry(2, "Removed"),
+			testDiffEntry(3, "Old Name", testEKUServerAuth),
+		},
+		CTLogs:        []CTLogKey{CTLogKey("kept log"), CTLogKey("removed log")},
+		EffectiveDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	oldCTL.SequenceNumber.SetInt64(1)
+	newCTL := &CTL{
+		Version: 1,
+		Entries: []Entry{
+			testDiffEntry(3, "New Name", testEKUServerAuth, testEKUClientAuth),
+			testDiffEntry(4, "Added"),
+			testDiffEntry(1, "Unchanged", testEKUServerAuth),
+		},
+		CTLogs:        []CTLogKey{CTLogKey("added log"), CTLogKey("kept log")},
+		EffectiveDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	newCTL.SequenceNumber.SetInt64(2)
+
+	diff := Diff(oldCTL, newCTL)
+	if diff.Empty() {
+		t.Fatal("Diff is empty")
+	}
+	if diff.OldSequenceNumber.Int64() != 1 || diff.NewSequenceNumber.Int64() != 2 {
+		t.Errorf("sequence numbers are %v and %v, want 1 and 2", diff.OldSequenceNumber, diff.NewSequenceNumber)
+	}
+	if !diff.OldEffectiveDate.Equal(oldCTL.EffectiveDate) || !diff.NewEffectiveDate.Equal(newCTL.EffectiveDate) {
+		t.Errorf("effective dates are %s and %s", diff.OldEffectiveDate, diff.NewEffectiveDate)
+	}
+	if len(diff.AddedRoots) != 1 || diff.AddedRoots[0].FriendlyName != "Added" {
+		t.Errorf("added roots are %+v, want only Added", diff.AddedRoots)
+	}
+	if len(diff.RemovedRoots) != 1 || diff.RemovedRoots[0].FriendlyName != "Removed" {
+		t.Errorf("removed roots are %+v, want only Removed", diff.RemovedRoots)
+	}
+	if len(diff.ChangedRoots) != 1 {
+		t.Fatalf("changed roots are %+v, want one", diff.ChangedRoots)
+	}
+	change := diff.ChangedRoots[0]
+	if change.Old.FriendlyName != "Old Name" || change.New.FriendlyName != "New Name" {
+		t.Errorf("changed root is %q -> %q", change.Old.FriendlyName, change.New.FriendlyName)
+	}
+	wantChanges := []string{
+		`friendly name changed from "Old Name" to "New Name"`,
+		"EKUs changed from 1.3.6.1.5.5.7.3.1 to 1.3.6.1.5.5.7.3.1,1.3.6.1.5.5.7.3.2",
+	}
+	if !slices.Equal(change.Changes, wantChanges) {
+		t.Errorf("changes are %q, want %q", change.Changes, wantChanges)
+	}
+	if len(diff.AddedCTLogs) != 1 || string(diff.AddedCTLogs[0]) != "added log" {
+		t.Errorf("added CT logs are %q", diff.AddedCTLogs)
+	}
+	if len(diff.RemovedCTLogs) != 1 || string(diff.RemovedCTLogs[0]) != "removed log" {
+		t.Errorf("removed CT logs are %q", diff.RemovedCTLogs)
+	}
+}
+
+func TestDiffRestrictions(t *testing.T) {
+	disallowed := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	oldEntry := testDiffEntry(1, "Root")
+	newEntry := oldEntry
+	newEntry.DisallowedDate = disallowed
+	newEntry.DisallowedEKUs = []asn1.ObjectIdentifier{testEKUServerAuth}
+	diff := Diff(&CTL{Entries: []Entry{oldEntry}}, &CTL{Entries: []Entry{newEntry}})
+	if len(diff.ChangedRoots) != 1 {
+		t.Fatalf("changed roots are %+v, want one", diff.ChangedRoots)
+	}
+	want := []string{"disallowed changed from (none) to 2024-06-01T00:00:00Z for 1.3.6.1.5.5.7.3.1"}
+	if got := diff.ChangedRoots[0].Changes; !slices.Equal(got, want) {
+		t.Errorf("changes are %q, want %q", got, want)
+	}
+}
+
+func TestDiffOtherAttributes(t *testing.T) {
+	oldEntry := testDiffEntry(1, "Root")
+	newEntry := oldEntry
+	newEntry.Attributes = []Attribute{{Type: asn1.ObjectIdentifier{1, 2, 3}, Values: [][]byte{{1}}}}
+	diff := Diff(&CTL{Entries: []Entry{oldEntry}}, &CTL{Entries: []Entry{newEntry}})
+	if len(diff.ChangedRoots) != 1 || !slices.Equal(diff.ChangedRoots[0].Changes, []string{"other attributes changed"}) {
+		t.Errorf("changed roots are %+v, want one with other attributes changed", diff.ChangedRoots)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// LocaleBaseURL returns baseURL (normally DefaultBaseURL) with its final path
+// component, which is the locale, replaced by the given locale (such as "de" or
+// "ja").  Microsoft's CDN serves a path for each locale, which are meant to have
+// the same content.
+func LocaleBaseURL(baseURL string, locale string) string {
+	trimmed := strings.TrimSuffix(baseURL, "/")
+	return trimmed[:strings.LastIndex(trimmed, "/")+1] + locale + "/"
+}
+
+// EndpointResult describes the trust list downloaded from one base URL
+type EndpointResult struct {
+	BaseURL        string
+	Err            error             // if non-nil, the other fields are empty or partial
+	FileSHA256     SHA256Fingerprint // of the downloaded CAB file
+	SequenceNumber *big.Int
+	Digest         [32]byte // CTL.Digest
+}
+
+// CompareEndpoints downloads the named CAB file (e.g. "authrootstl.cab") from each
+// of the base URLs concurrently, with client's settings, and returns the results in
+// the order of baseURLs.  consistent is true if every download succeeded and
+// every endpoint served the same sequence number and content (by CTL.Digest).
+func (client *Client) CompareEndpoints(ctx context.Context, name string, baseURLs []string) (results []EndpointResult, consistent bool) {
+	parse := ParseSTLCab
+	if name == "authrootstl.cab" {
+		parse = ParseAuthrootstlCab
+	}
+	results = make([]EndpointResult, len(baseURLs))
+	var wg sync.WaitGroup
+	for i, baseURL := range baseURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = client.withBaseURL(baseURL).fetchEndpoint(ctx, name, parse)
+		}()
+	}
+	wg.Wait()
+	return results, EndpointsConsistent(results)
+}
+
+// EndpointsConsistent returns true if every result is successful and has the same
+// sequence number and digest as the first
+func EndpointsConsistent(results []EndpointResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return false
+		}
+		if result.SequenceNumber.Cmp(results[0].SequenceNumber) != 0 || result.Digest != results[0].Digest {
+			return false
+		}
+	}
+	return true
+}
+
+func (client *Client) fetchEndpoint(ctx context.Context, name string, parse func(io.ReadSeeker, ...ParseOption) (*CTL, error)) EndpointResult {
+	result := EndpointResult{BaseURL: client.BaseURL}
+	ctl, dl, err := client.fetchCTLDownload(ctx, name, parse)
+	if dl != nil {
+		result.FileSHA256 = SHA256Fingerprint(sha256.Sum256(dl.body))
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.SequenceNumber = &ctl.SequenceNumber
+	result.Digest = ctl.Digest()
+	return result
+}
+
+// withBaseURL returns a client with the same settings as client, except for BaseURL
+func (client *Client) withBaseURL(baseURL string) *Client {
+	return &Client{
+		HTTPClient:         client.HTTPClient,
+		BaseURL:            baseURL,
+		Timeout:            client.Timeout,
+		Retries:            client.Retries,
+		VerifyOptions:      client.VerifyOptions,
+		InsecureSkipVerify: client.InsecureSkipVerify,
+		IntegrityPolicy:    client.IntegrityPolicy,
+		TLSRoots:           client.TLSRoots,
+		Logger:             client.Logger,
+	}
+}
+
+// EndpointDivergence is an inconsistency found by CheckEndpointRounds
+type EndpointDivergence struct {
+	BaseURL string
+	Round   int // index of the round in which it was found
+	Problem string
+}
+
+// CheckEndpointRounds checks the results of one or more rounds of CompareEndpoints,
+// in chronological order, and returns the divergences found: failed downloads, endpoints
+// serving an older sequence number than another endpoint in the same round, endpoints
+// whose sequence number went backwards since the previous round, and the same sequence
+// number being served with different content, which suggests tampering
+func CheckEndpointRounds(rounds [][]EndpointResult) []EndpointDivergence {
+	var divergences []EndpointDivergence
+	type observation struct {
+		baseURL string
+		round   int
+		digest  [32]byte
+	}
+	firstSeen := make(map[string]observation) // by sequence number
+	previous := make(map[string]*big.Int)     // by base URL
+	for round, results := range rounds {
+		var newest *big.Int
+		for _, result := range results {
+			if result.Err == nil && (newest == nil || result.SequenceNumber.Cmp(newest) > 0) {
+				newest = result.SequenceNumber
+			}
+		}
+		for _, result := range results {
+			report := func(format string, args ...any) {
+				divergences = append(divergences, EndpointDivergence{BaseURL: result.BaseURL, Round: round, Problem: fmt.Sprintf(format, args...)})
+			}
+			if result.Err != nil {
+				report("download failed: %s", result.Err)
+				continue
+			}
+			if result.SequenceNumber.Cmp(newest) < 0 {
+				report("serves sequence number %X, but another endpoint serves %X", result.SequenceNumber, newest)
+			}
+			if prev, ok := previous[result.BaseURL]; ok && result.SequenceNumber.Cmp(prev) < 0 {
+				report("sequence number went back from %X to %X", prev, result.SequenceNumber)
+			}
+			previous[result.BaseURL] = result.SequenceNumber
+			key := result.SequenceNumber.String()
+			if first, ok := firstSeen[key]; !ok {
+				firstSeen[key] = observation{baseURL: result.BaseURL, round: round, digest: result.Digest}
+			} else if first.digest != result.Digest {
+				report("serves different content for sequence number %X than %s did in round %d", result.SequenceNumber, first.baseURL, first.round)
+			}
+		}
+	}
+	return divergences
+}
@@ -0,0 +1,375 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Entry is a trusted subject (i.e. a root certificate) listed in the CTL
+type Entry struct {
+	SubjectIdentifier []byte            // for root lists, the certificate's SHA-1 hash; for pin rules, the rule name
+	SHA1              SHA1Fingerprint   // SHA-1 hash of the certificate, or zero if the subject identifier is not a SHA-1 hash
+	SHA256            SHA256Fingerprint // SHA-256 hash of the certificate, or zero if not present
+	FriendlyName      string
+	KeyID             []byte                  // subject key identifier
+	SubjectNameMD5    []byte                  // MD5 hash of the certificate's subject
+	EKUs              []asn1.ObjectIdentifier // extended key usages for which the certificate is trusted
+
+	// If DisallowedDate is non-zero, the certificate is distrusted as of this date,
+	// for the usages in DisallowedEKUs (or for all usages if DisallowedEKUs is empty).
+	DisallowedDate time.Time
+	DisallowedEKUs []asn1.ObjectIdentifier
+
+	// If NotBeforeDate is non-zero, certificates issued by this root after this date
+	// are distrusted for the usages in NotBeforeEKUs (or for all usages if NotBeforeEKUs is empty).
+	NotBeforeDate time.Time
+	NotBeforeEKUs []asn1.ObjectIdentifier
+
+	Attributes []Attribute // all attributes, including unrecognized ones
+}
+
+// Attribute is an attribute of an Entry.  Each value is the contents
+// of an OCTET STRING.
+type Attribute struct {
+	Type    asn1.ObjectIdentifier
+	Values  [][]byte
+	Decoded []any // Values decoded by the decoder registered with RegisterAttributeDecoder, or nil if none
+}
+
+// CheckCertificate returns an error unless the DER-encoded certificate matches
+// the entry's SHA-1 hash and, if present, its SHA-256 hash
+func (entry *Entry) CheckCertificate(certBytes []byte) error {
+	sha1Hash := SHA1Fingerprint(sha1.Sum(certBytes))
+	if sha1Hash != entry.SHA1 {
+		return fmt.Errorf("certificate has SHA-1 hash %X instead of %X", sha1Hash, entry.SHA1)
+	}
+	if !entry.SHA256.IsZero() {
+		sha256Hash := SHA256Fingerprint(sha256.Sum256(certBytes))
+		if sha256Hash != entry.SHA256 {
+			return fmt.Errorf("certificate %X has SHA-256 hash %X instead of %X", entry.SHA1, sha256Hash, entry.SHA256)
+		}
+	}
+	return nil
+}
+
+// TrustedFor reports whether the root is trusted for the given usage at the given
+// time, taking into account its EKUs and disallowed date.  NotBeforeDate is not
+// considered, since it restricts the certificates issued by the root rather than
+// the root itself.
+func (entry *Entry) TrustedFor(eku asn1.ObjectIdentifier, at time.Time) bool {
+	if !appliesTo(entry.EKUs, eku) {
+		return false
+	}
+	if !entry.DisallowedDate.IsZero() && !at.Before(entry.DisallowedDate) && appliesTo(entry.DisallowedEKUs, eku) {
+		return false
+	}
+	return true
+}
+
+var (
+	oidEKUProperty                = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 9}
+	oidFriendlyNameProperty       = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 11}
+	oidKeyIDProperty              = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 20}
+	oidSubjectNameMD5Property     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 29}
+	oidSHA256Property             = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 98}
+	oidDisallowedFiletimeProperty = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 104}
+	oidDisallowedEKUProperty      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 122}
+	oidNotBeforeFiletimeProperty  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 126}
+	oidNotBeforeEKUProperty       = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 127}
+)
+
+func parseEntries(der cryptobyte.String, workers int) ([]Entry, error) {
+	if workers > 1 {
+		return parseEntriesConcurrently(der, workers)
+	}
+	entries := make([]Entry, countElements(der))
+	for i := range entries {
+		var entryBytes cryptobyte.String
+		if !der.ReadASN1(&entryBytes, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed entry SEQUENCE")
+		}
+		if err := parseEntry(entryBytes, &entries[i]); err != nil {
+			return nil, fmt.Errorf("error parsing entry %d: %w", i, err)
+		}
+	}
+	if !der.Empty() {
+		return nil, fmt.Errorf("malformed entry SEQUENCE")
+	}
+	return entries, nil
+}
+
+func forEachEntry(der cryptobyte.String, fn func(*Entry) error) error {
+	for i := 0; !der.Empty(); i++ {
+		var entryBytes cryptobyte.String
+		if !der.ReadASN1(&entryBytes, cryptobyte_asn1.SEQUENCE) {
+			return fmt.Errorf("malformed entry SEQUENCE")
+		}
+		entry := new(Entry)
+		if err := parseEntry(entryBytes, entry); err != nil {
+			return fmt.Errorf("error parsing entry %d: %w", i, err)
+		}
+		if err := fn(entry); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// parseEntriesConcurrently splits the entries into one contiguous chunk per worker.
+// If more than one entry is malformed, the error for the first is returned, as
+// parseEntries would.
+func parseEntriesConcurrently(der cryptobyte.String, workers int) ([]Entry, error) {
+	rawEntries := make([]cryptobyte.String, 0, countElements(der))
+	for !der.Empty() {
+		var entryBytes cryptobyte.String
+		if !der.ReadASN1(&entryBytes, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed entry SEQUENCE")
+		}
+		rawEntries = append(rawEntries, entryBytes)
+	}
+	entries := make([]Entry, len(rawEntries))
+	chunkSize := (len(entries) + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for worker := 0; worker*chunkSize < len(entries); worker++ {
+		start, end := worker*chunkSize, min((worker+1)*chunkSize, len(entries))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				if err := parseEntry(rawEntries[i], &entries[i]); err != nil {
+					errs[worker] = fmt.Errorf("error parsing entry %d: %w", i, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// AllEntries returns an iterator over the CTL's entries.  If the CTL was parsed with
+// the WithoutEntries option, each entry is decoded as the iteration reaches it, and
+// decoding stops at the first error; otherwise the iterator yields the elements of Entries.
+func (ctl *CTL) AllEntries() iter.Seq2[Entry, error] {
+	if ctl.Entries != nil {
+		return func(yield func(Entry, error) bool) {
+			for _, entry := range ctl.Entries {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	}
+	return entrySeq(ctl.rawEntries)
+}
+
+// countElements returns the number of SEQUENCEs at the start of der
+func countElements(der cryptobyte.String) int {
+	count := 0
+	for der.SkipASN1(cryptobyte_asn1.SEQUENCE) {
+		count++
+	}
+	return count
+}
+
+func entrySeq(der cryptobyte.String) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		der := der // so the iterator can be used more than once
+		for i := 0; !der.Empty(); i++ {
+			var entryBytes cryptobyte.String
+			if !der.ReadASN1(&entryBytes, cryptobyte_asn1.SEQUENCE) {
+				yield(Entry{}, fmt.Errorf("malformed entry SEQUENCE"))
+				return
+			}
+			var entry Entry
+			if err := parseEntry(entryBytes, &entry); err != nil {
+				yield(Entry{}, fmt.Errorf("error parsing entry %d: %w", i, err))
+				return
+			}
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+// parseEntry decodes der into entry, which must be zero
+func parseEntry(der cryptobyte.String, entry *Entry) error {
+	var identifier cryptobyte.String
+	if !der.ReadASN1(&identifier, cryptobyte_asn1.OCTET_STRING) {
+		return fmt.Errorf("malformed subject identifier OCTET STRING")
+	}
+	entry.SubjectIdentifier = identifier
+	if len(identifier) == len(entry.SHA1) {
+		entry.SHA1 = SHA1Fingerprint(identifier)
+	}
+	var attributes cryptobyte.String
+	var hasAttributes bool
+	if !der.ReadOptionalASN1(&attributes, &hasAttributes, cryptobyte_asn1.SET) {
+		return fmt.Errorf("malformed attributes SET")
+	}
+	if attributes.Empty() {
+		return nil
+	}
+	// Attributes almost always have exactly one value, so allocate the attributes and
+	// one value for each up front, and carve each attribute's values out of valueBuf
+	entry.Attributes = make([]Attribute, countElements(attributes))
+	valueBuf := make([][]byte, 0, len(entry.Attributes))
+	for i := range entry.Attributes {
+		attribute := &entry.Attributes[i]
+		var attributeBytes cryptobyte.String
+		if !attributes.ReadASN1(&attributeBytes, cryptobyte_asn1.SEQUENCE) {
+			return fmt.Errorf("malformed attribute SEQUENCE")
+		}
+		if !attributeBytes.ReadASN1ObjectIdentifier(&attribute.Type) {
+			return fmt.Errorf("malformed attribute OBJECT IDENTIFIER")
+		}
+		var values cryptobyte.String
+		if !attributeBytes.ReadASN1(&values, cryptobyte_asn1.SET) {
+			return fmt.Errorf("malformed attribute values SET")
+		}
+		start := len(valueBuf)
+		for !values.Empty() {
+			var value cryptobyte.String
+			if !values.ReadASN1(&value, cryptobyte_asn1.OCTET_STRING) {
+				return fmt.Errorf("malformed attribute value OCTET STRING")
+			}
+			valueBuf = append(valueBuf, []byte(value))
+		}
+		attribute.Values = valueBuf[start:len(valueBuf):len(valueBuf)]
+		if len(attribute.Values) == 0 {
+			attribute.Values = nil
+		}
+		if err := entry.decodeAttribute(attribute); err != nil {
+			return fmt.Errorf("error decoding attribute %s: %w", attribute.Type, err)
+		}
+		if err := attribute.decodeRegistered(); err != nil {
+			return fmt.Errorf("error decoding attribute %s: %w", attribute.Type, err)
+		}
+	}
+	if !attributes.Empty() {
+		return fmt.Errorf("malformed attribute SEQUENCE")
+	}
+	return nil
+}
+
+func (entry *Entry) decodeAttribute(attribute *Attribute) error {
+	if len(attribute.Values) != 1 {
+		return nil
+	}
+	value := attribute.Values[0]
+	var err error
+	switch {
+	case attribute.Type.Equal(oidEKUProperty):
+		entry.EKUs, err = parseEKUs(value)
+	case attribute.Type.Equal(oidFriendlyNameProperty):
+		entry.FriendlyName, err = parseUTF16String(value)
+	case attribute.Type.Equal(oidKeyIDProperty):
+		entry.KeyID = value
+	case attribute.Type.Equal(oidSubjectNameMD5Property):
+		entry.SubjectNameMD5 = value
+	case attribute.Type.Equal(oidSHA256Property):
+		if len(value) != len(entry.SHA256) {
+			return fmt.Errorf("SHA-256 hash has length %d", len(value))
+		}
+		entry.SHA256 = SHA256Fingerprint(value)
+	case attribute.Type.Equal(oidDisallowedFiletimeProperty):
+		entry.DisallowedDate, err = ParseFiletime(value)
+	case attribute.Type.Equal(oidDisallowedEKUProperty):
+		entry.DisallowedEKUs, err = parseEKUs(value)
+	case attribute.Type.Equal(oidNotBeforeFiletimeProperty):
+		entry.NotBeforeDate, err = ParseFiletime(value)
+	case attribute.Type.Equal(oidNotBeforeEKUProperty):
+		entry.NotBeforeEKUs, err = parseEKUs(value)
+	}
+	return err
+}
+
+func parseEKUs(der cryptobyte.String) ([]asn1.ObjectIdentifier, error) {
+	var sequence cryptobyte.String
+	if !der.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed SEQUENCE")
+	} else if !der.Empty() {
+		return nil, fmt.Errorf("trailing bytes after SEQUENCE")
+	}
+	var ekus []asn1.ObjectIdentifier
+	for !sequence.Empty() {
+		var eku asn1.ObjectIdentifier
+		if !sequence.ReadASN1ObjectIdentifier(&eku) {
+			return nil, fmt.Errorf("malformed OBJECT IDENTIFIER")
+		}
+		ekus = append(ekus, eku)
+	}
+	return ekus, nil
+}
+
+func parseUTF16String(value []byte) (string, error) {
+	if len(value)%2 != 0 {
+		return "", fmt.Errorf("UTF-16 string has odd length")
+	}
+	for len(value) >= 2 && value[len(value)-2] == 0 && value[len(value)-1] == 0 {
+		value = value[:len(value)-2]
+	}
+	var str strings.Builder
+	str.Grow(len(value) / 2) // enough for ASCII, which friendly names almost always are
+	for len(value) > 0 {
+		r := rune(binary.LittleEndian.Uint16(value))
+		value = value[2:]
+		if utf16.IsSurrogate(r) && len(value) > 0 {
+			if decoded := utf16.DecodeRune(r, rune(binary.LittleEndian.Uint16(value))); decoded != unicode.ReplacementChar {
+				r = decoded
+				value = value[2:]
+			}
+		}
+		if utf16.IsSurrogate(r) {
+			r = unicode.ReplacementChar
+		}
+		str.WriteRune(r)
+	}
+	return str.String(), nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"slices"
+)
+
+// Equal reports whether ctl and other have the same semantic content: the same
+// subject usages, the same entries with the same attributes, the same CT logs
+// and CT logs version, and the same other extensions.  The order of entries,
+// attributes, attribute values, logs, and extensions is ignored, as are the
+// sequence number, effective date, next update, and signature, so Equal reports
+// whether a re-published file actually changed anything.  Both CTLs must have
+// been parsed with their entries.  Equal CTLs have the same Digest.
+func (ctl *CTL) Equal(other *CTL) bool {
+	return slices.Equal(oidSet(ctl.SubjectUsage), oidSet(other.SubjectUsage)) &&
+		slices.Equal(entrySet(ctl.Entries), entrySet(other.Entries)) &&
+		slices.Equal(ctl.CTLogsVersion, other.CTLogsVersion) &&
+		slices.Equal(ctLogSet(ctl.CTLogs), ctLogSet(other.CTLogs)) &&
+		slices.Equal(extensionSet(ctl.Extensions), extensionSet(other.Extensions))
+}
+
+// Digest returns a SHA-256 hash of the CTL's semantic content, as compared by
+// Equal: it covers the subject usages, entries, CT logs, and other extensions, in
+// canonical order, and excludes the sequence number, dates, and signature.  Digests
+// are a cheap way to tell whether two CTLs, perhaps from different mirrors or
+// different times, contain the same roots.  The CTL must have been parsed with
+// its entries.
+func (ctl *CTL) Digest() [32]byte {
+	var encoded []byte
+	for _, set := range [][]string{
+		oidSet(ctl.SubjectUsage),
+		entrySet(ctl.Entries),
+		ctLogsVersionSet(ctl.CTLogsVersion),
+		ctLogSet(ctl.CTLogs),
+		extensionSet(ctl.Extensions),
+	} {
+		encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(set)))
+		for _, element := range set {
+			encoded = appendField(encoded, element)
+		}
+	}
+	return sha256.Sum256(encoded)
+}
+
+// The *Set functions return a sorted list of strings, each uniquely encoding an
+// element, so that sets can be compared with slices.Equal
+
+func oidSet(oids []asn1.ObjectIdentifier) []string {
+	set := make([]string, len(oids))
+	for i, oid := range oids {
+		set[i] = oid.String()
+	}
+	slices.Sort(set)
+	return set
+}
+
+func entrySet(entries []Entry) []string {
+	set := make([]string, len(entries))
+	for i := range entries {
+		attributes := make([]string, len(entries[i].Attributes))
+		for j, attribute := range entries[i].Attributes {
+			values := make([]string, len(attribute.Values))
+			for k, value := range attribute.Values {
+				values[k] = string(value)
+			}
+			slices.Sort(values)
+			attributes[j] = encodeSetElement(attribute.Type.String(), encodeSetElement(values...))
+		}
+		slices.Sort(attributes)
+		set[i] = encodeSetElement(string(entries[i].SubjectIdentifier), encodeSetElement(attributes...))
+	}
+	slices.Sort(set)
+	return set
+}
+
+// ctLogsVersionSet returns the CT logs version as a one-element set, so it can be digested like the other sets
+func ctLogsVersionSet(version []int32) []string {
+	var encoded []byte
+	for _, component := range version {
+		encoded = binary.BigEndian.AppendUint32(encoded, uint32(component))
+	}
+	return []string{string(encoded)}
+}
+
+func ctLogSet(logs []CTLogKey) []string {
+	set := make([]string, len(logs))
+	for i, logKey := range logs {
+		set[i] = string(logKey)
+	}
+	slices.Sort(set)
+	return set
+}
+
+func extensionSet(extensions []Extension) []string {
+	var set []string
+	for _, extension := range extensions {
+		if extension.ID.Equal(oidCTLogsExtension) {
+			continue // compared via CTLogs and CTLogsVersion
+		}
+		critical := "0"
+		if extension.Critical {
+			critical = "1"
+		}
+		set = append(set, encodeSetElement(extension.ID.String(), critical, string(extension.Value)))
+	}
+	slices.Sort(set)
+	return set
+}
+
+// encodeSetElement unambiguously encodes a list of strings as a single string
+func encodeSetElement(fields ...string) string {
+	var encoded []byte
+	for _, field := range fields {
+		encoded = appendField(encoded, field)
+	}
+	return string(encoded)
+}
+
+// appendField appends field to encoded, prefixed by its length
+func appendField(encoded []byte, field string) []byte {
+	encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(field)))
+	return append(encoded, field...)
+}
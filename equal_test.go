@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+func testEqualCTL() *CTL {
+	ctl := &CTL{
+		SubjectUsage: []asn1.ObjectIdentifier{{1, 3, 6, 1, 4, 1, 311, 20, 1}},
+		Entries: []Entry{
+			{SubjectIdentifier: []byte{1}, Attributes: []Attribute{
+				{Type: asn1.ObjectIdentifier{1, 2, 3}, Values: [][]byte{{1}, {2}}},
+				{Type: asn1.ObjectIdentifier{1, 2, 4}, Values: [][]byte{{3}}},
+			}},
+			{SubjectIdentifier: []byte{2}},
+		},
+		CTLogsVersion: []int32{1},
+		CTLogs:        []CTLogKey{CTLogKey("log one"), CTLogKey("log two")},
+		Extensions:    []Extension{{ID: asn1.ObjectIdentifier{1, 2, 5}, Value: []byte{4}}},
+	}
+	ctl.SequenceNumber.SetInt64(1)
+	return ctl
+}
+
+func TestEqualIgnoresOrderAndMetadata(t *testing.T) {
+	ctl := testEqualCTL()
+	other := testEqualCTL()
+	other.SequenceNumber.SetInt64(2)
+	other.Entries[0], other.Entries[1] = other.Entries[1], other.Entries[0]
+	attributes := other.Entries[1].Attributes
+	attributes[0], attributes[1] = attributes[1], attributes[0]
+	attributes[1].Values[0], attributes[1].Values[1] = attributes[1].Values[1], attributes[1].Values[0]
+	other.CTLogs[0], other.CTLogs[1] = other.CTLogs[1], other.CTLogs[0]
+	if !ctl.Equal(other) {
+		t.Errorf("CTLs differing only in order and sequence number are not Equal")
+	}
+}
+
+func TestEqualDetectsChanges(t *testing.T) {
+	for name, modify := range map[string]func(*CTL){
+		"subject usage":   func(ctl *CTL) { ctl.SubjectUsage = nil },
+		"entry removed":   func(ctl *CTL) { ctl.Entries = ctl.Entries[:1] },
+		"entry replaced":  func(ctl *CTL) { ctl.Entries[1].SubjectIdentifier = []byte{3} },
+		"attribute value": func(ctl *CTL) { ctl.Entries[0].Attributes[1].Values[0] = []byte{4} },
+		"CT logs version": func(ctl *CTL) { ctl.CTLogsVersion = []int32{2} },
+		"CT log":          func(ctl *CTL) { ctl.CTLogs = ctl.CTLogs[1:] },
+		"extension":       func(ctl *CTL) { ctl.Extensions[0].Critical = true },
+	} {
+		modified := testEqualCTL()
+		modify(modified)
+		if testEqualCTL().Equal(modified) {
+			t.Errorf("CTLs differing in %s are Equal", name)
+		}
+	}
+}
+
+func TestDigest(t *testing.T) {
+	ctl := testEqualCTL()
+	reordered := testEqualCTL()
+	reordered.SequenceNumber.SetInt64(2)
+	reordered.Entries[0], reordered.Entries[1] = reordered.Entries[1], reordered.Entries[0]
+	if ctl.Digest() != reordered.Digest() {
+		t.Errorf("Equal CTLs have different digests")
+	}
+	changed := testEqualCTL()
+	changed.Entries[0].Attributes[0].Values[0] = []byte{5}
+	if ctl.Digest() == changed.Digest() {
+		t.Errorf("CTLs with different attributes have the same digest")
+	}
+	// the length prefixes keep adjacent fields from running together
+	split := testEqualCTL()
+	split.CTLogs = []CTLogKey{CTLogKey("log o"), CTLogKey("nelog two")}
+	if ctl.Digest() == split.Digest() {
+		t.Errorf("CTLs with differently-split CT logs have the same digest")
+	}
+}
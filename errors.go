@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// These errors classify failures returned by the parse, verify, and fetch
+// functions.  They are wrapped with more detail, so test for them with errors.Is.
+var (
+	// ErrNotSignedData means the input is not a PKCS#7 SignedData
+	ErrNotSignedData = errors.New("not a PKCS#7 SignedData")
+
+	// ErrNotCTL means the input is a SignedData whose content is not a certificate trust list
+	ErrNotCTL = errors.New("not a certificate trust list")
+
+	// ErrTruncated means the input ended prematurely, as happens with an interrupted download
+	ErrTruncated = errors.New("truncated")
+
+	// ErrSignatureInvalid means that the signature, its timestamp, or the signer's
+	// certificate chain failed verification
+	ErrSignatureInvalid = errors.New("invalid signature")
+
+	// ErrNoMicrosoftRoot means that a signature could not be verified because
+	// VerifyOptions.Roots is nil and Microsoft's root certificate was not available
+	// (see MicrosoftRootSHA256)
+	ErrNoMicrosoftRoot = errors.New("no Microsoft root certificate available")
+
+	// ErrImplausibleDate means that a date in a CTL is before 1990 or after 2100
+	ErrImplausibleDate = errors.New("implausible date")
+
+	// ErrDuplicate means that a CTL lists the same entry, attribute, extension, or
+	// CT log more than once
+	ErrDuplicate = errors.New("duplicate")
+
+	// ErrUnrecognized means that a CTL contains an attribute or extension whose
+	// type is not known to this package
+	ErrUnrecognized = errors.New("unrecognized")
+
+	// ErrUnusualEncoding means that the input is encoded in a way which is valid
+	// enough to parse but which Microsoft does not use, such as trailing bytes
+	ErrUnusualEncoding = errors.New("unusual encoding")
+
+	// ErrRevoked means that a certificate in the signer's chain has been revoked
+	ErrRevoked = errors.New("revoked")
+
+	// ErrStale means that a CTL is past its next update time or older than the permitted age
+	ErrStale = errors.New("stale CTL")
+)
+
+// UnexpectedContentTypeError is returned when the input is a SignedData whose
+// content is something other than a CTL, such as a catalog file.  It wraps ErrNotCTL.
+type UnexpectedContentTypeError struct {
+	ContentType asn1.ObjectIdentifier // the content type found in the SignedData
+}
+
+func (err *UnexpectedContentTypeError) Error() string {
+	if name, ok := LookupOID(err.ContentType); ok {
+		return fmt.Sprintf("%s: SignedData has content type %s (%s)", ErrNotCTL, err.ContentType, name)
+	}
+	return fmt.Sprintf("%s: SignedData has content type %s", ErrNotCTL, err.ContentType)
+}
+
+func (err *UnexpectedContentTypeError) Unwrap() error {
+	return ErrNotCTL
+}
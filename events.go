@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// EventType identifies the kind of change described by an Event.  The values
+// are part of the JSON schema and will not change.
+type EventType string
+
+const (
+	RootAdded           EventType = "root_added"
+	RootRemoved         EventType = "root_removed"
+	RootPropertyChanged EventType = "root_property_changed"
+	LogAdded            EventType = "log_added"
+	LogRemoved          EventType = "log_removed"
+	SequenceAdvanced    EventType = "sequence_advanced"
+)
+
+// Event is a single change from one CTL to another.  Which fields are set depends on Type.
+type Event struct {
+	Type EventType
+
+	// Root is the affected root's entry, as it appears in the new CTL (or the old
+	// CTL, for RootRemoved).  Changes describes each change, for RootPropertyChanged.
+	Root    *Entry
+	Changes []string
+
+	// CTLog is the affected log, for LogAdded and LogRemoved
+	CTLog CTLogKey
+
+	// OldSequenceNumber and NewSequenceNumber are set for SequenceAdvanced
+	OldSequenceNumber *big.Int
+	NewSequenceNumber *big.Int
+}
+
+// Events returns the diff as a list of events: SequenceAdvanced if the sequence
+// number changed, followed by the root events and then the CT log events
+func (diff *CTLDiff) Events() []Event {
+	var events []Event
+	if diff.OldSequenceNumber.Cmp(diff.NewSequenceNumber) != 0 {
+		events = append(events, Event{Type: SequenceAdvanced, OldSequenceNumber: diff.OldSequenceNumber, NewSequenceNumber: diff.NewSequenceNumber})
+	}
+	for i := range diff.AddedRoots {
+		events = append(events, Event{Type: RootAdded, Root: &diff.AddedRoots[i]})
+	}
+	for i := range diff.RemovedRoots {
+		events = append(events, Event{Type: RootRemoved, Root: &diff.RemovedRoots[i]})
+	}
+	for i := range diff.ChangedRoots {
+		change := &diff.ChangedRoots[i]
+		events = append(events, Event{Type: RootPropertyChanged, Root: &change.New, Changes: change.Changes})
+	}
+	for _, logKey := range diff.AddedCTLogs {
+		events = append(events, Event{Type: LogAdded, CTLog: logKey})
+	}
+	for _, logKey := range diff.RemovedCTLogs {
+		events = append(events, Event{Type: LogRemoved, CTLog: logKey})
+	}
+	return events
+}
+
+type jsonEvent struct {
+	Type              EventType `json:"type"`
+	SHA1              string    `json:"sha1,omitempty"`
+	SHA256            string    `json:"sha256,omitempty"`
+	FriendlyName      string    `json:"friendly_name,omitempty"`
+	Changes           []string  `json:"changes,omitempty"`
+	LogID             []byte    `json:"log_id,omitempty"`
+	Key               []byte    `json:"key,omitempty"`
+	OldSequenceNumber string    `json:"old_sequence_number,omitempty"`
+	NewSequenceNumber string    `json:"new_sequence_number,omitempty"`
+}
+
+// MarshalJSON encodes the event as a JSON object with a "type" field and the
+// fields relevant to the type.  Hashes and sequence numbers are hex-encoded, and
+// log IDs and keys are base64-encoded.
+func (event Event) MarshalJSON() ([]byte, error) {
+	output := jsonEvent{
+		Type:    event.Type,
+		Changes: event.Changes,
+		Key:     event.CTLog,
+	}
+	if event.Root != nil {
+		output.SHA1 = hex.EncodeToString(event.Root.SubjectIdentifier)
+		if !event.Root.SHA256.IsZero() {
+			output.SHA256 = event.Root.SHA256.Hex()
+		}
+		output.FriendlyName = event.Root.FriendlyName
+	}
+	if event.CTLog != nil {
+		logID := event.CTLog.LogID()
+		output.LogID = logID[:]
+	}
+	if event.OldSequenceNumber != nil {
+		output.OldSequenceNumber = fmt.Sprintf("%X", event.OldSequenceNumber)
+	}
+	if event.NewSequenceNumber != nil {
+		output.NewSequenceNumber = fmt.Sprintf("%X", event.NewSequenceNumber)
+	}
+	return json.Marshal(output)
+}
+
+// UnmarshalJSON decodes an event encoded by MarshalJSON.  The "type" field determines
+// which fields are decoded and which are required.  A decoded Root has only the
+// SubjectIdentifier, SHA1, SHA256, and FriendlyName fields set.
+func (event *Event) UnmarshalJSON(data []byte) error {
+	var input jsonEvent
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	decoded := Event{Type: input.Type}
+	switch input.Type {
+	case RootAdded, RootRemoved, RootPropertyChanged:
+		root, err := input.root()
+		if err != nil {
+			return err
+		}
+		decoded.Root = root
+		if input.Type == RootPropertyChanged {
+			decoded.Changes = input.Changes
+		}
+	case LogAdded, LogRemoved:
+		if len(input.Key) == 0 {
+			return fmt.Errorf("%s event has no key", input.Type)
+		}
+		decoded.CTLog = CTLogKey(input.Key)
+		if logID := decoded.CTLog.LogID(); input.LogID != nil && !bytes.Equal(input.LogID, logID[:]) {
+			return fmt.Errorf("%s event has log ID which does not match its key", input.Type)
+		}
+	case SequenceAdvanced:
+		var ok bool
+		if decoded.OldSequenceNumber, ok = new(big.Int).SetString(input.OldSequenceNumber, 16); !ok {
+			return fmt.Errorf("%s event has malformed old sequence number %q", input.Type, input.OldSequenceNumber)
+		}
+		if decoded.NewSequenceNumber, ok = new(big.Int).SetString(input.NewSequenceNumber, 16); !ok {
+			return fmt.Errorf("%s event has malformed new sequence number %q", input.Type, input.NewSequenceNumber)
+		}
+	default:
+		return fmt.Errorf("unknown event type %q", input.Type)
+	}
+	*event = decoded
+	return nil
+}
+
+// root returns the entry described by a root event
+func (input *jsonEvent) root() (*Entry, error) {
+	identifier, err := hex.DecodeString(input.SHA1)
+	if err != nil || len(identifier) == 0 {
+		return nil, fmt.Errorf("%s event has malformed sha1 %q", input.Type, input.SHA1)
+	}
+	root := &Entry{SubjectIdentifier: identifier, FriendlyName: input.FriendlyName}
+	if len(identifier) == len(root.SHA1) {
+		root.SHA1 = SHA1Fingerprint(identifier)
+	}
+	if input.SHA256 != "" {
+		if root.SHA256, err = ParseSHA256Fingerprint(input.SHA256); err != nil {
+			return nil, fmt.Errorf("%s event has malformed sha256: %w", input.Type, err)
+		}
+	}
+	return root, nil
+}
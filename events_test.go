@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestEventJSONRoundTrip(t *testing.T) {
+	sha1 := bytes.Repeat([]byte{0xab}, 20)
+	root := &Entry{
+		SubjectIdentifier: sha1,
+		SHA1:              SHA1Fingerprint(sha1),
+		SHA256:            SHA256Fingerprint(bytes.Repeat([]byte{0xcd}, 32)),
+		FriendlyName:      "Example Root",
+	}
+	logKey := CTLogKey("example log key")
+	events := []Event{
+		{Type: RootAdded, Root: root},
+		{Type: RootRemoved, Root: root},
+		{Type: RootPropertyChanged, Root: root, Changes: []string{"friendly name changed", "EKUs changed"}},
+		{Type: LogAdded, CTLog: logKey},
+		{Type: LogRemoved, CTLog: logKey},
+		{Type: SequenceAdvanced, OldSequenceNumber: big.NewInt(0x1f), NewSequenceNumber: big.NewInt(0x20)},
+	}
+	for _, event := range events {
+		t.Run(string(event.Type), func(t *testing.T) {
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded Event
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("%s: %v", encoded, err)
+			}
+			if !reflect.DeepEqual(decoded, event) {
+				t.Errorf("%s decoded as %+v, want %+v", encoded, decoded, event)
+			}
+		})
+	}
+
+	var decoded []Event
+	encoded, err := json.Marshal(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, events) {
+		t.Errorf("event list decoded as %+v", decoded)
+	}
+}
+
+func TestEventUnmarshalErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"type":"root_exploded"}`,
+		`{"type":"root_added"}`,
+		`{"type":"root_added","sha1":"not hex"}`,
+		`{"type":"log_added"}`,
+		`{"type":"log_removed","key":"a2V5","log_id":"aWQ="}`,
+		`{"type":"sequence_advanced","old_sequence_number":"1F"}`,
+	} {
+		var event Event
+		if err := json.Unmarshal([]byte(input), &event); err == nil {
+			t.Errorf("%s: decoded as %+v, want error", input, event)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"cmp"
+	"crypto/x509"
+	"slices"
+	"time"
+)
+
+// ExpiringRoot is a root whose certificate has expired, or will soon, while it
+// is still trusted
+type ExpiringRoot struct {
+	Entry    *Entry
+	NotAfter time.Time
+	Expired  bool // whether NotAfter is before the time passed to ExpiringRoots
+}
+
+// ExpiringRoots returns the roots which are active at the given time (see ActiveAt)
+// but whose certificates expire before at+within, ordered by expiration time.
+// Pass zero for within to find only the expired roots.  Roots whose certificates
+// can't be parsed are omitted.
+func ExpiringRoots(roots []ExportRoot, at time.Time, within time.Duration) []ExpiringRoot {
+	active := ActiveAt(at)
+	deadline := at.Add(within)
+	var expiring []ExpiringRoot
+	for _, root := range roots {
+		if !active(root.Entry) {
+			continue
+		}
+		cert, err := x509.ParseCertificate(root.Certificate)
+		if err != nil || !cert.NotAfter.Before(deadline) {
+			continue
+		}
+		expiring = append(expiring, ExpiringRoot{
+			Entry:    root.Entry,
+			NotAfter: cert.NotAfter,
+			Expired:  cert.NotAfter.Before(at),
+		})
+	}
+	slices.SortStableFunc(expiring, func(a, b ExpiringRoot) int { return cmp.Compare(a.NotAfter.UnixNano(), b.NotAfter.UnixNano()) })
+	return expiring
+}
@@ -0,0 +1,404 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// ExportRoot is a root certificate to be exported, along with its CTL entry
+type ExportRoot struct {
+	Entry       *Entry
+	Certificate []byte // DER
+}
+
+// ExportPEM writes each root certificate as a PEM block, preceded by a comment
+// containing its friendly name
+func ExportPEM(w io.Writer, roots []ExportRoot) error {
+	for _, root := range roots {
+		if _, err := fmt.Fprintf(w, "# %s\n", root.Entry.FriendlyName); err != nil {
+			return err
+		}
+		if err := pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: root.Certificate}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportCABundle writes the roots as a single PEM bundle in the style of distribution
+// ca-certificates packages, with each certificate preceded by comments describing
+// its owner, validity, fingerprints, trusted usages, and any distrust dates, so that
+// changes to the bundle are easy to review
+func ExportCABundle(w io.Writer, roots []ExportRoot) error {
+	var buf bytes.Buffer
+	buf.WriteString("##\n## Root certificates trusted by Microsoft, generated by software.sslmate.com/src/authrootstl\n##\n")
+	for _, root := range roots {
+		cert, err := x509.ParseCertificate(root.Certificate)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate %X: %w", root.Entry.SHA1, err)
+		}
+		label := root.Entry.FriendlyName
+		if label == "" {
+			label = cert.Subject.String()
+		}
+		sha256Hash := sha256.Sum256(root.Certificate)
+		fmt.Fprintf(&buf, "\n# %s\n", label)
+		if len(cert.Subject.Organization) > 0 {
+			fmt.Fprintf(&buf, "# Owner: %s\n", strings.Join(cert.Subject.Organization, ", "))
+		}
+		fmt.Fprintf(&buf, "# Subject: %s\n", cert.Subject)
+		fmt.Fprintf(&buf, "# Issuer: %s\n", cert.Issuer)
+		fmt.Fprintf(&buf, "# Serial: %X\n", cert.SerialNumber)
+		fmt.Fprintf(&buf, "# Validity: %s to %s\n", cert.NotBefore.UTC().Format(time.DateOnly), cert.NotAfter.UTC().Format(time.DateOnly))
+		fmt.Fprintf(&buf, "# SHA1 Fingerprint: %s\n", colonHex(root.Entry.SHA1[:]))
+		fmt.Fprintf(&buf, "# SHA256 Fingerprint: %s\n", colonHex(sha256Hash[:]))
+		fmt.Fprintf(&buf, "# Trusted for: %s\n", ekuList(root.Entry.EKUs))
+		if !root.Entry.DisallowedDate.IsZero() {
+			fmt.Fprintf(&buf, "# Disallowed: %s for %s\n", root.Entry.DisallowedDate.UTC().Format(time.DateOnly), ekuList(root.Entry.DisallowedEKUs))
+		}
+		if !root.Entry.NotBeforeDate.IsZero() {
+			fmt.Fprintf(&buf, "# Distrusted after: %s for %s\n", root.Entry.NotBeforeDate.UTC().Format(time.DateOnly), ekuList(root.Entry.NotBeforeEKUs))
+		}
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: root.Certificate}); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// colonHex formats b as upper-case hex bytes separated by colons, as OpenSSL does
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i := range b {
+		parts[i] = fmt.Sprintf("%02X", b[i])
+	}
+	return strings.Join(parts, ":")
+}
+
+// ekuList describes a list of EKUs, where an empty list means all usages
+func ekuList(ekus []asn1.ObjectIdentifier) string {
+	if len(ekus) == 0 {
+		return "all usages"
+	}
+	names := make([]string, len(ekus))
+	for i, eku := range ekus {
+		if name, ok := LookupOID(eku); ok {
+			names[i] = name
+		} else {
+			names[i] = eku.String()
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ExportCertdata writes the roots in the format of NSS's certdata.txt.  Trust for
+// server authentication, email protection, and code signing is derived from each
+// entry's EKUs and disallowed date as of the given time, and NotBeforeDate becomes
+// the distrust-after date.
+func ExportCertdata(w io.Writer, roots []ExportRoot, at time.Time) error {
+	var buf bytes.Buffer
+	buf.WriteString("#\n# Root certificates trusted by Microsoft, generated by software.sslmate.com/src/authrootstl\n#\n")
+	buf.WriteString("BEGINDATA\n")
+	for _, root := range roots {
+		cert, err := x509.ParseCertificate(root.Certificate)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate %X: %w", root.Entry.SHA1, err)
+		}
+		serialNumber, err := asn1.Marshal(cert.SerialNumber)
+		if err != nil {
+			return err
+		}
+		label := root.Entry.FriendlyName
+		if label == "" {
+			label = cert.Subject.String()
+		}
+		sha1Hash := sha1.Sum(root.Certificate)
+		md5Hash := md5.Sum(root.Certificate)
+
+		fmt.Fprintf(&buf, "\n# Certificate %q\n", label)
+		buf.WriteString("CKA_CLASS CK_OBJECT_CLASS CKO_CERTIFICATE\n")
+		writeCertdataCommon(&buf, label)
+		buf.WriteString("CKA_CERTIFICATE_TYPE CK_CERTIFICATE_TYPE CKC_X_509\n")
+		writeCertdataOctal(&buf, "CKA_SUBJECT", cert.RawSubject)
+		buf.WriteString("CKA_ID UTF8 \"0\"\n")
+		writeCertdataOctal(&buf, "CKA_ISSUER", cert.RawIssuer)
+		writeCertdataOctal(&buf, "CKA_SERIAL_NUMBER", serialNumber)
+		writeCertdataOctal(&buf, "CKA_VALUE", root.Certificate)
+		buf.WriteString("CKA_NSS_MOZILLA_CA_POLICY CK_BBOOL CK_TRUE\n")
+		writeCertdataDistrustAfter(&buf, "CKA_NSS_SERVER_DISTRUST_AFTER", root.Entry, oidServerAuth)
+		writeCertdataDistrustAfter(&buf, "CKA_NSS_EMAIL_DISTRUST_AFTER", root.Entry, oidEmailProtection)
+
+		fmt.Fprintf(&buf, "\n# Trust for %q\n", label)
+		buf.WriteString("CKA_CLASS CK_OBJECT_CLASS CKO_NSS_TRUST\n")
+		writeCertdataCommon(&buf, label)
+		writeCertdataOctal(&buf, "CKA_CERT_SHA1_HASH", sha1Hash[:])
+		writeCertdataOctal(&buf, "CKA_CERT_MD5_HASH", md5Hash[:])
+		writeCertdataOctal(&buf, "CKA_ISSUER", cert.RawIssuer)
+		writeCertdataOctal(&buf, "CKA_SERIAL_NUMBER", serialNumber)
+		fmt.Fprintf(&buf, "CKA_TRUST_SERVER_AUTH CK_TRUST %s\n", certdataTrust(root.Entry, oidServerAuth, at))
+		fmt.Fprintf(&buf, "CKA_TRUST_EMAIL_PROTECTION CK_TRUST %s\n", certdataTrust(root.Entry, oidEmailProtection, at))
+		fmt.Fprintf(&buf, "CKA_TRUST_CODE_SIGNING CK_TRUST %s\n", certdataTrust(root.Entry, oidCodeSigning, at))
+		buf.WriteString("CKA_TRUST_STEP_UP_APPROVED CK_BBOOL CK_FALSE\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeCertdataCommon(buf *bytes.Buffer, label string) {
+	buf.WriteString("CKA_TOKEN CK_BBOOL CK_TRUE\n")
+	buf.WriteString("CKA_PRIVATE CK_BBOOL CK_FALSE\n")
+	buf.WriteString("CKA_MODIFIABLE CK_BBOOL CK_FALSE\n")
+	fmt.Fprintf(buf, "CKA_LABEL UTF8 %q\n", label)
+}
+
+func writeCertdataOctal(buf *bytes.Buffer, name string, value []byte) {
+	fmt.Fprintf(buf, "%s MULTILINE_OCTAL\n", name)
+	for len(value) > 0 {
+		n := min(len(value), 16)
+		for _, b := range value[:n] {
+			fmt.Fprintf(buf, "\\%03o", b)
+		}
+		buf.WriteByte('\n')
+		value = value[n:]
+	}
+	buf.WriteString("END\n")
+}
+
+func writeCertdataDistrustAfter(buf *bytes.Buffer, name string, entry *Entry, eku asn1.ObjectIdentifier) {
+	if entry.NotBeforeDate.IsZero() || !appliesTo(entry.NotBeforeEKUs, eku) {
+		fmt.Fprintf(buf, "%s CK_BBOOL CK_FALSE\n", name)
+		return
+	}
+	writeCertdataOctal(buf, name, []byte(entry.NotBeforeDate.UTC().Format("060102150405Z")))
+}
+
+func certdataTrust(entry *Entry, eku asn1.ObjectIdentifier, at time.Time) string {
+	if entry.TrustedFor(eku, at) {
+		return "CKT_NSS_TRUSTED_DELEGATOR"
+	}
+	if !entry.DisallowedDate.IsZero() && !at.Before(entry.DisallowedDate) && appliesTo(entry.DisallowedEKUs, eku) {
+		return "CKT_NSS_NOT_TRUSTED"
+	}
+	return "CKT_NSS_MUST_VERIFY_TRUST"
+}
+
+// Serialized certificate store constants, from wincrypt.h
+const (
+	sstMagic            = 0x54524543 // "CERT"
+	sstCertElement      = 32         // CERT_CERT_PROP_ID
+	sstEndElement       = 0
+	sstFriendlyNameProp = 11 // CERT_FRIENDLY_NAME_PROP_ID
+	x509ASNEncoding     = 1
+)
+
+// ExportSST writes the roots as a Microsoft serialized certificate store (.sst file),
+// which can be imported with certutil or the Certificates MMC snap-in.  Each
+// certificate's friendly name is included as a property.
+func ExportSST(w io.Writer, roots []ExportRoot) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [2]uint32{0, sstMagic})
+	for _, root := range roots {
+		if root.Entry.FriendlyName != "" {
+			writeSSTElement(&buf, sstFriendlyNameProp, encodeUTF16String(root.Entry.FriendlyName))
+		}
+		writeSSTElement(&buf, sstCertElement, root.Certificate)
+	}
+	writeSSTElement(&buf, sstEndElement, nil)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSSTElement(buf *bytes.Buffer, id uint32, value []byte) {
+	binary.Write(buf, binary.LittleEndian, [3]uint32{id, x509ASNEncoding, uint32(len(value))})
+	buf.Write(value)
+}
+
+// encodeUTF16String encodes s as NUL-terminated little-endian UTF-16
+func encodeUTF16String(s string) []byte {
+	units := utf16.Encode([]rune(s + "\x00"))
+	encoded := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.LittleEndian.PutUint16(encoded[2*i:], unit)
+	}
+	return encoded
+}
+
+// ExportOpenSSLDir writes each root certificate to a PEM file in dir, along with
+// a symlink named after the OpenSSL subject hash, as created by `openssl rehash`,
+// so that dir can be used as an OpenSSL CApath
+func ExportOpenSSLDir(dir string, roots []ExportRoot) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	hashCounts := make(map[uint32]int)
+	for _, root := range roots {
+		cert, err := x509.ParseCertificate(root.Certificate)
+		if err != nil {
+			return fmt.Errorf("error parsing certificate %X: %w", root.Entry.SHA1, err)
+		}
+		hash, err := openSSLSubjectHash(cert.RawSubject)
+		if err != nil {
+			return fmt.Errorf("error hashing subject of certificate %X: %w", root.Entry.SHA1, err)
+		}
+		filename := fmt.Sprintf("%X.pem", root.Entry.SHA1)
+		pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Certificate})
+		if err := os.WriteFile(filepath.Join(dir, filename), pemBytes, 0666); err != nil {
+			return err
+		}
+		linkName := filepath.Join(dir, fmt.Sprintf("%08x.%d", hash, hashCounts[hash]))
+		hashCounts[hash]++
+		if err := os.Remove(linkName); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Symlink(filename, linkName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openSSLSubjectHash computes OpenSSL's X509_NAME_hash: the first four bytes (little endian)
+// of the SHA-1 hash of the name's canonical encoding, in which string values are converted
+// to UTF8String, ASCII letters are lowercased, whitespace is collapsed, and the outer
+// SEQUENCE tag is omitted
+func openSSLSubjectHash(rawName cryptobyte.String) (uint32, error) {
+	var rdns cryptobyte.String
+	if !rawName.ReadASN1(&rdns, cryptobyte_asn1.SEQUENCE) {
+		return 0, fmt.Errorf("malformed Name SEQUENCE")
+	}
+	var canonical cryptobyte.Builder
+	for !rdns.Empty() {
+		var rdn cryptobyte.String
+		if !rdns.ReadASN1(&rdn, cryptobyte_asn1.SET) {
+			return 0, fmt.Errorf("malformed RelativeDistinguishedName SET")
+		}
+		var canonicalAVAs [][]byte
+		for !rdn.Empty() {
+			var ava cryptobyte.String
+			var attributeType cryptobyte.String
+			var value cryptobyte.String
+			var valueTag cryptobyte_asn1.Tag
+			if !rdn.ReadASN1(&ava, cryptobyte_asn1.SEQUENCE) ||
+				!ava.ReadASN1Element(&attributeType, cryptobyte_asn1.OBJECT_IDENTIFIER) ||
+				!ava.ReadAnyASN1Element(&value, &valueTag) {
+				return 0, fmt.Errorf("malformed AttributeTypeAndValue")
+			}
+			var b cryptobyte.Builder
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddBytes(attributeType)
+				if s, ok := canonicalNameString(value, valueTag); ok {
+					b.AddASN1(cryptobyte_asn1.UTF8String, func(b *cryptobyte.Builder) { b.AddBytes([]byte(s)) })
+				} else {
+					b.AddBytes(value)
+				}
+			})
+			avaBytes, err := b.Bytes()
+			if err != nil {
+				return 0, err
+			}
+			canonicalAVAs = append(canonicalAVAs, avaBytes)
+		}
+		slices.SortFunc(canonicalAVAs, bytes.Compare) // DER requires SET OF elements to be sorted
+		canonical.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+			for _, avaBytes := range canonicalAVAs {
+				b.AddBytes(avaBytes)
+			}
+		})
+	}
+	canonicalBytes, err := canonical.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	hash := sha1.Sum(canonicalBytes)
+	return binary.LittleEndian.Uint32(hash[:4]), nil
+}
+
+// canonicalNameString returns the canonical form of a string-valued name attribute,
+// or false if the value is not a string type which OpenSSL canonicalizes
+func canonicalNameString(element cryptobyte.String, tag cryptobyte_asn1.Tag) (string, bool) {
+	var contents cryptobyte.String
+	if !element.ReadASN1(&contents, tag) {
+		return "", false
+	}
+	var s string
+	switch tag {
+	case cryptobyte_asn1.UTF8String, cryptobyte_asn1.PrintableString, cryptobyte_asn1.IA5String, cryptobyte_asn1.Tag(20) /* T61String */, cryptobyte_asn1.Tag(26) /* VisibleString */ :
+		s = string(contents)
+	case cryptobyte_asn1.Tag(30): // BMPString
+		if len(contents)%2 != 0 {
+			return "", false
+		}
+		units := make([]uint16, len(contents)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(contents[2*i:])
+		}
+		s = string(utf16.Decode(units))
+	case cryptobyte_asn1.Tag(28): // UniversalString
+		if len(contents)%4 != 0 {
+			return "", false
+		}
+		runes := make([]rune, len(contents)/4)
+		for i := range runes {
+			runes[i] = rune(binary.BigEndian.Uint32(contents[4*i:]))
+		}
+		s = string(runes)
+	default:
+		return "", false
+	}
+	s = strings.Join(strings.FieldsFunc(s, isOpenSSLSpace), " ")
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s), true
+}
+
+func isOpenSSLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\v' || r == '\f' || r == '\r'
+}
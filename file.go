@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"slices"
+)
+
+// ParseAuthrootstlFile parses the STL file with the given name.  Where the platform
+// supports it, the file is memory-mapped and parsed as if with WithZeroCopy, so the
+// CTL refers directly to the mapping.  Calling release unmaps the file, after which
+// the CTL and every byte slice obtained from it must no longer be used.  The file
+// must not be modified while it is mapped.  With WithCache, the cache stores a copy
+// of the CTL, which remains valid after release.
+func ParseAuthrootstlFile(filename string, opts ...ParseOption) (ctl *CTL, release func() error, err error) {
+	data, unmap, err := mapFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctl, err = ParseAuthrootstl(data, append(slices.Clip(opts), WithZeroCopy())...)
+	if err != nil {
+		unmap()
+		return nil, nil, err
+	}
+	return ctl, unmap, nil
+}
+
+// ParseAuthrootstlCabFile parses the CAB file with the given name, as
+// ParseAuthrootstlCab does.  Where the platform supports it, the CAB file is
+// memory-mapped while authroot.stl is extracted from it, rather than read into memory.
+func ParseAuthrootstlCabFile(filename string, opts ...ParseOption) (*CTL, error) {
+	data, unmap, err := mapFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer unmap()
+	return ParseAuthrootstlCab(bytes.NewReader(data), opts...)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFileCacheOutlivesRelease(t *testing.T) {
+	der := signTestCTL(t, testCacheCTL(), nil)
+	filename := filepath.Join(t.TempDir(), "authroot.stl")
+	if err := os.WriteFile(filename, der, 0666); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewLRUCache(1)
+	_, release, err := ParseAuthrootstlFile(filename, WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := release(); err != nil {
+		t.Fatal(err)
+	}
+	ctl, err := ParseAuthrootstl(der, WithCache(cache), WithZeroCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ctl.Entries[0].SubjectIdentifier, bytes.Repeat([]byte{1}, 20)) {
+		t.Errorf("cached CTL has subject identifier %x", ctl.Entries[0].SubjectIdentifier)
+	}
+}
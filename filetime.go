@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// filetimeEpochOffset is the number of seconds between the FILETIME
+// epoch (1601-01-01) and the Unix epoch (1970-01-01)
+const filetimeEpochOffset = 11644473600
+
+// filetimeTicksPerSecond is the number of 100-nanosecond FILETIME intervals in a second
+const filetimeTicksPerSecond = 10000000
+
+// FiletimeToTime converts a Windows FILETIME, which counts 100-nanosecond
+// intervals since 1601-01-01 UTC, to a time.Time in UTC.  Every FILETIME
+// value, including zero, converts to a valid time.
+func FiletimeToTime(filetime uint64) time.Time {
+	seconds := int64(filetime/filetimeTicksPerSecond) - filetimeEpochOffset
+	nanoseconds := int64(filetime%filetimeTicksPerSecond) * 100
+	return time.Unix(seconds, nanoseconds).UTC()
+}
+
+// TimeToFiletime converts t to a Windows FILETIME, truncating it to a
+// multiple of 100 nanoseconds.  It returns an error if t is before
+// 1601-01-01 UTC or too far in the future to be represented.
+func TimeToFiletime(t time.Time) (uint64, error) {
+	if t.Unix() < -filetimeEpochOffset {
+		return 0, fmt.Errorf("time %s is before the FILETIME epoch", t.UTC().Format(time.RFC3339))
+	}
+	seconds, ticks := uint64(t.Unix()+filetimeEpochOffset), uint64(t.Nanosecond()/100)
+	const maxSeconds, maxTicks = math.MaxUint64 / filetimeTicksPerSecond, math.MaxUint64 % filetimeTicksPerSecond
+	if t.Unix() > maxSeconds-filetimeEpochOffset || (seconds == maxSeconds && ticks > maxTicks) {
+		return 0, fmt.Errorf("time %s is too late to be represented as a FILETIME", t.UTC().Format(time.RFC3339))
+	}
+	return seconds*filetimeTicksPerSecond + ticks, nil
+}
+
+// ParseFiletime decodes an 8-byte little-endian FILETIME, as found in the
+// disallowed date and NotBefore date properties of a CTL entry
+func ParseFiletime(value []byte) (time.Time, error) {
+	if len(value) != 8 {
+		return time.Time{}, fmt.Errorf("FILETIME has wrong length %d", len(value))
+	}
+	return FiletimeToTime(binary.LittleEndian.Uint64(value)), nil
+}
+
+// MarshalFiletime encodes t as an 8-byte little-endian FILETIME, the inverse of ParseFiletime
+func MarshalFiletime(t time.Time) ([]byte, error) {
+	filetime, err := TimeToFiletime(t)
+	if err != nil {
+		return nil, err
+	}
+	return binary.LittleEndian.AppendUint64(nil, filetime), nil
+}
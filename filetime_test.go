@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+var filetimeTests = []struct {
+	filetime uint64
+	time     time.Time
+}{
+	{0, time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)},
+	{1, time.Date(1601, 1, 1, 0, 0, 0, 100, time.UTC)},
+	{116444736000000000, time.Unix(0, 0).UTC()},
+	{133801632000000000, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	{math.MaxUint64, time.Date(60056, 5, 28, 5, 36, 10, 955161500, time.UTC)},
+}
+
+func TestFiletimeToTime(t *testing.T) {
+	for _, test := range filetimeTests {
+		if got := FiletimeToTime(test.filetime); !got.Equal(test.time) || got.Location() != time.UTC {
+			t.Errorf("FiletimeToTime(%d) = %s, want %s", test.filetime, got, test.time)
+		}
+	}
+}
+
+func TestTimeToFiletime(t *testing.T) {
+	for _, test := range filetimeTests {
+		if got, err := TimeToFiletime(test.time); err != nil {
+			t.Errorf("TimeToFiletime(%s) failed: %s", test.time, err)
+		} else if got != test.filetime {
+			t.Errorf("TimeToFiletime(%s) = %d, want %d", test.time, got, test.filetime)
+		}
+	}
+}
+
+func TestTimeToFiletimeTruncates(t *testing.T) {
+	if got, err := TimeToFiletime(time.Unix(0, 199)); err != nil || got != 116444736000000001 {
+		t.Errorf("TimeToFiletime(199ns after the Unix epoch) = %d, %v, want 116444736000000001", got, err)
+	}
+}
+
+func TestTimeToFiletimeOutOfRange(t *testing.T) {
+	for _, tm := range []time.Time{
+		time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond),
+		time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(60056, 5, 28, 5, 36, 10, 955161600, time.UTC),
+		time.Date(60056, 5, 28, 5, 36, 11, 0, time.UTC),
+		time.Date(100000, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got, err := TimeToFiletime(tm); err == nil {
+			t.Errorf("TimeToFiletime(%s) = %d, want an error", tm, got)
+		}
+	}
+}
+
+func TestParseFiletime(t *testing.T) {
+	got, err := ParseFiletime([]byte{0x00, 0x80, 0x3e, 0xd5, 0xde, 0xb1, 0x9d, 0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Unix(0, 0).UTC(); !got.Equal(want) {
+		t.Errorf("ParseFiletime = %s, want %s", got, want)
+	}
+	for _, value := range [][]byte{nil, make([]byte, 7), make([]byte, 9)} {
+		if _, err := ParseFiletime(value); err == nil {
+			t.Errorf("ParseFiletime accepted a %d-byte value", len(value))
+		}
+	}
+}
+
+func TestMarshalFiletime(t *testing.T) {
+	for _, test := range filetimeTests {
+		value, err := MarshalFiletime(test.time)
+		if err != nil {
+			t.Errorf("MarshalFiletime(%s) failed: %s", test.time, err)
+			continue
+		}
+		if parsed, err := ParseFiletime(value); err != nil || !parsed.Equal(test.time) {
+			t.Errorf("ParseFiletime(MarshalFiletime(%s)) = %s, %v", test.time, parsed, err)
+		}
+	}
+	if value, _ := MarshalFiletime(time.Unix(0, 0)); !bytes.Equal(value, []byte{0x00, 0x80, 0x3e, 0xd5, 0xde, 0xb1, 0x9d, 0x01}) {
+		t.Errorf("MarshalFiletime(Unix epoch) = %x", value)
+	}
+}
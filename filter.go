@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"time"
+)
+
+// EntryFilter selects entries for FilterEntries
+type EntryFilter func(*Entry) bool
+
+// FilterEntries returns the entries which match all of the filters.  For example,
+// the roots currently trusted for server authentication are:
+//
+//	FilterEntries(ctl.Entries, TrustedForAt(serverAuth, time.Now()))
+func FilterEntries(entries []Entry, filters ...EntryFilter) []Entry {
+	var result []Entry
+	for i := range entries {
+		if matchesAll(&entries[i], filters) {
+			result = append(result, entries[i])
+		}
+	}
+	return result
+}
+
+func matchesAll(entry *Entry, filters []EntryFilter) bool {
+	for _, filter := range filters {
+		if !filter(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// EntriesWithEKU matches entries which are trusted for the given extended key usage,
+// ignoring any disallowed date
+func EntriesWithEKU(eku asn1.ObjectIdentifier) EntryFilter {
+	return func(entry *Entry) bool {
+		return appliesTo(entry.EKUs, eku)
+	}
+}
+
+// NotDisallowedAt matches entries which are not disallowed for any usage at the given time
+func NotDisallowedAt(at time.Time) EntryFilter {
+	return func(entry *Entry) bool {
+		return entry.DisallowedDate.IsZero() || at.Before(entry.DisallowedDate)
+	}
+}
+
+// ActiveAt matches entries which are trusted for at least one usage at the given time,
+// i.e. which are not disallowed at that time for all of their usages
+func ActiveAt(at time.Time) EntryFilter {
+	return func(entry *Entry) bool {
+		if entry.DisallowedDate.IsZero() || at.Before(entry.DisallowedDate) {
+			return true
+		}
+		if len(entry.DisallowedEKUs) == 0 {
+			return false
+		}
+		if len(entry.EKUs) == 0 {
+			return true
+		}
+		for _, eku := range entry.EKUs {
+			if !containsOID(entry.DisallowedEKUs, eku) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TrustedForAt matches entries which are trusted for the given extended key usage
+// at the given time (see Entry.TrustedFor)
+func TrustedForAt(eku asn1.ObjectIdentifier, at time.Time) EntryFilter {
+	return func(entry *Entry) bool {
+		return entry.TrustedFor(eku, at)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SHA1Fingerprint is the SHA-1 hash of a certificate.  The zero value means no hash.
+type SHA1Fingerprint [20]byte
+
+// SHA256Fingerprint is the SHA-256 hash of a certificate.  The zero value means no hash.
+type SHA256Fingerprint [32]byte
+
+// ParseSHA1Fingerprint parses a hex-encoded SHA-1 fingerprint, optionally with colons
+// between bytes (as printed by OpenSSL)
+func ParseSHA1Fingerprint(s string) (SHA1Fingerprint, error) {
+	var fingerprint SHA1Fingerprint
+	return fingerprint, parseFingerprint(fingerprint[:], s, "SHA-1")
+}
+
+// ParseSHA256Fingerprint parses a hex-encoded SHA-256 fingerprint, optionally with colons
+// between bytes (as printed by OpenSSL)
+func ParseSHA256Fingerprint(s string) (SHA256Fingerprint, error) {
+	var fingerprint SHA256Fingerprint
+	return fingerprint, parseFingerprint(fingerprint[:], s, "SHA-256")
+}
+
+func parseFingerprint(out []byte, s string, hashName string) error {
+	decoded, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil {
+		return fmt.Errorf("invalid %s fingerprint %q: %w", hashName, s, err)
+	}
+	if len(decoded) != len(out) {
+		return fmt.Errorf("invalid %s fingerprint %q: length is %d bytes instead of %d", hashName, s, len(decoded), len(out))
+	}
+	copy(out, decoded)
+	return nil
+}
+
+// Hex returns the fingerprint in lower-case hex without separators
+func (fingerprint SHA1Fingerprint) Hex() string { return hex.EncodeToString(fingerprint[:]) }
+
+// Hex returns the fingerprint in lower-case hex without separators
+func (fingerprint SHA256Fingerprint) Hex() string { return hex.EncodeToString(fingerprint[:]) }
+
+// IsZero reports whether the fingerprint is the zero value
+func (fingerprint SHA1Fingerprint) IsZero() bool { return fingerprint == SHA1Fingerprint{} }
+
+// IsZero reports whether the fingerprint is the zero value
+func (fingerprint SHA256Fingerprint) IsZero() bool { return fingerprint == SHA256Fingerprint{} }
+
+// MarshalText encodes the fingerprint as lower-case hex
+func (fingerprint SHA1Fingerprint) MarshalText() ([]byte, error) {
+	return []byte(fingerprint.Hex()), nil
+}
+
+// MarshalText encodes the fingerprint as lower-case hex
+func (fingerprint SHA256Fingerprint) MarshalText() ([]byte, error) {
+	return []byte(fingerprint.Hex()), nil
+}
+
+// UnmarshalText decodes a fingerprint as accepted by ParseSHA1Fingerprint
+func (fingerprint *SHA1Fingerprint) UnmarshalText(text []byte) error {
+	return parseFingerprint(fingerprint[:], string(text), "SHA-1")
+}
+
+// UnmarshalText decodes a fingerprint as accepted by ParseSHA256Fingerprint
+func (fingerprint *SHA256Fingerprint) UnmarshalText(text []byte) error {
+	return parseFingerprint(fingerprint[:], string(text), "SHA-256")
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const (
+	testSHA1Hex   = "3b1efd3a66ea28b16697394703a72ca340a05bd5"
+	testSHA256Hex = "df545bf919a2439c36983b54cdfc903dfa4f37d3996d8d84b4c31eec6f3c163e"
+)
+
+func TestParseSHA1Fingerprint(t *testing.T) {
+	for _, s := range []string{
+		testSHA1Hex,
+		"3B1EFD3A66EA28B16697394703A72CA340A05BD5",
+		"3B:1E:FD:3A:66:EA:28:B1:66:97:39:47:03:A7:2C:A3:40:A0:5B:D5",
+	} {
+		fingerprint, err := ParseSHA1Fingerprint(s)
+		if err != nil {
+			t.Errorf("ParseSHA1Fingerprint(%q) failed: %s", s, err)
+		} else if fingerprint.Hex() != testSHA1Hex {
+			t.Errorf("ParseSHA1Fingerprint(%q).Hex() = %s", s, fingerprint.Hex())
+		}
+	}
+}
+
+func TestParseSHA256Fingerprint(t *testing.T) {
+	fingerprint, err := ParseSHA256Fingerprint(testSHA256Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint.Hex() != testSHA256Hex {
+		t.Errorf("Hex() = %s", fingerprint.Hex())
+	}
+	if fingerprint.IsZero() {
+		t.Errorf("IsZero() = true")
+	}
+}
+
+func TestParseFingerprintErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		testSHA1Hex[:38],
+		testSHA1Hex + "00",
+		"zz" + testSHA1Hex[2:],
+		testSHA256Hex,
+	} {
+		if _, err := ParseSHA1Fingerprint(s); err == nil {
+			t.Errorf("ParseSHA1Fingerprint(%q) succeeded", s)
+		}
+	}
+	if _, err := ParseSHA256Fingerprint(testSHA1Hex); err == nil {
+		t.Errorf("ParseSHA256Fingerprint accepted a SHA-1 fingerprint")
+	}
+}
+
+func TestFingerprintJSON(t *testing.T) {
+	type fingerprints struct {
+		SHA1   SHA1Fingerprint
+		SHA256 SHA256Fingerprint
+	}
+	var in fingerprints
+	in.SHA1[0], in.SHA256[31] = 0xab, 0xcd
+	encoded, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"SHA1":"ab00000000000000000000000000000000000000","SHA256":"00000000000000000000000000000000000000000000000000000000000000cd"}`
+	if string(encoded) != want {
+		t.Errorf("json.Marshal = %s, want %s", encoded, want)
+	}
+	var out fingerprints
+	if err := json.Unmarshal(encoded, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("round trip changed %+v to %+v", in, out)
+	}
+	if err := json.Unmarshal([]byte(`{"SHA1":"ab"}`), &out); err == nil {
+		t.Errorf("json.Unmarshal accepted a short fingerprint")
+	}
+	if !(SHA1Fingerprint{}).IsZero() {
+		t.Errorf("zero SHA1Fingerprint is not IsZero")
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// CTLogKey is the DER-encoded SubjectPublicKeyInfo of a CT log recognized by Microsoft
+type CTLogKey []byte
+
+// LogID returns the log's ID, the SHA-256 hash of its key
+func (key CTLogKey) LogID() [32]byte {
+	return sha256.Sum256(key)
+}
+
+// String returns the base64-encoded log ID, the form in which CT log lists identify logs
+func (key CTLogKey) String() string {
+	logID := key.LogID()
+	return base64.StdEncoding.EncodeToString(logID[:])
+}
+
+// String summarizes the CTL, e.g. "sequence number 1A2B, effective 2025-03-01, 412 entries, 9 CT logs"
+func (ctl *CTL) String() string {
+	return fmt.Sprintf("sequence number %X, effective %s, %d entries, %d CT logs",
+		&ctl.SequenceNumber, ctl.EffectiveDate.Format(time.DateOnly), len(ctl.Entries), len(ctl.CTLogs))
+}
+
+// String returns the entry's subject identifier (normally the SHA-1 hash) in upper-case hex followed by its friendly name, if any
+func (entry *Entry) String() string {
+	if entry.FriendlyName == "" {
+		return fmt.Sprintf("%X", entry.SubjectIdentifier)
+	}
+	return fmt.Sprintf("%X %q", entry.SubjectIdentifier, entry.FriendlyName)
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"maps"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	testNotBefore = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testNotAfter  = time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC)
+	testTime      = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type testIssuer struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// newTestCert creates a P-256 certificate issued by parent, or self-signed if parent is nil
+func newTestCert(t testing.TB, name string, parent *testIssuer, isCA bool, ekus []x509.ExtKeyUsage, unknownEKUs ...asn1.ObjectIdentifier) *testIssuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             testNotBefore,
+		NotAfter:              testNotAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           ekus,
+		UnknownExtKeyUsage:    unknownEKUs,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	issuerCert, issuerKey := template, key
+	if parent != nil {
+		issuerCert, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testIssuer{key: key, cert: cert}
+}
+
+type testEntry struct {
+	identifier []byte
+	properties map[int][]byte // keyed by the last arc of 1.3.6.1.4.1.311.10.11.n
+}
+
+// newTestCTL returns the DER encoding of a CTL with the given sequence number and entries
+func newTestCTL(sequenceNumber int64, entries []testEntry) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 9})
+		})
+		b.AddASN1Int64(sequenceNumber)
+		b.AddASN1UTCTime(testTime)
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26})
+		})
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			for _, entry := range entries {
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1OctetString(entry.identifier)
+					b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+						for _, n := range slices.Sorted(maps.Keys(entry.properties)) {
+							value := entry.properties[n]
+							b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+								b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, n})
+								b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) { b.AddASN1OctetString(value) })
+							})
+						}
+					})
+				})
+			}
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+// signTestCTL wraps ctl in a PKCS#7 SignedData signed by signer, which includes
+// certificates.  If signer is nil, the SignedData has no signers.
+func signTestCTL(t testing.TB, ctl []byte, signer *testIssuer, certificates ...*x509.Certificate) []byte {
+	t.Helper()
+	// the message digest covers the contents octets of the CTL SEQUENCE
+	input := cryptobyte.String(ctl)
+	var contents cryptobyte.String
+	if !input.ReadASN1(&contents, cryptobyte_asn1.SEQUENCE) {
+		t.Fatal("malformed CTL")
+	}
+	contentDigest := sha256.Sum256(contents)
+	addAttributes := func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(oidMessageDigest)
+			b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) { b.AddASN1OctetString(contentDigest[:]) })
+		})
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(oidSigningTime)
+			b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) { b.AddASN1UTCTime(testTime) })
+		})
+	}
+	var signature []byte
+	if signer != nil {
+		var attributes cryptobyte.Builder
+		attributes.AddASN1(cryptobyte_asn1.SET, addAttributes)
+		attributesDigest := sha256.Sum256(attributes.BytesOrPanic())
+		var err error
+		if signature, err = signer.key.Sign(rand.Reader, attributesDigest[:], crypto.SHA256); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2})
+		b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1Int64(1)
+				b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+					b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1})
+					})
+				})
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 1})
+					b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) { b.AddBytes(ctl) })
+				})
+				b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+					for _, cert := range certificates {
+						b.AddBytes(cert.Raw)
+					}
+				})
+				b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+					if signer == nil {
+						return
+					}
+					b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1Int64(1)
+						b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+							b.AddBytes(signer.cert.RawIssuer)
+							b.AddASN1BigInt(signer.cert.SerialNumber)
+						})
+						b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+							b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1})
+						})
+						b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), addAttributes)
+						b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+							b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2})
+						})
+						b.AddASN1OctetString(signature)
+					})
+				})
+			})
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+// newTestCAB returns an uncompressed CAB file containing the given file
+func newTestCAB(name string, contents []byte) []byte {
+	const headerSize, folderSize, fileHeaderSize = 36, 8, 16
+	var blocks [][]byte
+	for rest := contents; len(rest) > 0; {
+		n := min(len(rest), 32768)
+		blocks, rest = append(blocks, rest[:n]), rest[n:]
+	}
+	filesOffset := headerSize + folderSize
+	dataOffset := filesOffset + fileHeaderSize + len(name) + 1
+	size := dataOffset + 8*len(blocks) + len(contents)
+
+	cab := []byte("MSCF")
+	cab = binary.LittleEndian.AppendUint32(cab, 0)
+	cab = binary.LittleEndian.AppendUint32(cab, uint32(size))
+	cab = binary.LittleEndian.AppendUint32(cab, 0)
+	cab = binary.LittleEndian.AppendUint32(cab, uint32(filesOffset))
+	cab = binary.LittleEndian.AppendUint32(cab, 0)
+	cab = append(cab, 3, 1)                        // version 1.3
+	cab = binary.LittleEndian.AppendUint16(cab, 1) // folders
+	cab = binary.LittleEndian.AppendUint16(cab, 1) // files
+	cab = binary.LittleEndian.AppendUint16(cab, 0) // flags
+	cab = binary.LittleEndian.AppendUint16(cab, 0) // set ID
+	cab = binary.LittleEndian.AppendUint16(cab, 0) // cabinet number
+	cab = binary.LittleEndian.AppendUint32(cab, uint32(dataOffset))
+	cab = binary.LittleEndian.AppendUint16(cab, uint16(len(blocks)))
+	cab = binary.LittleEndian.AppendUint16(cab, 0) // no compression
+	cab = binary.LittleEndian.AppendUint32(cab, uint32(len(contents)))
+	cab = binary.LittleEndian.AppendUint32(cab, 0)      // offset in folder
+	cab = binary.LittleEndian.AppendUint16(cab, 0)      // folder index
+	cab = binary.LittleEndian.AppendUint16(cab, 0x5021) // date
+	cab = binary.LittleEndian.AppendUint16(cab, 0)      // time
+	cab = binary.LittleEndian.AppendUint16(cab, 0x20)   // attributes
+	cab = append(append(cab, name...), 0)
+	for _, block := range blocks {
+		cab = binary.LittleEndian.AppendUint32(cab, 0) // no checksum
+		cab = binary.LittleEndian.AppendUint16(cab, uint16(len(block)))
+		cab = binary.LittleEndian.AppendUint16(cab, uint16(len(block)))
+		cab = append(cab, block...)
+	}
+	return cab
+}
@@ -0,0 +1,357 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package history maintains a durable record of every observed CTL and how it
+// differed from its predecessor, in a directory.  History implements
+// authrootstl.HistoryStore, so it can be passed to authrootstl.Watcher.
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// ErrNoRaw is returned by Put when the CTL has no Raw encoding to record, because it
+// was parsed with authrootstl.WithEntryFields or compacted
+var ErrNoRaw = errors.New("CTL has no raw encoding to record")
+
+const (
+	recordsFilename = "history.jsonl"
+	latestFilename  = "latest.stl"
+	stlsDirname     = "stls"
+)
+
+// Record describes one observed CTL.  Diff is relative to the previously recorded
+// CTL; for the first record it is relative to an empty CTL, so every root appears
+// as added.
+type Record struct {
+	ObservedAt     time.Time            `json:"observed_at"`
+	SequenceNumber string               `json:"sequence_number"`
+	EffectiveDate  time.Time            `json:"effective_date"`
+	Roots          int                  `json:"roots"`
+	Diff           authrootstl.JSONDiff `json:"diff"`
+}
+
+// NewRecord returns a record for newCTL, observed at the given time, with a diff
+// relative to oldCTL
+func NewRecord(oldCTL, newCTL *authrootstl.CTL, observedAt time.Time) Record {
+	return Record{
+		ObservedAt:     observedAt.UTC(),
+		SequenceNumber: fmt.Sprintf("%X", &newCTL.SequenceNumber),
+		EffectiveDate:  newCTL.EffectiveDate,
+		Roots:          len(newCTL.Entries),
+		Diff:           authrootstl.Diff(oldCTL, newCTL).JSON(),
+	}
+}
+
+// Store is a history of observed CTLs, kept in a directory (History) or an
+// SQLite database (see package history/sqlite)
+type Store interface {
+	authrootstl.HistoryStore
+
+	// Latest returns the most recently recorded CTL, or nil if nothing has been recorded
+	Latest() (*authrootstl.CTL, error)
+
+	// Records returns all records, oldest first
+	Records() ([]Record, error)
+
+	// Query returns the records matching q, oldest first
+	Query(q Query) ([]Record, error)
+
+	Close() error
+}
+
+// Query selects records from a Store
+type Query struct {
+	// Since and Until, if non-zero, restrict the records to CTLs effective
+	// in the range [Since, Until)
+	Since, Until time.Time
+
+	// Root, if non-empty, is the hex SHA-1 or SHA-256 fingerprint of a root.
+	// Only records whose diff includes the root match, and their diffs are
+	// reduced to that root.
+	Root string
+}
+
+// Match reports whether record matches q, reducing its diff to q.Root if set
+func (q *Query) Match(record *Record) bool {
+	if !q.Since.IsZero() && record.EffectiveDate.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !record.EffectiveDate.Before(q.Until) {
+		return false
+	}
+	if q.Root == "" {
+		return true
+	}
+	hash := strings.ToLower(q.Root)
+	matches := func(roots []authrootstl.JSONDiffRoot) []authrootstl.JSONDiffRoot {
+		var result []authrootstl.JSONDiffRoot
+		for _, root := range roots {
+			if root.SHA1 == hash || root.SHA256 == hash {
+				result = append(result, root)
+			}
+		}
+		return result
+	}
+	record.Diff.AddedRoots = matches(record.Diff.AddedRoots)
+	record.Diff.RemovedRoots = matches(record.Diff.RemovedRoots)
+	record.Diff.ChangedRoots = matches(record.Diff.ChangedRoots)
+	record.Diff.AddedCTLogs = nil
+	record.Diff.RemovedCTLogs = nil
+	return len(record.Diff.AddedRoots) > 0 || len(record.Diff.RemovedRoots) > 0 || len(record.Diff.ChangedRoots) > 0
+}
+
+// History is a directory containing an append-only JSON Lines file of records,
+// a copy of every recorded STL file named after its sequence number, and a copy
+// of the most recently recorded STL file for computing the next diff.
+type History struct {
+	dir string
+}
+
+var _ Store = (*History)(nil)
+
+// Open opens the history in dir, creating dir if necessary
+func Open(dir string) (*History, error) {
+	if err := os.MkdirAll(filepath.Join(dir, stlsDirname), 0777); err != nil {
+		return nil, err
+	}
+	return &History{dir: dir}, nil
+}
+
+func (h *History) stlFilename(sequenceNumber *big.Int) string {
+	return filepath.Join(h.dir, stlsDirname, fmt.Sprintf("%X.stl", sequenceNumber))
+}
+
+// Latest returns the most recently recorded CTL, or nil if nothing has been recorded
+func (h *History) Latest() (*authrootstl.CTL, error) {
+	ctl, err := readCTL(filepath.Join(h.dir, latestFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return ctl, err
+}
+
+// Put appends a record for ctl, unless it has the same sequence number as the
+// most recently recorded CTL.  It returns true if a record was appended.  If ctl is
+// older than the most recently recorded CTL, Put returns a *authrootstl.RollbackError.
+// If ctl has no Raw encoding, Put returns ErrNoRaw.
+func (h *History) Put(ctx context.Context, ctl *authrootstl.CTL, observedAt time.Time) (bool, error) {
+	if len(ctl.Raw) == 0 {
+		return false, ErrNoRaw
+	}
+	latest, err := h.Latest()
+	if err != nil {
+		return false, fmt.Errorf("error reading latest recorded CTL: %w", err)
+	}
+	if latest == nil {
+		latest = new(authrootstl.CTL)
+	} else if err := ctl.CheckRollback(&latest.SequenceNumber, latest.EffectiveDate); err != nil {
+		return false, err
+	} else if latest.SequenceNumber.Cmp(&ctl.SequenceNumber) == 0 {
+		return false, nil
+	}
+
+	line, err := json.Marshal(NewRecord(latest, ctl, observedAt))
+	if err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(h.stlFilename(&ctl.SequenceNumber), ctl.Raw); err != nil {
+		return false, fmt.Errorf("error saving CTL: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(h.dir, recordsFilename), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return false, err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return false, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return false, err
+	}
+	if err := file.Close(); err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(filepath.Join(h.dir, latestFilename), ctl.Raw); err != nil {
+		return true, fmt.Errorf("error saving latest CTL: %w", err)
+	}
+	return true, nil
+}
+
+// Records returns all records, oldest first
+func (h *History) Records() ([]Record, error) {
+	file, err := os.Open(filepath.Join(h.dir, recordsFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", recordsFilename, lineNumber, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// GetBySequence returns the recorded CTL with the given sequence number, or nil if
+// there is none
+func (h *History) GetBySequence(ctx context.Context, sequenceNumber *big.Int) (*authrootstl.CTL, error) {
+	ctl, err := readCTL(h.stlFilename(sequenceNumber))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return ctl, err
+}
+
+// List returns the metadata of every recorded CTL, oldest first
+func (h *History) List(ctx context.Context) ([]authrootstl.HistoryItem, error) {
+	records, err := h.Records()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]authrootstl.HistoryItem, 0, len(records))
+	for _, record := range records {
+		sequenceNumber, ok := new(big.Int).SetString(record.SequenceNumber, 16)
+		if !ok {
+			return nil, fmt.Errorf("%s contains invalid sequence number %q", recordsFilename, record.SequenceNumber)
+		}
+		items = append(items, authrootstl.HistoryItem{
+			SequenceNumber: sequenceNumber,
+			EffectiveDate:  record.EffectiveDate,
+			ObservedAt:     record.ObservedAt,
+		})
+	}
+	return items, nil
+}
+
+// DiffRange returns the diff of each recorded CTL whose sequence number is in the
+// range [from, to] against the CTL recorded before it, oldest first.  The first
+// recorded CTL is diffed against an empty CTL.
+func (h *History) DiffRange(ctx context.Context, from, to *big.Int) ([]*authrootstl.CTLDiff, error) {
+	return DiffRange(ctx, h, from, to)
+}
+
+// Query returns the records matching q, oldest first
+func (h *History) Query(q Query) ([]Record, error) {
+	records, err := h.Records()
+	if err != nil {
+		return nil, err
+	}
+	var matching []Record
+	for _, record := range records {
+		if q.Match(&record) {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+// Close does nothing; it exists so that History implements Store
+func (h *History) Close() error {
+	return nil
+}
+
+// readCTL parses the bare STL file named filename
+func readCTL(filename string) (*authrootstl.CTL, error) {
+	der, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ctl, err := authrootstl.ParseAuthrootstl(der, authrootstl.WithZeroCopy())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return ctl, nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to filename, so
+// that a crash never leaves filename partially written
+func writeFileAtomic(filename string, data []byte) error {
+	tempFilename := filename + ".tmp"
+	if err := os.WriteFile(tempFilename, data, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tempFilename, filename)
+}
+
+// DiffRange implements authrootstl.HistoryStore.DiffRange using store's List and
+// GetBySequence, for Stores which have no better way
+func DiffRange(ctx context.Context, store authrootstl.HistoryStore, from, to *big.Int) ([]*authrootstl.CTLDiff, error) {
+	items, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []*authrootstl.CTLDiff
+	previous := new(authrootstl.CTL)
+	for i := range items {
+		if !items[i].InRange(from, to) {
+			continue
+		}
+		if len(diffs) == 0 && i > 0 {
+			if previous, err = mustGet(ctx, store, items[i-1].SequenceNumber); err != nil {
+				return nil, err
+			}
+		}
+		ctl, err := mustGet(ctx, store, items[i].SequenceNumber)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, authrootstl.Diff(previous, ctl))
+		previous = ctl
+	}
+	return diffs, nil
+}
+
+func mustGet(ctx context.Context, store authrootstl.HistoryStore, sequenceNumber *big.Int) (*authrootstl.CTL, error) {
+	ctl, err := store.GetBySequence(ctx, sequenceNumber)
+	if err != nil {
+		return nil, err
+	} else if ctl == nil {
+		return nil, fmt.Errorf("CTL with sequence number %X is not saved in the history", sequenceNumber)
+	}
+	return ctl, nil
+}
@@ -0,0 +1,255 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package history_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/history"
+	"software.sslmate.com/src/authrootstl/history/sqlite"
+)
+
+var testObservedAt = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestSTL returns an unsigned STL file with the given sequence number and
+// effective date, listing a root with the subject identifier bytes.Repeat([]byte{id}, 20)
+// for each id
+func newTestSTL(sequenceNumber int64, effectiveDate time.Time, ids ...byte) []byte {
+	var ctl cryptobyte.Builder
+	ctl.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 9})
+		})
+		b.AddASN1Int64(sequenceNumber)
+		b.AddASN1UTCTime(effectiveDate)
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26})
+		})
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			for _, id := range ids {
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1OctetString(bytes.Repeat([]byte{id}, 20))
+					b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {})
+				})
+			}
+		})
+	})
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2})
+		b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1Int64(1)
+				b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {})
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1ObjectIdentifier(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 1})
+					b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) { b.AddBytes(ctl.BytesOrPanic()) })
+				})
+				b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {})
+			})
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+func parseTestSTL(t *testing.T, der []byte, opts ...authrootstl.ParseOption) *authrootstl.CTL {
+	t.Helper()
+	ctl, err := authrootstl.ParseAuthrootstl(der, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctl
+}
+
+func rootHex(id byte) string {
+	return hex.EncodeToString(bytes.Repeat([]byte{id}, 20))
+}
+
+var stores = map[string]func(t *testing.T) history.Store{
+	"directory": func(t *testing.T) history.Store {
+		h, err := history.Open(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	},
+	"sqlite": func(t *testing.T) history.Store {
+		h, err := sqlite.Open(filepath.Join(t.TempDir(), "history.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	},
+}
+
+func TestStore(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) { testStore(t, open(t)) })
+	}
+}
+
+func testStore(t *testing.T, store history.Store) {
+	defer store.Close()
+	ctx := context.Background()
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	stl1 := newTestSTL(1, january, 1, 2)
+	stl2 := newTestSTL(2, february, 2, 3)
+
+	if latest, err := store.Latest(); err != nil || latest != nil {
+		t.Fatalf("Latest of empty history returned %v, %v", latest, err)
+	}
+	for _, test := range []struct {
+		stl  []byte
+		want bool
+	}{
+		{stl1, true},
+		{stl1, false},
+		{stl2, true},
+	} {
+		if recorded, err := store.Put(ctx, parseTestSTL(t, test.stl), testObservedAt); err != nil {
+			t.Fatal(err)
+		} else if recorded != test.want {
+			t.Errorf("Put returned %v, want %v", recorded, test.want)
+		}
+	}
+	var rollbackErr *authrootstl.RollbackError
+	if _, err := store.Put(ctx, parseTestSTL(t, stl1), testObservedAt); !errors.As(err, &rollbackErr) {
+		t.Errorf("Put of an older CTL returned %v, want a RollbackError", err)
+	}
+
+	if latest, err := store.Latest(); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(latest.Raw, stl2) {
+		t.Errorf("Latest is sequence number %X, want 2", &latest.SequenceNumber)
+	}
+	if ctl, err := store.GetBySequence(ctx, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	} else if ctl == nil || !bytes.Equal(ctl.Raw, stl1) {
+		t.Errorf("GetBySequence(1) returned the wrong CTL")
+	}
+	if ctl, err := store.GetBySequence(ctx, big.NewInt(3)); err != nil || ctl != nil {
+		t.Errorf("GetBySequence(3) returned %v, %v, want nil", ctl, err)
+	}
+
+	records, err := store.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Records returned %d records, want 2", len(records))
+	}
+	second := records[1]
+	if second.SequenceNumber != "2" || !second.EffectiveDate.Equal(february) || !second.ObservedAt.Equal(testObservedAt) || second.Roots != 2 {
+		t.Errorf("second record is %+v", second)
+	}
+	if len(second.Diff.AddedRoots) != 1 || second.Diff.AddedRoots[0].SHA1 != rootHex(3) {
+		t.Errorf("second record added %+v, want only root 3", second.Diff.AddedRoots)
+	}
+	if len(second.Diff.RemovedRoots) != 1 || second.Diff.RemovedRoots[0].SHA1 != rootHex(1) {
+		t.Errorf("second record removed %+v, want only root 1", second.Diff.RemovedRoots)
+	}
+	if len(records[0].Diff.AddedRoots) != 2 {
+		t.Errorf("first record added %d roots, want 2", len(records[0].Diff.AddedRoots))
+	}
+
+	for _, test := range []struct {
+		name  string
+		query history.Query
+		want  []string
+	}{
+		{"all", history.Query{}, []string{"1", "2"}},
+		{"since", history.Query{Since: february}, []string{"2"}},
+		{"until", history.Query{Until: february}, []string{"1"}},
+		{"root 1", history.Query{Root: rootHex(1)}, []string{"1", "2"}},
+		{"root 3", history.Query{Root: rootHex(3)}, []string{"2"}},
+		{"unknown root", history.Query{Root: rootHex(4)}, nil},
+	} {
+		records, err := store.Query(test.query)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var got []string
+		for _, record := range records {
+			got = append(got, record.SequenceNumber)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: Query returned %q, want %q", test.name, got, test.want)
+		}
+	}
+	if records, err := store.Query(history.Query{Root: rootHex(3)}); err == nil && len(records) == 1 {
+		diff := records[0].Diff
+		if len(diff.AddedRoots) != 1 || len(diff.RemovedRoots) != 0 || len(diff.ChangedRoots) != 0 {
+			t.Errorf("Query by root did not reduce the diff to the root: %+v", diff)
+		}
+	}
+
+	items, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].SequenceNumber.Int64() != 1 || items[1].SequenceNumber.Int64() != 2 {
+		t.Errorf("List returned %+v", items)
+	}
+	diffs, err := store.DiffRange(ctx, big.NewInt(2), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || len(diffs[0].AddedRoots) != 1 || len(diffs[0].RemovedRoots) != 1 {
+		t.Errorf("DiffRange(2, 2) returned %+v", diffs)
+	}
+}
+
+func TestStorePutRequiresRaw(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+			ctl := parseTestSTL(t, newTestSTL(1, testObservedAt, 1), authrootstl.WithEntryFields(0))
+			if _, err := store.Put(context.Background(), ctl, testObservedAt); !errors.Is(err, history.ErrNoRaw) {
+				t.Errorf("Put of a CTL without Raw returned %v, want ErrNoRaw", err)
+			}
+			if latest, err := store.Latest(); err != nil || latest != nil {
+				t.Errorf("after failed Put, Latest returned %v, %v", latest, err)
+			}
+		})
+	}
+}
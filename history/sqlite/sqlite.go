@@ -0,0 +1,282 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package sqlite keeps a history of observed CTLs in an SQLite database, as an
+// alternative to the directory kept by package history.  It is a separate
+// package so that programs which don't use it don't link SQLite.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/history"
+)
+
+// sqliteSchema creates the tables of an SQLite history.  Each recorded CTL is a row
+// of ctls, and each root it added, removed, or changed relative to the previous CTL
+// is a row of root_changes, so that questions such as when a root was added can be
+// answered with SQL.  Dates are in RFC 3339 format, in UTC with nine fractional
+// digits (see sqliteDateFormat), so they sort correctly as strings.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS ctls (
+	id              INTEGER PRIMARY KEY,
+	sequence_number TEXT NOT NULL UNIQUE, -- uppercase hex
+	effective_date  TEXT NOT NULL,
+	observed_at     TEXT NOT NULL,
+	roots           INTEGER NOT NULL,
+	diff            TEXT NOT NULL,        -- JSON, as in stldiff -json
+	stl             BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS root_changes (
+	ctl_id        INTEGER NOT NULL REFERENCES ctls (id),
+	change        TEXT NOT NULL,          -- added, removed, or changed
+	sha1          TEXT NOT NULL,          -- lowercase hex
+	sha256        TEXT NOT NULL,          -- lowercase hex, or empty if unknown
+	friendly_name TEXT NOT NULL,
+	details       TEXT NOT NULL           -- for changed roots, the changes, separated by "; "
+);
+CREATE INDEX IF NOT EXISTS root_changes_sha1 ON root_changes (sha1);
+CREATE INDEX IF NOT EXISTS root_changes_sha256 ON root_changes (sha256);
+CREATE INDEX IF NOT EXISTS ctls_effective_date ON ctls (effective_date);
+`
+
+// History is a history in an SQLite database.  CTLs are recorded in the order
+// observed, which Put ensures is also the order of their sequence numbers.
+type History struct {
+	db *sql.DB
+}
+
+var _ history.Store = (*History)(nil)
+
+// Open opens the SQLite history in filename, creating it if necessary
+func Open(filename string) (*History, error) {
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return nil, err
+	}
+	// a single connection serializes Put's transactions and keeps the pragmas in effect
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 10000; PRAGMA foreign_keys = ON;" + sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &History{db: db}, nil
+}
+
+// Close closes the database
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+const sqliteDateFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format(sqliteDateFormat)
+}
+
+func parseDate(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// Latest returns the most recently recorded CTL, or nil if nothing has been recorded
+func (h *History) Latest() (*authrootstl.CTL, error) {
+	return h.getCTL(context.Background(), "SELECT stl FROM ctls ORDER BY id DESC LIMIT 1")
+}
+
+// GetBySequence returns the recorded CTL with the given sequence number, or nil if
+// there is none
+func (h *History) GetBySequence(ctx context.Context, sequenceNumber *big.Int) (*authrootstl.CTL, error) {
+	return h.getCTL(ctx, "SELECT stl FROM ctls WHERE sequence_number = ?", fmt.Sprintf("%X", sequenceNumber))
+}
+
+func (h *History) getCTL(ctx context.Context, query string, args ...any) (*authrootstl.CTL, error) {
+	var der []byte
+	if err := h.db.QueryRowContext(ctx, query, args...).Scan(&der); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return authrootstl.ParseAuthrootstl(der, authrootstl.WithZeroCopy())
+}
+
+// Put records ctl, unless it has the same sequence number as the most recently
+// recorded CTL.  It returns true if ctl was recorded.  If ctl is older than the most
+// recently recorded CTL, Put returns a *authrootstl.RollbackError.  If ctl has no
+// Raw encoding, Put returns history.ErrNoRaw.
+func (h *History) Put(ctx context.Context, ctl *authrootstl.CTL, observedAt time.Time) (bool, error) {
+	if len(ctl.Raw) == 0 {
+		return false, history.ErrNoRaw
+	}
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	latest := new(authrootstl.CTL)
+	var latestDER []byte
+	if err := tx.QueryRowContext(ctx, "SELECT stl FROM ctls ORDER BY id DESC LIMIT 1").Scan(&latestDER); err == nil {
+		if latest, err = authrootstl.ParseAuthrootstl(latestDER, authrootstl.WithZeroCopy()); err != nil {
+			return false, fmt.Errorf("error reading latest recorded CTL: %w", err)
+		}
+		if err := ctl.CheckRollback(&latest.SequenceNumber, latest.EffectiveDate); err != nil {
+			return false, err
+		} else if latest.SequenceNumber.Cmp(&ctl.SequenceNumber) == 0 {
+			return false, nil
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("error reading latest recorded CTL: %w", err)
+	}
+
+	record := history.NewRecord(latest, ctl, observedAt)
+	diffJSON, err := json.Marshal(record.Diff)
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx, "INSERT INTO ctls (sequence_number, effective_date, observed_at, roots, diff, stl) VALUES (?, ?, ?, ?, ?, ?)",
+		record.SequenceNumber, formatDate(record.EffectiveDate), formatDate(record.ObservedAt), record.Roots, string(diffJSON), ctl.Raw)
+	if err != nil {
+		return false, fmt.Errorf("error saving CTL: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+	for _, changes := range []struct {
+		change string
+		roots  []authrootstl.JSONDiffRoot
+	}{
+		{"added", record.Diff.AddedRoots},
+		{"removed", record.Diff.RemovedRoots},
+		{"changed", record.Diff.ChangedRoots},
+	} {
+		for _, root := range changes.roots {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO root_changes (ctl_id, change, sha1, sha256, friendly_name, details) VALUES (?, ?, ?, ?, ?, ?)",
+				id, changes.change, root.SHA1, root.SHA256, root.FriendlyName, strings.Join(root.Changes, "; ")); err != nil {
+				return false, fmt.Errorf("error saving root changes: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Records returns all records, oldest first
+func (h *History) Records() ([]history.Record, error) {
+	return h.Query(history.Query{})
+}
+
+// Query returns the records matching q, oldest first
+func (h *History) Query(q history.Query) ([]history.Record, error) {
+	query := "SELECT sequence_number, effective_date, observed_at, roots, diff FROM ctls WHERE 1"
+	var args []any
+	if !q.Since.IsZero() {
+		query += " AND effective_date >= ?"
+		args = append(args, formatDate(q.Since))
+	}
+	if !q.Until.IsZero() {
+		query += " AND effective_date < ?"
+		args = append(args, formatDate(q.Until))
+	}
+	if q.Root != "" {
+		query += " AND id IN (SELECT ctl_id FROM root_changes WHERE sha1 = ? OR sha256 = ?)"
+		args = append(args, strings.ToLower(q.Root), strings.ToLower(q.Root))
+	}
+	rows, err := h.db.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []history.Record
+	for rows.Next() {
+		var record history.Record
+		var effectiveDate, observedAt, diffJSON string
+		if err := rows.Scan(&record.SequenceNumber, &effectiveDate, &observedAt, &record.Roots, &diffJSON); err != nil {
+			return nil, err
+		}
+		if record.EffectiveDate, err = parseDate(effectiveDate); err != nil {
+			return nil, fmt.Errorf("record %s: %w", record.SequenceNumber, err)
+		}
+		if record.ObservedAt, err = parseDate(observedAt); err != nil {
+			return nil, fmt.Errorf("record %s: %w", record.SequenceNumber, err)
+		}
+		if err := json.Unmarshal([]byte(diffJSON), &record.Diff); err != nil {
+			return nil, fmt.Errorf("record %s: %w", record.SequenceNumber, err)
+		}
+		if q.Match(&record) {
+			records = append(records, record)
+		}
+	}
+	return records, rows.Err()
+}
+
+// List returns the metadata of every recorded CTL, oldest first
+func (h *History) List(ctx context.Context) ([]authrootstl.HistoryItem, error) {
+	rows, err := h.db.QueryContext(ctx, "SELECT sequence_number, effective_date, observed_at FROM ctls ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []authrootstl.HistoryItem
+	for rows.Next() {
+		var sequenceNumber, effectiveDate, observedAt string
+		if err := rows.Scan(&sequenceNumber, &effectiveDate, &observedAt); err != nil {
+			return nil, err
+		}
+		item := authrootstl.HistoryItem{SequenceNumber: new(big.Int)}
+		if _, ok := item.SequenceNumber.SetString(sequenceNumber, 16); !ok {
+			return nil, fmt.Errorf("history contains invalid sequence number %q", sequenceNumber)
+		}
+		if item.EffectiveDate, err = parseDate(effectiveDate); err != nil {
+			return nil, err
+		}
+		if item.ObservedAt, err = parseDate(observedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// DiffRange returns the diff of each recorded CTL whose sequence number is in the
+// range [from, to] against the CTL recorded before it, oldest first.  The first
+// recorded CTL is diffed against an empty CTL.
+func (h *History) DiffRange(ctx context.Context, from, to *big.Int) ([]*authrootstl.CTLDiff, error) {
+	return history.DiffRange(ctx, h, from, to)
+}
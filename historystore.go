@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"math/big"
+	"time"
+)
+
+// HistoryStore is a durable record of observed CTLs, such as a directory, a
+// database, or an object store
+type HistoryStore interface {
+	// Put stores ctl, observed at the given time, unless a CTL with the same
+	// sequence number is already stored.  It returns true if ctl was stored.
+	// If ctl fails CTL.CheckRollback against the most recently stored CTL,
+	// Put returns a *RollbackError.
+	Put(ctx context.Context, ctl *CTL, observedAt time.Time) (bool, error)
+
+	// GetBySequence returns the stored CTL with the given sequence number,
+	// or nil if there is none
+	GetBySequence(ctx context.Context, sequenceNumber *big.Int) (*CTL, error)
+
+	// List returns every stored CTL's metadata, oldest first
+	List(ctx context.Context) ([]HistoryItem, error)
+
+	// DiffRange returns the Diff of each stored CTL whose sequence number is in
+	// the range [from, to] against the stored CTL before it, oldest first.  A
+	// nil bound leaves that end of the range open.
+	DiffRange(ctx context.Context, from, to *big.Int) ([]*CTLDiff, error)
+}
+
+// HistoryItem describes a CTL in a HistoryStore
+type HistoryItem struct {
+	SequenceNumber *big.Int
+	EffectiveDate  time.Time
+	ObservedAt     time.Time
+}
+
+// InRange reports whether the item's sequence number is in the range [from, to],
+// where a nil bound leaves that end of the range open
+func (item *HistoryItem) InRange(from, to *big.Int) bool {
+	return (from == nil || item.SequenceNumber.Cmp(from) >= 0) && (to == nil || item.SequenceNumber.Cmp(to) <= 0)
+}
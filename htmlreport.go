@@ -0,0 +1,257 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// HTMLReportOptions controls the contents of the report written by WriteHTMLReport
+type HTMLReportOptions struct {
+	Previous *CTL          // if non-nil, highlight roots and CT logs which differ from this CTL
+	At       time.Time     // time at which constraints are evaluated; if zero, the current time
+	Filters  []EntryFilter // only include roots matching all of these filters
+}
+
+type htmlReport struct {
+	SequenceNumber   string
+	EffectiveDate    string
+	NextUpdate       string
+	GeneratedAt      string
+	PreviousSequence string // empty if there is no previous CTL
+	Roots            []htmlReportRoot
+	RemovedRoots     []changelogRoot
+	CTLogs           []htmlReportLog
+	RemovedCTLogs    []string
+}
+
+type htmlReportRoot struct {
+	changelogRoot
+	SHA256 string
+	EKUs   string
+	Badges []htmlReportBadge
+	Status string // "added", "changed", or empty
+}
+
+type htmlReportBadge struct {
+	Class string
+	Text  string
+}
+
+type htmlReportLog struct {
+	LogID string
+	Key   string
+	Added bool
+}
+
+func newHTMLReportBadges(entry *Entry, at time.Time) []htmlReportBadge {
+	var badges []htmlReportBadge
+	if !entry.DisallowedDate.IsZero() {
+		badge := htmlReportBadge{Class: "disallowed", Text: "disallowed " + entry.DisallowedDate.Format(time.DateOnly)}
+		if at.Before(entry.DisallowedDate) {
+			badge.Class = "scheduled"
+			badge.Text = "disallowed from " + entry.DisallowedDate.Format(time.DateOnly)
+		}
+		if len(entry.DisallowedEKUs) > 0 {
+			badge.Text += " for " + htmlReportEKUs(entry.DisallowedEKUs)
+		}
+		badges = append(badges, badge)
+	}
+	if !entry.NotBeforeDate.IsZero() {
+		badge := htmlReportBadge{Class: "notbefore", Text: "NotBefore " + entry.NotBeforeDate.Format(time.DateOnly)}
+		if len(entry.NotBeforeEKUs) > 0 {
+			badge.Text += " for " + htmlReportEKUs(entry.NotBeforeEKUs)
+		}
+		badges = append(badges, badge)
+	}
+	return badges
+}
+
+func htmlReportEKUs(ekus []asn1.ObjectIdentifier) string {
+	if len(ekus) == 0 {
+		return "all usages"
+	}
+	names := make([]string, 0, len(ekus))
+	for _, eku := range ekus {
+		if name, ok := LookupOID(eku); ok {
+			names = append(names, name)
+		} else {
+			names = append(names, eku.String())
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func newHTMLReport(ctl *CTL, options HTMLReportOptions) *htmlReport {
+	at := options.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	report := &htmlReport{
+		SequenceNumber: fmt.Sprintf("%X", &ctl.SequenceNumber),
+		EffectiveDate:  ctl.EffectiveDate.Format(time.DateOnly),
+		GeneratedAt:    at.UTC().Format(time.RFC3339),
+	}
+	if !ctl.NextUpdate.IsZero() {
+		report.NextUpdate = ctl.NextUpdate.Format(time.DateOnly)
+	}
+
+	changes := make(map[string][]string)
+	added := make(map[string]bool)
+	addedLogs := make(map[string]bool)
+	if options.Previous != nil {
+		diff := Diff(options.Previous, ctl)
+		report.PreviousSequence = fmt.Sprintf("%X", diff.OldSequenceNumber)
+		for i := range diff.AddedRoots {
+			added[fmt.Sprintf("%X", diff.AddedRoots[i].SubjectIdentifier)] = true
+		}
+		for i := range diff.ChangedRoots {
+			changes[fmt.Sprintf("%X", diff.ChangedRoots[i].New.SubjectIdentifier)] = diff.ChangedRoots[i].Changes
+		}
+		for _, entry := range FilterEntries(diff.RemovedRoots, options.Filters...) {
+			report.RemovedRoots = append(report.RemovedRoots, newChangelogRoot(&entry, nil))
+		}
+		for _, logKey := range diff.AddedCTLogs {
+			addedLogs[logKey.String()] = true
+		}
+		for _, logKey := range diff.RemovedCTLogs {
+			report.RemovedCTLogs = append(report.RemovedCTLogs, logKey.String())
+		}
+	}
+
+	for _, entry := range FilterEntries(ctl.Entries, options.Filters...) {
+		root := htmlReportRoot{
+			changelogRoot: newChangelogRoot(&entry, nil),
+			EKUs:          htmlReportEKUs(entry.EKUs),
+			Badges:        newHTMLReportBadges(&entry, at),
+		}
+		if !entry.SHA256.IsZero() {
+			root.SHA256 = fmt.Sprintf("%X", entry.SHA256[:])
+		}
+		if added[root.SHA1] {
+			root.Status = "added"
+		} else if rootChanges, ok := changes[root.SHA1]; ok {
+			root.Status = "changed"
+			root.Changes = rootChanges
+		}
+		report.Roots = append(report.Roots, root)
+	}
+
+	for _, logKey := range ctl.CTLogs {
+		reportLog := htmlReportLog{LogID: logKey.String(), Key: "malformed", Added: addedLogs[logKey.String()]}
+		if publicKey, err := logKey.PublicKey(); err == nil {
+			reportLog.Key = publicKeyName(publicKey)
+		}
+		report.CTLogs = append(report.CTLogs, reportLog)
+	}
+	return report
+}
+
+var htmlReportTemplate = htmltemplate.Must(htmltemplate.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Microsoft trusted root list {{.SequenceNumber}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.5em; text-align: left; vertical-align: top; }
+th { background: #eee; cursor: pointer; user-select: none; }
+code { font-size: 0.85em; word-break: break-all; }
+tr.added { background: #e6ffe6; }
+tr.changed { background: #fff8d6; }
+.badge { display: inline-block; border-radius: 0.8em; padding: 0.1em 0.6em; margin: 0.1em; font-size: 0.85em; white-space: nowrap; }
+.disallowed { background: #f8d0d0; }
+.scheduled { background: #fde2c0; }
+.notbefore { background: #d6e4fb; }
+.new { background: #c8f0c8; }
+ul.changes { margin: 0.2em 0; padding-left: 1.2em; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>Microsoft trusted root list</h1>
+<p>Sequence number <code>{{.SequenceNumber}}</code>, effective {{.EffectiveDate}}{{if .NextUpdate}}, next update {{.NextUpdate}}{{end}}.
+Constraints evaluated at {{.GeneratedAt}}.{{if .PreviousSequence}}
+Changes are highlighted relative to sequence number <code>{{.PreviousSequence}}</code>.{{end}}</p>
+
+<h2>Roots ({{len .Roots}})</h2>
+<table class="sortable">
+<thead><tr><th>Name</th><th>SHA-1</th><th>SHA-256</th><th>Trusted for</th><th>Constraints</th>{{if .PreviousSequence}}<th>Change</th>{{end}}</tr></thead>
+<tbody>
+{{range .Roots}}<tr{{if .Status}} class="{{.Status}}"{{end}}><td>{{.Name}}</td><td><code>{{.SHA1}}</code></td><td><code>{{.SHA256}}</code></td><td>{{.EKUs}}</td><td>{{range .Badges}}<span class="badge {{.Class}}">{{.Text}}</span>{{end}}</td>{{if $.PreviousSequence}}<td>{{.Status}}{{if .Changes}}<ul class="changes">{{range .Changes}}<li>{{.}}</li>{{end}}</ul>{{end}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{if .RemovedRoots}}
+<h2>Removed roots ({{len .RemovedRoots}})</h2>
+<ul>
+{{range .RemovedRoots}}<li>{{.Name}} (<code>{{.SHA1}}</code>)</li>
+{{end}}</ul>
+{{end}}
+<h2>CT logs ({{len .CTLogs}})</h2>
+<table class="sortable">
+<thead><tr><th>Log ID</th><th>Key</th></tr></thead>
+<tbody>
+{{range .CTLogs}}<tr{{if .Added}} class="added"{{end}}><td><code>{{.LogID}}</code>{{if .Added}} <span class="badge new">new</span>{{end}}</td><td>{{.Key}}</td></tr>
+{{end}}</tbody>
+</table>
+{{if .RemovedCTLogs}}
+<h2>Removed CT logs ({{len .RemovedCTLogs}})</h2>
+<ul>
+{{range .RemovedCTLogs}}<li><code>{{.}}</code></li>
+{{end}}</ul>
+{{end}}
+<script>
+for (const table of document.querySelectorAll("table.sortable")) {
+	table.querySelectorAll("th").forEach((th, column) => {
+		th.addEventListener("click", () => {
+			const tbody = table.tBodies[0];
+			const ascending = th.dataset.order !== "asc";
+			table.querySelectorAll("th").forEach(other => delete other.dataset.order);
+			th.dataset.order = ascending ? "asc" : "desc";
+			const rows = Array.from(tbody.rows);
+			rows.sort((a, b) => a.cells[column].textContent.localeCompare(b.cells[column].textContent) * (ascending ? 1 : -1));
+			rows.forEach(row => tbody.appendChild(row));
+		});
+	});
+}
+</script>
+</body>
+</html>
+`))
+
+// WriteHTMLReport writes a self-contained HTML page describing the roots and CT logs
+// in ctl, with a sortable table of roots, badges for disallowed and NotBefore
+// constraints, and, if options.Previous is set, highlights of what has changed
+func WriteHTMLReport(w io.Writer, ctl *CTL, options HTMLReportOptions) error {
+	return htmlReportTemplate.Execute(w, newHTMLReport(ctl, options))
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"sync"
+)
+
+// entryIndex maps fingerprints to entries for FindBySHA1 and FindBySHA256
+type entryIndex struct {
+	once     sync.Once
+	bySHA1   map[SHA1Fingerprint]*Entry
+	bySHA256 map[SHA256Fingerprint]*Entry
+}
+
+func (ctl *CTL) buildIndex() {
+	ctl.index.bySHA1 = make(map[SHA1Fingerprint]*Entry, len(ctl.Entries))
+	ctl.index.bySHA256 = make(map[SHA256Fingerprint]*Entry, len(ctl.Entries))
+	for i := range ctl.Entries {
+		entry := &ctl.Entries[i]
+		if !entry.SHA1.IsZero() {
+			ctl.index.bySHA1[entry.SHA1] = entry
+		}
+		if !entry.SHA256.IsZero() {
+			ctl.index.bySHA256[entry.SHA256] = entry
+		}
+	}
+}
+
+// FindBySHA1 returns the entry for the certificate with the given SHA-1 hash, or nil
+// if there is none.  The index used for lookups is built on first use, so Entries
+// must not be modified after calling FindBySHA1 or FindBySHA256.
+func (ctl *CTL) FindBySHA1(fingerprint SHA1Fingerprint) *Entry {
+	ctl.index.once.Do(ctl.buildIndex)
+	return ctl.index.bySHA1[fingerprint]
+}
+
+// FindBySHA256 returns the entry for the certificate with the given SHA-256 hash, or nil
+// if there is none.  Not every entry contains a SHA-256 hash.
+func (ctl *CTL) FindBySHA256(fingerprint SHA256Fingerprint) *Entry {
+	ctl.index.once.Do(ctl.buildIndex)
+	return ctl.index.bySHA256[fingerprint]
+}
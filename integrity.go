@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIntegrity means that a downloaded trust list was rejected because neither
+// HTTPS nor signature verification established its integrity as required by the
+// Client's IntegrityPolicy
+var ErrIntegrity = errors.New("integrity not established")
+
+// IntegrityPolicy specifies what a Client requires to establish that a downloaded
+// trust list has not been tampered with before returning it
+type IntegrityPolicy int
+
+const (
+	IntegrityNotRequired     IntegrityPolicy = iota // no requirement beyond verifying the signature unless InsecureSkipVerify is set
+	RequireHTTPSOrSignature                         // downloaded over HTTPS, or its signature was verified, or both
+	RequireHTTPS                                    // downloaded over HTTPS
+	RequireSignature                                // its signature was verified
+	RequireHTTPSAndSignature                        // downloaded over HTTPS and its signature was verified
+)
+
+func (policy IntegrityPolicy) String() string {
+	switch policy {
+	case IntegrityNotRequired:
+		return "none"
+	case RequireHTTPSOrSignature:
+		return "https-or-signature"
+	case RequireHTTPS:
+		return "https"
+	case RequireSignature:
+		return "signature"
+	case RequireHTTPSAndSignature:
+		return "https-and-signature"
+	default:
+		return fmt.Sprintf("IntegrityPolicy(%d)", int(policy))
+	}
+}
+
+// ParseIntegrityPolicy parses the string returned by IntegrityPolicy.String
+func ParseIntegrityPolicy(s string) (IntegrityPolicy, error) {
+	for policy := IntegrityNotRequired; policy <= RequireHTTPSAndSignature; policy++ {
+		if policy.String() == s {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown integrity policy %q", s)
+}
+
+// Integrity records how the integrity of a trust list downloaded by a Client
+// was established, for auditing
+type Integrity struct {
+	URL               string          // URL from which the trust list was downloaded, after any redirects
+	HTTPS             bool            // whether it was requested, and every redirect received, over HTTPS with a verified server certificate
+	SignatureVerified bool            // whether its signature was verified
+	Policy            IntegrityPolicy // the policy which it satisfied
+}
+
+// Check returns an error wrapping ErrIntegrity unless integrity satisfies policy
+func (integrity *Integrity) Check(policy IntegrityPolicy) error {
+	var ok bool
+	switch policy {
+	case IntegrityNotRequired:
+		ok = true
+	case RequireHTTPSOrSignature:
+		ok = integrity.HTTPS || integrity.SignatureVerified
+	case RequireHTTPS:
+		ok = integrity.HTTPS
+	case RequireSignature:
+		ok = integrity.SignatureVerified
+	case RequireHTTPSAndSignature:
+		ok = integrity.HTTPS && integrity.SignatureVerified
+	}
+	if !ok {
+		return fmt.Errorf("%s: %w: policy %s requires %s, but it was downloaded over %s %s signature verification",
+			integrity.URL, ErrIntegrity, policy, policy.requirement(), integrity.transport(), integrity.verification())
+	}
+	return nil
+}
+
+func (policy IntegrityPolicy) requiresHTTPS() bool {
+	return policy == RequireHTTPS || policy == RequireHTTPSAndSignature
+}
+
+func (policy IntegrityPolicy) requirement() string {
+	switch policy {
+	case RequireHTTPSOrSignature:
+		return "HTTPS or a verified signature"
+	case RequireHTTPS:
+		return "HTTPS"
+	case RequireSignature:
+		return "a verified signature"
+	case RequireHTTPSAndSignature:
+		return "HTTPS and a verified signature"
+	default:
+		return "nothing"
+	}
+}
+
+func (integrity *Integrity) transport() string {
+	if integrity.HTTPS {
+		return "HTTPS"
+	}
+	return "plain HTTP"
+}
+
+func (integrity *Integrity) verification() string {
+	if integrity.SignatureVerified {
+		return "with"
+	}
+	return "without"
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftcdn implements the msftcdn command.
+package msftcdn
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+type jsonResult struct {
+	BaseURL        string `json:"base_url"`
+	Error          string `json:"error,omitempty"`
+	FileSHA256     string `json:"file_sha256,omitempty"`
+	SequenceNumber string `json:"sequence_number,omitempty"`
+	Digest         string `json:"digest,omitempty"`
+}
+
+type jsonDivergence struct {
+	BaseURL string `json:"base_url"`
+	Round   int    `json:"round"`
+	Problem string `json:"problem"`
+}
+
+type jsonOutput struct {
+	Consistent  bool             `json:"consistent"`
+	Rounds      [][]jsonResult   `json:"rounds"`
+	Divergences []jsonDivergence `json:"divergences"`
+}
+
+// Main runs msftcdn: check that Microsoft's CDN locales and mirrors serve the same trust list
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	locales := flag.String("locales", "", "Compare the trust lists served for these `LOCALES` (comma-separated, e.g. en,de,ja), by replacing the locale in -url")
+	var endpoints []string
+	flag.Func("endpoint", "Compare the trust list served from the base `URL` of a mirror or CDN edge (may be repeated)", func(value string) error {
+		endpoints = append(endpoints, value)
+		return nil
+	})
+	cabName := flag.String("cab", "authrootstl.cab", "Name of the CAB file to compare (e.g. authrootstl.cab, disallowedcertstl.cab)")
+	repeat := flag.Int("repeat", 1, "Number of rounds of downloads, to detect endpoints which change inconsistently over time")
+	interval := flag.Duration("interval", time.Minute, "Time between rounds")
+	jsonFlag := flag.Bool("json", false, "Output the results as JSON")
+	clientFromFlags := cmdutil.ClientFlags()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -locales LOCALES | -endpoint URL... [flags]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Exit status is 0 if every endpoint serves the same trust list, 1 on error, 2 on invalid usage, and 3 if they diverge or a download fails.\n")
+		flag.PrintDefaults()
+	}
+	cmdutil.ParseFlags()
+	if *locales == "" && len(endpoints) == 0 {
+		flag.Usage()
+		os.Exit(cmdutil.ExitUsage)
+	}
+
+	client := clientFromFlags()
+	baseURLs := endpoints
+	if *locales != "" {
+		for _, locale := range strings.Split(*locales, ",") {
+			baseURLs = append(baseURLs, authrootstl.LocaleBaseURL(client.BaseURL, locale))
+		}
+	}
+
+	var rounds [][]authrootstl.EndpointResult
+	for round := range max(*repeat, 1) {
+		if round > 0 {
+			time.Sleep(*interval)
+		}
+		results, _ := client.CompareEndpoints(context.Background(), *cabName, baseURLs)
+		rounds = append(rounds, results)
+		if !*jsonFlag {
+			if *repeat > 1 {
+				fmt.Printf("Round %d:\n", round)
+			}
+			for _, result := range results {
+				printResult(&result)
+			}
+		}
+	}
+	divergences := authrootstl.CheckEndpointRounds(rounds)
+
+	if *jsonFlag {
+		output := jsonOutput{Consistent: len(divergences) == 0, Divergences: []jsonDivergence{}}
+		for _, results := range rounds {
+			var jsonResults []jsonResult
+			for _, result := range results {
+				jsonResults = append(jsonResults, newJSONResult(&result))
+			}
+			output.Rounds = append(output.Rounds, jsonResults)
+		}
+		for _, divergence := range divergences {
+			output.Divergences = append(output.Divergences, jsonDivergence(divergence))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(output); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, divergence := range divergences {
+			fmt.Printf("DIVERGENCE: round %d: %s: %s\n", divergence.Round, divergence.BaseURL, divergence.Problem)
+		}
+		if len(divergences) == 0 {
+			fmt.Println("Consistent")
+		}
+	}
+	if len(divergences) > 0 {
+		os.Exit(cmdutil.ExitCheckFailed)
+	}
+}
+
+func newJSONResult(result *authrootstl.EndpointResult) jsonResult {
+	if result.Err != nil {
+		return jsonResult{BaseURL: result.BaseURL, Error: result.Err.Error()}
+	}
+	return jsonResult{
+		BaseURL:        result.BaseURL,
+		FileSHA256:     result.FileSHA256.Hex(),
+		SequenceNumber: fmt.Sprintf("%X", result.SequenceNumber),
+		Digest:         hex.EncodeToString(result.Digest[:]),
+	}
+}
+
+func printResult(result *authrootstl.EndpointResult) {
+	if result.Err != nil {
+		fmt.Printf("%s: FAILED: %s\n", result.BaseURL, result.Err)
+		return
+	}
+	fmt.Printf("%s: sequence number %X, digest %x, file SHA-256 %s\n", result.BaseURL, result.SequenceNumber, result.Digest[:8], result.FileSHA256.Hex())
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftcerts implements the msftcerts command.
+package msftcerts
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+// Main runs msftcerts: download the root certificates trusted by Microsoft
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	dir := flag.String("dir", "", "Write certificates to `DIR` (required)")
+	format := flag.String("format", "der", "Certificate format (der, pem)")
+	parallel := flag.Int("parallel", 4, "Number of certificates to download concurrently")
+	input := cmdutil.InputFlag()
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	if *dir == "" {
+		log.Fatal("-dir is required")
+	}
+	if *format != "der" && *format != "pem" {
+		log.Fatalf("unknown format %q", *format)
+	}
+	client := clientFromFlags()
+
+	ctl, err := cmdutil.LoadCTL(context.Background(), client, *input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(*dir, 0777); err != nil {
+		log.Fatal(err)
+	}
+
+	counts := cmdutil.DownloadCertificates(context.Background(), client, ctl.Entries, *dir, *format, *parallel)
+	fmt.Printf("%d downloaded, %d already present, %d failed\n", counts.Downloaded, counts.Present, counts.Failed)
+	if counts.Failed > 0 {
+		os.Exit(1)
+	}
+}
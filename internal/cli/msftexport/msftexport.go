@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftexport implements the msftexport command.
+package msftexport
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+	"software.sslmate.com/src/authrootstl/internal/ctljson"
+)
+
+// formats which require the certificates, rather than just the CTL entries
+var certificateFormats = map[string]bool{
+	"pem":         true,
+	"ca-bundle":   true,
+	"certdata":    true,
+	"sst":         true,
+	"p12":         true,
+	"openssl-dir": true,
+	"configmap":   true,
+	"secret":      true,
+}
+
+// Main runs msftexport: export the root certificates trusted by Microsoft in various formats
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	format := flag.String("format", "pem", "Output format (pem, ca-bundle, json, csv, certdata, sst, p12, openssl-dir, configmap, secret)")
+	output := flag.String("output", "", "Write output to `PATH` (default: stdout; required for openssl-dir, where it is a directory)")
+	certDir := flag.String("cert-dir", "", "Cache downloaded certificates in `DIR` (default: a temporary directory)")
+	password := flag.String("password", "", "Password for the p12 MAC (default: no MAC)")
+	var kubernetes authrootstl.KubernetesOptions
+	flag.StringVar(&kubernetes.Name, "k8s-name", "microsoft-roots", "Name of the ConfigMap or Secret")
+	flag.StringVar(&kubernetes.Namespace, "k8s-namespace", "", "Namespace of the ConfigMap or Secret (default: none)")
+	flag.StringVar(&kubernetes.Key, "k8s-key", "ca-certificates.crt", "Key under which the ConfigMap or Secret stores the PEM bundle")
+	parallel := flag.Int("parallel", 4, "Number of certificates to download concurrently")
+	input := cmdutil.InputFlag()
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	if _, ok := certificateFormats[*format]; !ok && *format != "json" && *format != "csv" {
+		log.Fatalf("unknown format %q", *format)
+	}
+	if *format == "openssl-dir" && *output == "" {
+		log.Fatal("-output is required for the openssl-dir format")
+	}
+	client := clientFromFlags()
+
+	ctl, err := cmdutil.LoadCTL(context.Background(), client, *input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctl = ctl.Sorted()
+
+	switch *format {
+	case "json":
+		err = writeOutput(*output, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "\t")
+			return encoder.Encode(ctljson.NewEntries(ctl.Entries))
+		})
+	case "csv":
+		err = writeOutput(*output, func(w io.Writer) error {
+			return cmdutil.WriteEntriesCSV(w, ctl.Entries)
+		})
+	default:
+		var roots []authrootstl.ExportRoot
+		roots, err = cmdutil.LoadRoots(client, ctl.Entries, *certDir, *parallel)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch *format {
+		case "pem":
+			err = writeOutput(*output, func(w io.Writer) error { return authrootstl.ExportPEM(w, roots) })
+		case "ca-bundle":
+			err = writeOutput(*output, func(w io.Writer) error { return authrootstl.ExportCABundle(w, roots) })
+		case "certdata":
+			err = writeOutput(*output, func(w io.Writer) error { return authrootstl.ExportCertdata(w, roots, time.Now()) })
+		case "sst":
+			err = writeOutput(*output, func(w io.Writer) error { return authrootstl.ExportSST(w, roots) })
+		case "p12":
+			err = writeOutput(*output, func(w io.Writer) error {
+				p12, err := authrootstl.ExportPKCS12(roots, *password)
+				if err != nil {
+					return err
+				}
+				_, err = w.Write(p12)
+				return err
+			})
+		case "openssl-dir":
+			err = authrootstl.ExportOpenSSLDir(*output, roots)
+		case "configmap", "secret":
+			kubernetes.Kind = "ConfigMap"
+			if *format == "secret" {
+				kubernetes.Kind = "Secret"
+			}
+			err = writeOutput(*output, func(w io.Writer) error { return authrootstl.ExportKubernetes(w, roots, kubernetes) })
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeOutput(filename string, write func(io.Writer) error) error {
+	if filename == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
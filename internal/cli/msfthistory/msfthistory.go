@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msfthistory implements the msfthistory command.
+package msfthistory
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/history"
+	"software.sslmate.com/src/authrootstl/history/sqlite"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+// Main runs msfthistory: record the history of Microsoft's trust list and query past changes
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	dir := flag.String("dir", "", "Keep the history in `DIR`")
+	db := flag.String("db", "", "Keep the history in the SQLite database `FILE`, which can also be queried with SQL (tables ctls and root_changes)")
+	record := flag.Bool("record", false, "Record the current trust list instead of querying the history")
+	importDir := flag.String("import", "", "Record every STL and CAB file in the archive `DIR`, in order of effective date, instead of querying the history")
+	get := flag.String("get", "", "Write the recorded STL file with sequence number `SEQ` (in hex) to standard output instead of querying the history")
+	root := flag.String("root", "", "Only show changes to the root with the given SHA-1 or SHA-256 `HASH`")
+	since := flag.String("since", "", "Only show trust lists effective on or after `DATE` (YYYY-MM-DD)")
+	until := flag.String("until", "", "Only show trust lists effective before `DATE` (YYYY-MM-DD)")
+	jsonOutput := flag.Bool("json", false, "Output the matching records as JSON")
+	input := cmdutil.InputFlag()
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	var h history.Store
+	var err error
+	switch {
+	case *dir != "" && *db != "":
+		log.Fatal("-dir and -db are mutually exclusive")
+	case *dir != "":
+		h, err = history.Open(*dir)
+	case *db != "":
+		h, err = sqlite.Open(*db)
+	default:
+		log.Fatal("-dir or -db is required")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer h.Close()
+
+	if *record {
+		ctl, err := cmdutil.LoadCTL(context.Background(), clientFromFlags(), *input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recorded, err := h.Put(context.Background(), ctl, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if recorded {
+			fmt.Printf("Recorded sequence number %X\n", &ctl.SequenceNumber)
+		} else {
+			fmt.Printf("Sequence number %X already recorded\n", &ctl.SequenceNumber)
+		}
+		return
+	}
+
+	if *importDir != "" {
+		archived, err := authrootstl.ParseArchiveDir(*importDir, 0)
+		if err != nil {
+			log.Print(err)
+		}
+		for _, file := range archived {
+			recorded, err := h.Put(context.Background(), file.CTL, file.CTL.EffectiveDate)
+			if err != nil {
+				log.Fatalf("%s: %s", file.Name, err)
+			}
+			if recorded {
+				fmt.Printf("Recorded sequence number %X from %s\n", &file.CTL.SequenceNumber, file.Name)
+			}
+		}
+		return
+	}
+
+	if *get != "" {
+		sequenceNumber, ok := new(big.Int).SetString(*get, 16)
+		if !ok {
+			log.Fatalf("-get: invalid sequence number %q", *get)
+		}
+		ctl, err := h.GetBySequence(context.Background(), sequenceNumber)
+		if err != nil {
+			log.Fatal(err)
+		} else if ctl == nil {
+			log.Fatalf("sequence number %X is not saved in the history", sequenceNumber)
+		}
+		if _, err := os.Stdout.Write(ctl.Raw); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	sinceTime, err := parseDate(*since)
+	if err != nil {
+		log.Fatalf("-since: %s", err)
+	}
+	untilTime, err := parseDate(*until)
+	if err != nil {
+		log.Fatalf("-until: %s", err)
+	}
+	matching, err := h.Query(history.Query{Since: sinceTime, Until: untilTime, Root: *root})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(matching); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for i, record := range matching {
+		if i > 0 {
+			fmt.Println()
+		}
+		printRecord(&record)
+	}
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func printRecord(record *history.Record) {
+	fmt.Printf("Sequence number %s, effective %s (observed %s): %d roots\n",
+		record.SequenceNumber, record.EffectiveDate.Format(time.RFC3339), record.ObservedAt.Format(time.RFC3339), record.Roots)
+	for _, root := range record.Diff.AddedRoots {
+		fmt.Printf("  + %s %s\n", root.SHA1, root.FriendlyName)
+	}
+	for _, root := range record.Diff.RemovedRoots {
+		fmt.Printf("  - %s %s\n", root.SHA1, root.FriendlyName)
+	}
+	for _, root := range record.Diff.ChangedRoots {
+		fmt.Printf("  ~ %s %s: %s\n", root.SHA1, root.FriendlyName, strings.Join(root.Changes, "; "))
+	}
+	for _, ctLog := range record.Diff.AddedCTLogs {
+		fmt.Printf("  + CT log %x\n", ctLog.LogID)
+	}
+	for _, ctLog := range record.Diff.RemovedCTLogs {
+		fmt.Printf("  - CT log %x\n", ctLog.LogID)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftlint implements the msftlint command.
+package msftlint
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+type jsonResult struct {
+	SHA1         string                    `json:"sha1"`
+	FriendlyName string                    `json:"friendly_name"`
+	Findings     []authrootstl.LintFinding `json:"findings"`
+}
+
+// Main runs msftlint: lint the root certificates trusted by Microsoft
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	lintNames := flag.String("lints", "", "Run only the named lints (comma-separated; default: all)")
+	listLints := flag.Bool("list-lints", false, "List the available lints and exit")
+	ctlLints := flag.Bool("ctl", false, "Lint the trust list itself for publication mistakes, instead of the root certificates")
+	jsonOutput := flag.Bool("json", false, "Output the findings as JSON")
+	certDir := flag.String("cert-dir", "", "Cache downloaded certificates in `DIR` (default: a temporary directory)")
+	parallel := flag.Int("parallel", 4, "Number of certificates to download concurrently")
+	input := cmdutil.InputFlag()
+	clientFromFlags := cmdutil.ClientFlags()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "The lints are built in (see -list-lints); zlint is not run.\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Exit status is 0 if there are no findings, 1 on error, 2 on invalid usage, and 3 if there are findings.\n")
+		flag.PrintDefaults()
+	}
+	cmdutil.ParseFlags()
+
+	if *ctlLints {
+		lintCTL(*input, clientFromFlags, *lintNames, *listLints, *jsonOutput)
+		return
+	}
+	if *listLints {
+		for _, lint := range authrootstl.DefaultLints {
+			fmt.Printf("%-16s %s\n", lint.Name, lint.Description)
+		}
+		return
+	}
+	lints := authrootstl.DefaultLints
+	if *lintNames != "" {
+		lints = nil
+		for _, name := range strings.Split(*lintNames, ",") {
+			lint, ok := authrootstl.LookupLint(name)
+			if !ok {
+				log.Fatalf("unknown lint %q (see -list-lints)", name)
+			}
+			lints = append(lints, lint)
+		}
+	}
+
+	client := clientFromFlags()
+	ctl, err := cmdutil.LoadCTL(context.Background(), client, *input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctl = ctl.Sorted()
+	roots, err := cmdutil.LoadRoots(client, ctl.Entries, *certDir, *parallel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results := authrootstl.RunLints(roots, lints, time.Now())
+	if *jsonOutput {
+		jsonResults := make([]jsonResult, 0, len(results))
+		for _, result := range results {
+			jsonResults = append(jsonResults, jsonResult{
+				SHA1:         result.Entry.SHA1.Hex(),
+				FriendlyName: result.Entry.FriendlyName,
+				Findings:     result.Findings,
+			})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(jsonResults); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, result := range results {
+			for _, finding := range result.Findings {
+				fmt.Printf("%s: %s: %s\n", result.Entry, finding.Lint, finding.Detail)
+			}
+		}
+		fmt.Printf("%d of %d roots have findings\n", len(results), len(roots))
+	}
+	if len(results) > 0 {
+		os.Exit(cmdutil.ExitCheckFailed)
+	}
+}
+
+func lintCTL(input string, clientFromFlags func() *authrootstl.Client, lintNames string, listLints bool, jsonOutput bool) {
+	if listLints {
+		for _, lint := range authrootstl.DefaultCTLLints {
+			fmt.Printf("%-28s %s\n", lint.Name, lint.Description)
+		}
+		return
+	}
+	lints := authrootstl.DefaultCTLLints
+	if lintNames != "" {
+		lints = nil
+		for _, name := range strings.Split(lintNames, ",") {
+			lint, ok := authrootstl.LookupCTLLint(name)
+			if !ok {
+				log.Fatalf("unknown CTL lint %q (see -ctl -list-lints)", name)
+			}
+			lints = append(lints, lint)
+		}
+	}
+
+	ctl, err := cmdutil.LoadCTL(context.Background(), clientFromFlags(), input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	findings := authrootstl.RunCTLLints(ctl, lints, time.Now())
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(append([]authrootstl.LintFinding{}, findings...)); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Printf("%s: %s\n", finding.Lint, finding.Detail)
+		}
+		fmt.Printf("%d findings in sequence number %X\n", len(findings), &ctl.SequenceNumber)
+	}
+	if len(findings) > 0 {
+		os.Exit(cmdutil.ExitCheckFailed)
+	}
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftlogs implements the msftlogs command.
+package msftlogs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+var logLists = map[string]struct {
+	url   string
+	parse func([]byte) ([]authrootstl.KnownLog, error)
+}{
+	"chrome": {authrootstl.ChromeLogListURL, authrootstl.ParseChromeLogList},
+	"apple":  {authrootstl.AppleLogListURL, authrootstl.ParseAppleLogList},
+}
+
+var client *authrootstl.Client
+
+// Main runs msftlogs: list the CT logs recognized by Microsoft
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	compare := flag.String("compare", "", "Compare to the named log lists (comma-separated: chrome, apple) instead of listing logs")
+	jsonOutput := flag.Bool("json", false, "Output log IDs, keys, key algorithms, and the extension version as JSON")
+	pemOutput := flag.Bool("pem", false, "Output each log's public key as a PEM PUBLIC KEY block")
+	pemDir := flag.String("pem-dir", "", "Write each log's public key to a PEM file named after its hex log ID in `DIR`")
+	format := flag.String("format", "log-id", "Output format for each log (log-id, spki-sha256, der-hex, base64-key)")
+	names := flag.Bool("names", false, "Print each log's description and operator from the public log lists")
+	input := cmdutil.InputFlag()
+	templateText := flag.String("template", "", "Render the logs using the given Go text/template `TEMPLATE` (fields: .Version, .Logs[].LogID, .LogIDHex, .Key, .KeyPEM, .KeyAlgorithm)")
+	onlyNotIn := flag.String("only-not-in", "", "Only output logs which are absent from all of the named log lists (comma-separated: chrome, apple)")
+	stateFile := flag.String("state", "", "Report changes since the previous run, as recorded in state `FILE`, and exit with status 3 if there were any")
+	clientFromFlags := cmdutil.ClientFlags()
+	probe := flag.Bool("probe", false, "Fetch each log's STH or checkpoint, using URLs from the public log lists, and report whether it is reachable")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Exit status is 0 on success, 1 on error, 2 on invalid usage, and 3 if -state detected changes.\n")
+		flag.PrintDefaults()
+	}
+	cmdutil.ParseFlags()
+
+	formatKey, ok := formats[*format]
+	if !ok {
+		log.Fatalf("unknown format %q", *format)
+	}
+	client = clientFromFlags()
+
+	ctl, err := cmdutil.LoadCTL(context.Background(), client, *input)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *onlyNotIn != "" {
+		lists, err := fetchLogLists(context.Background(), strings.Split(*onlyNotIn, ","))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctl.CTLogs = logsNotIn(ctl, lists)
+	}
+
+	if *stateFile != "" {
+		changed, err := updateState(*stateFile, ctl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if changed {
+			os.Exit(cmdutil.ExitCheckFailed)
+		}
+		return
+	}
+
+	if *compare != "" {
+		listNames := strings.Split(*compare, ",")
+		lists, err := fetchLogLists(context.Background(), listNames)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(listNames) == 1 {
+			printComparison(listNames[0], authrootstl.CompareCTLogs(ctl, lists[listNames[0]]))
+		} else {
+			printMemberships(listNames, authrootstl.CompareCTLogLists(ctl, lists))
+		}
+		return
+	}
+
+	if *templateText != "" {
+		if err := executeTemplate(*templateText, ctl); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *jsonOutput {
+		if err := printJSON(ctl); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *pemDir != "" {
+		if err := writePEMFiles(*pemDir, ctl); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *pemOutput {
+		for _, logKey := range ctl.CTLogs {
+			if err := pem.Encode(os.Stdout, &pem.Block{Type: "PUBLIC KEY", Bytes: logKey}); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+
+	if *names || *probe {
+		listNames := slices.Sorted(maps.Keys(logLists))
+		lists, err := fetchLogLists(context.Background(), listNames)
+		if err != nil {
+			log.Fatal(err)
+		}
+		memberships := authrootstl.CompareCTLogLists(ctl, lists)
+		if *probe {
+			printProbes(context.Background(), listNames, memberships, formatKey)
+		} else {
+			printNames(listNames, memberships, formatKey)
+		}
+		return
+	}
+
+	for _, logKey := range ctl.CTLogs {
+		fmt.Println(formatKey(logKey))
+	}
+}
+
+func printNames(listNames []string, memberships []authrootstl.CTLogMembership, formatKey func([]byte) string) {
+	for _, membership := range memberships {
+		if !membership.Microsoft {
+			continue
+		}
+		description := "[not in any public log list]"
+		for _, name := range listNames {
+			if knownLog, listed := membership.Lists[name]; listed {
+				description = fmt.Sprintf("%s (%s)", knownLog.Description, knownLog.Operator)
+				break
+			}
+		}
+		fmt.Printf("%s\t%s\n", formatKey(membership.Key), description)
+	}
+}
+
+var formats = map[string]func([]byte) string{
+	"log-id": logID,
+	"spki-sha256": func(logKey []byte) string {
+		keyID := sha256.Sum256(logKey)
+		return hex.EncodeToString(keyID[:])
+	},
+	"der-hex":    hex.EncodeToString,
+	"base64-key": base64.StdEncoding.EncodeToString,
+}
+
+type jsonLog struct {
+	LogID        []byte `json:"log_id"`
+	Key          []byte `json:"key"`
+	KeyAlgorithm string `json:"key_algorithm"`
+}
+
+type jsonLogList struct {
+	Version []int32   `json:"version"`
+	Logs    []jsonLog `json:"logs"`
+}
+
+func printJSON(ctl *authrootstl.CTL) error {
+	output := jsonLogList{
+		Version: ctl.CTLogsVersion,
+		Logs:    []jsonLog{},
+	}
+	for _, logKey := range ctl.CTLogs {
+		keyID := sha256.Sum256(logKey)
+		output.Logs = append(output.Logs, jsonLog{
+			LogID:        keyID[:],
+			Key:          logKey,
+			KeyAlgorithm: keyAlgorithm(logKey),
+		})
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(output)
+}
+
+func writePEMFiles(dir string, ctl *authrootstl.CTL) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	for _, logKey := range ctl.CTLogs {
+		keyID := sha256.Sum256(logKey)
+		pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: logKey})
+		if err := os.WriteFile(filepath.Join(dir, hex.EncodeToString(keyID[:])+".pem"), pemBytes, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func keyAlgorithm(spki []byte) string {
+	pubkey, err := x509.ParsePKIXPublicKey(spki)
+	if err != nil {
+		return "unknown"
+	}
+	switch pubkey := pubkey.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + pubkey.Curve.Params().Name
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", pubkey.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return "unknown"
+	}
+}
+
+// logsNotIn returns the SPKIs of the logs recognized by Microsoft which are absent from all of the lists
+func logsNotIn(ctl *authrootstl.CTL, lists map[string][]authrootstl.KnownLog) []authrootstl.CTLogKey {
+	var logKeys []authrootstl.CTLogKey
+	for _, membership := range authrootstl.CompareCTLogLists(ctl, lists) {
+		if membership.Microsoft && len(membership.Lists) == 0 {
+			logKeys = append(logKeys, membership.Key)
+		}
+	}
+	return logKeys
+}
+
+func printComparison(listName string, comparison *authrootstl.CTLogsComparison) {
+	fmt.Printf("Recognized by Microsoft but not %s:\n", listName)
+	for _, logKey := range comparison.OnlyMicrosoft {
+		fmt.Printf("\t%s\n", logKey)
+	}
+	fmt.Printf("Recognized by %s but not Microsoft:\n", listName)
+	for _, knownLog := range comparison.OnlyList {
+		fmt.Printf("\t%s\t%s (%s)\n", base64.StdEncoding.EncodeToString(knownLog.LogID[:]), knownLog.Description, knownLog.State)
+	}
+}
+
+func printMemberships(listNames []string, memberships []authrootstl.CTLogMembership) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "LOG ID\tMICROSOFT\t%s\tDESCRIPTION\n", strings.ToUpper(strings.Join(listNames, "\t")))
+	for _, membership := range memberships {
+		var description string
+		fmt.Fprintf(w, "%s\t%s", base64.StdEncoding.EncodeToString(membership.LogID[:]), yesNo(membership.Microsoft))
+		for _, name := range listNames {
+			knownLog, listed := membership.Lists[name]
+			if listed && description == "" {
+				description = knownLog.Description
+			}
+			fmt.Fprintf(w, "\t%s", yesNo(listed))
+		}
+		fmt.Fprintf(w, "\t%s\n", description)
+	}
+	w.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func fetchLogLists(ctx context.Context, names []string) (map[string][]authrootstl.KnownLog, error) {
+	lists := make(map[string][]authrootstl.KnownLog)
+	for _, name := range names {
+		list, err := fetchLogList(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		lists[name] = list
+	}
+	return lists, nil
+}
+
+func fetchLogList(ctx context.Context, name string) ([]authrootstl.KnownLog, error) {
+	logList, ok := logLists[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown log list %q", name)
+	}
+	bodyBytes, err := client.Fetch(ctx, logList.url)
+	if err != nil {
+		return nil, err
+	}
+	return logList.parse(bodyBytes)
+}
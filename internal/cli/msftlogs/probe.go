@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package msftlogs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+type probeResult struct {
+	TreeSize  uint64
+	Timestamp time.Time
+}
+
+func printProbes(ctx context.Context, listNames []string, memberships []authrootstl.CTLogMembership, formatKey func([]byte) string) {
+	for _, membership := range memberships {
+		if !membership.Microsoft {
+			continue
+		}
+		var knownLog *authrootstl.KnownLog
+		for _, name := range listNames {
+			if listed, ok := membership.Lists[name]; ok {
+				knownLog = &listed
+				break
+			}
+		}
+		if knownLog == nil {
+			fmt.Printf("%s\t[no URL known]\n", formatKey(membership.Key))
+			continue
+		}
+		result, err := probeLog(ctx, knownLog)
+		if err != nil {
+			fmt.Printf("%s\t%s\tunreachable: %s\n", formatKey(membership.Key), knownLog.Description, err)
+			continue
+		}
+		fmt.Printf("%s\t%s\treachable\ttree_size=%d\ttimestamp=%s\n", formatKey(membership.Key), knownLog.Description, result.TreeSize, result.Timestamp.Format(time.RFC3339))
+	}
+}
+
+func probeLog(ctx context.Context, knownLog *authrootstl.KnownLog) (*probeResult, error) {
+	if knownLog.MonitoringURL != "" {
+		return probeTiledLog(ctx, knownLog.MonitoringURL, knownLog.Key)
+	}
+	return probeRFC6962Log(ctx, knownLog.URL)
+}
+
+func probeRFC6962Log(ctx context.Context, url string) (*probeResult, error) {
+	body, err := client.Fetch(ctx, strings.TrimSuffix(url, "/")+"/ct/v1/get-sth")
+	if err != nil {
+		return nil, err
+	}
+	var sth struct {
+		TreeSize  uint64 `json:"tree_size"`
+		Timestamp int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &sth); err != nil {
+		return nil, fmt.Errorf("error parsing STH: %w", err)
+	}
+	return &probeResult{TreeSize: sth.TreeSize, Timestamp: time.UnixMilli(sth.Timestamp).UTC()}, nil
+}
+
+// probeTiledLog fetches the checkpoint of a static-ct-api log whose DER-encoded
+// public key is key
+func probeTiledLog(ctx context.Context, monitoringURL string, key []byte) (*probeResult, error) {
+	body, err := client.Fetch(ctx, strings.TrimSuffix(monitoringURL, "/")+"/checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	return parseCheckpoint(body, key)
+}
+
+// parseCheckpoint parses a static-ct-api checkpoint.  The timestamp is taken from the
+// log's own signature, which per the static-ct-api spec begins with a 4 byte key hash,
+// SHA-256(origin || 0x0A || 0x05 || key), followed by an 8 byte timestamp.  Other
+// signatures, such as witness cosignatures, are ignored.
+func parseCheckpoint(body []byte, key []byte) (*probeResult, error) {
+	note, signatures, ok := strings.Cut(string(body), "\n\n")
+	lines := strings.Split(note, "\n")
+	if !ok || len(lines) < 3 {
+		return nil, fmt.Errorf("checkpoint is too short")
+	}
+	origin := lines[0]
+	treeSize, err := strconv.ParseUint(lines[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint contains malformed tree size")
+	}
+	keyHash := sha256.Sum256(append([]byte(origin+"\n\x05"), key...))
+	for _, line := range strings.Split(signatures, "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "\u2014 "))
+		if len(fields) != 2 || fields[0] != origin {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(signature) < 12 || !bytes.Equal(signature[:4], keyHash[:4]) {
+			continue
+		}
+		timestamp := int64(binary.BigEndian.Uint64(signature[4:12]))
+		return &probeResult{TreeSize: treeSize, Timestamp: time.UnixMilli(timestamp).UTC()}, nil
+	}
+	return nil, fmt.Errorf("checkpoint does not contain a signature by the log's key")
+}
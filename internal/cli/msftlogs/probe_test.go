@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package msftlogs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// testCheckpointSignature returns a signature line whose signature begins with
+// the 4 byte keyHash and the 8 byte timestamp
+func testCheckpointSignature(name string, keyHash []byte, timestamp time.Time) string {
+	signature := append([]byte(nil), keyHash[:4]...)
+	signature = binary.BigEndian.AppendUint64(signature, uint64(timestamp.UnixMilli()))
+	signature = append(signature, make([]byte, 64)...)
+	return "— " + name + " " + base64.StdEncoding.EncodeToString(signature) + "\n"
+}
+
+func TestParseCheckpointIgnoresWitnesses(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const origin = "log.example/2025h1"
+	logKeyHash := sha256.Sum256(append([]byte(origin+"\n\x05"), key...))
+	witnessKeyHash := sha256.Sum256([]byte("witness key"))
+	logTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	witnessTime := time.Date(2025, 3, 1, 12, 0, 5, 0, time.UTC)
+	note := origin + "\n1234\n" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + "\n\n"
+	witnessLine := testCheckpointSignature("witness.example/w1", witnessKeyHash[:], witnessTime)
+
+	result, err := parseCheckpoint([]byte(note+witnessLine+testCheckpointSignature(origin, logKeyHash[:], logTime)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TreeSize != 1234 || !result.Timestamp.Equal(logTime) {
+		t.Errorf("parseCheckpoint returned tree size %d and timestamp %s, want 1234 and %s", result.TreeSize, result.Timestamp, logTime)
+	}
+
+	// a signature under the log's name by another key is not the log's
+	otherKeyLine := testCheckpointSignature(origin, witnessKeyHash[:], witnessTime)
+	if result, err := parseCheckpoint([]byte(note+witnessLine+otherKeyLine), key); err == nil {
+		t.Errorf("checkpoint without the log's signature: parseCheckpoint returned timestamp %s", result.Timestamp)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package msftlogs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+type state struct {
+	Version []int32                `json:"version"`
+	Logs    []authrootstl.CTLogKey `json:"logs"`
+}
+
+// updateState compares ctl to the state saved in filename by a previous run, prints
+// any changes, and saves the new state.  It returns true if there were changes.
+// If there is no saved state, the state is saved and no changes are reported.
+func updateState(filename string, ctl *authrootstl.CTL) (bool, error) {
+	newState := state{Version: ctl.CTLogsVersion, Logs: ctl.CTLogs}
+	var oldState state
+	if stateBytes, err := os.ReadFile(filename); errors.Is(err, fs.ErrNotExist) {
+		return false, writeState(filename, &newState)
+	} else if err != nil {
+		return false, err
+	} else if err := json.Unmarshal(stateBytes, &oldState); err != nil {
+		return false, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	changed := false
+	if !slices.Equal(oldState.Version, newState.Version) {
+		fmt.Printf("version changed: %s -> %s\n", formatVersion(oldState.Version), formatVersion(newState.Version))
+		changed = true
+	}
+	for _, logKey := range newState.Logs {
+		if !slices.ContainsFunc(oldState.Logs, func(oldKey authrootstl.CTLogKey) bool { return slices.Equal(oldKey, logKey) }) {
+			fmt.Printf("added: %s\n", logID(logKey))
+			changed = true
+		}
+	}
+	for _, logKey := range oldState.Logs {
+		if !slices.ContainsFunc(newState.Logs, func(newKey authrootstl.CTLogKey) bool { return slices.Equal(newKey, logKey) }) {
+			fmt.Printf("removed: %s\n", logID(logKey))
+			changed = true
+		}
+	}
+	return changed, writeState(filename, &newState)
+}
+
+func writeState(filename string, s *state) error {
+	stateBytes, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tempFilename := filename + ".tmp"
+	if err := os.WriteFile(tempFilename, stateBytes, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tempFilename, filename)
+}
+
+func formatVersion(version []int32) string {
+	var formatted string
+	for i, component := range version {
+		if i > 0 {
+			formatted += "."
+		}
+		formatted += fmt.Sprint(component)
+	}
+	return formatted
+}
+
+func logID(logKey []byte) string {
+	keyID := sha256.Sum256(logKey)
+	return base64.StdEncoding.EncodeToString(keyID[:])
+}
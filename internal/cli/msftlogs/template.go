@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package msftlogs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"text/template"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// templateData is the data passed to the -template template
+type templateData struct {
+	Version string // e.g. "1.2"
+	Logs    []templateLog
+}
+
+type templateLog struct {
+	LogID        string // base64
+	LogIDHex     string
+	Key          string // base64 DER-encoded SPKI
+	KeyPEM       string
+	KeyAlgorithm string
+}
+
+func executeTemplate(text string, ctl *authrootstl.CTL) error {
+	tmpl, err := template.New("template").Parse(text)
+	if err != nil {
+		return err
+	}
+	data := templateData{Version: formatVersion(ctl.CTLogsVersion)}
+	for _, logKey := range ctl.CTLogs {
+		keyID := sha256.Sum256(logKey)
+		data.Logs = append(data.Logs, templateLog{
+			LogID:        base64.StdEncoding.EncodeToString(keyID[:]),
+			LogIDHex:     hex.EncodeToString(keyID[:]),
+			Key:          base64.StdEncoding.EncodeToString(logKey),
+			KeyPEM:       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: logKey})),
+			KeyAlgorithm: keyAlgorithm(logKey),
+		})
+	}
+	return tmpl.Execute(os.Stdout, data)
+}
@@ -0,0 +1,281 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftmirror implements the msftmirror command.
+package msftmirror
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+const defaultFiles = "authrootstl.cab,authrootseq.txt,disallowedcertstl.cab,disallowedcertseq.txt"
+
+// sequenceFiles maps each CAB file to the file containing its sequence number,
+// which is checked to avoid downloading an unchanged CAB file
+var sequenceFiles = map[string]string{
+	"authrootstl.cab":       "authrootseq.txt",
+	"disallowedcertstl.cab": "disallowedcertseq.txt",
+}
+
+var (
+	client *authrootstl.Client
+	signer crypto.Signer // for attestations, if non-nil
+)
+
+// Main runs msftmirror: maintain a local mirror of Microsoft's trust lists and root certificates,
+// suitable for serving to Windows clients (see the RootDirURL registry setting)
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	dir := flag.String("dir", "", "Mirror directory (required)")
+	files := flag.String("files", defaultFiles, "Comma-separated list of files to mirror in addition to the certificates")
+	parallel := flag.Int("parallel", 4, "Number of certificates to download concurrently")
+	check := flag.Bool("check", false, "Check the integrity of the mirror instead of refreshing it")
+	attestKey := flag.String("attest-key", "", "Sign an attestation of each CAB file downloaded with the PKCS#8 private key in PEM `FILE`, and save it as NAME.attestation.json")
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	if *dir == "" {
+		log.Fatal("-dir is required")
+	}
+	client = clientFromFlags()
+	fileList := strings.Split(*files, ",")
+	if *attestKey != "" {
+		var err error
+		if signer, err = readPrivateKey(*attestKey); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *check {
+		if problems := checkMirror(*dir, fileList); problems > 0 {
+			log.Fatalf("%d problems found", problems)
+		}
+		fmt.Println("Mirror is intact")
+		return
+	}
+
+	if err := os.MkdirAll(*dir, 0777); err != nil {
+		log.Fatal(err)
+	}
+	if err := refreshFiles(context.Background(), *dir, fileList); err != nil {
+		log.Fatal(err)
+	}
+	ctl, err := cmdutil.ReadCTL(filepath.Join(*dir, "authrootstl.cab"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	counts := cmdutil.DownloadCertificates(context.Background(), client, ctl.Entries, *dir, "der", *parallel)
+	fmt.Printf("Sequence number %X: %d certificates downloaded, %d already present, %d failed\n", &ctl.SequenceNumber, counts.Downloaded, counts.Present, counts.Failed)
+	if counts.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func refreshFiles(ctx context.Context, dir string, fileList []string) error {
+	handled := make(map[string]bool)
+	for _, name := range fileList {
+		if handled[name] {
+			continue
+		}
+		seqName, hasSeq := sequenceFiles[name]
+		if !hasSeq || !slices.Contains(fileList, seqName) {
+			if err := refreshFile(ctx, dir, name); err != nil {
+				return err
+			}
+			continue
+		}
+		handled[seqName] = true
+		seqBytes, err := client.Fetch(ctx, seqName)
+		if err != nil {
+			return err
+		}
+		localSeqBytes, err := os.ReadFile(filepath.Join(dir, seqName))
+		if err == nil && bytes.Equal(seqBytes, localSeqBytes) && fileExists(filepath.Join(dir, name)) {
+			continue
+		}
+		// Write the CAB file before the sequence file, so that an interrupted
+		// refresh is retried next time
+		if err := refreshFile(ctx, dir, name); err != nil {
+			return err
+		}
+		if err := cmdutil.WriteFileAtomic(filepath.Join(dir, seqName), seqBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func refreshFile(ctx context.Context, dir string, name string) error {
+	if signer != nil && strings.HasSuffix(name, ".cab") {
+		return refreshAttestedFile(ctx, dir, name)
+	}
+	var fileBytes []byte
+	var err error
+	if strings.HasSuffix(name, ".cab") {
+		// verifies the signature and integrity policy, like every other download of a trust list
+		fileBytes, _, err = client.FetchCAB(ctx, name)
+	} else {
+		fileBytes, err = client.Fetch(ctx, name)
+	}
+	if err != nil {
+		return err
+	}
+	return cmdutil.WriteFileAtomic(filepath.Join(dir, name), fileBytes)
+}
+
+// refreshAttestedFile downloads a CAB file and saves it along with a signed attestation of its contents
+func refreshAttestedFile(ctx context.Context, dir string, name string) error {
+	fileBytes, ctl, err := client.FetchCAB(ctx, name)
+	if err != nil {
+		return err
+	}
+	observation, err := authrootstl.NewObservation(ctl.Integrity.URL, time.Now(), fileBytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	attestation, err := observation.Sign(signer)
+	if err != nil {
+		return fmt.Errorf("error signing attestation: %w", err)
+	}
+	attestationJSON, err := json.Marshal(attestation)
+	if err != nil {
+		return err
+	}
+	if err := cmdutil.WriteFileAtomic(filepath.Join(dir, name), fileBytes); err != nil {
+		return err
+	}
+	return cmdutil.WriteFileAtomic(filepath.Join(dir, name+".attestation.json"), append(attestationJSON, '\n'))
+}
+
+func readPrivateKey(filename string) (crypto.Signer, error) {
+	pemBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: does not contain a PEM PRIVATE KEY block", filename)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key type %T", filename, key)
+	}
+	return signer, nil
+}
+
+// checkMirror logs every problem with the mirror and returns the number of problems
+func checkMirror(dir string, fileList []string) int {
+	problems := 0
+	for _, name := range fileList {
+		filename := filepath.Join(dir, name)
+		if !fileExists(filename) {
+			log.Printf("%s: missing", name)
+			problems++
+		} else if strings.HasSuffix(name, ".cab") {
+			if err := checkCAB(filename); err != nil {
+				log.Printf("%s: %s", name, err)
+				problems++
+			}
+		}
+	}
+
+	ctl, err := cmdutil.ReadCTL(filepath.Join(dir, "authrootstl.cab"))
+	if err != nil {
+		log.Print(err)
+		return problems + 1
+	}
+	referenced := make(map[string]bool)
+	for i := range ctl.Entries {
+		entry := &ctl.Entries[i]
+		name := authrootstl.CertificateFilename(entry)
+		referenced[name] = true
+		if !cmdutil.HaveCertificate(filepath.Join(dir, name), entry, "der") {
+			log.Printf("%s: missing or does not match the CTL", name)
+			problems++
+		}
+	}
+
+	crtFiles, err := filepath.Glob(filepath.Join(dir, "*.crt"))
+	if err != nil {
+		log.Print(err)
+		return problems + 1
+	}
+	for _, filename := range crtFiles {
+		if !referenced[filepath.Base(filename)] {
+			log.Printf("%s: not referenced by the CTL (harmless)", filepath.Base(filename))
+		}
+	}
+	return problems
+}
+
+// checkCAB returns an error unless the CAB file contains a trust list whose
+// signature is valid (or -insecure-skip-verify is given)
+func checkCAB(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var opts []authrootstl.ParseOption
+	if !client.InsecureSkipVerify {
+		verifyOptions := client.VerifyOptions
+		if verifyOptions.Roots == nil {
+			// on failure, Verify uses the root in the SignedData, if present
+			verifyOptions.Roots, _ = client.FetchMicrosoftRoot(context.Background())
+		}
+		opts = append(opts, authrootstl.WithVerification(verifyOptions))
+	}
+	_, err = authrootstl.ParseSTLCab(file, opts...)
+	return err
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftpins implements the msftpins command.
+package msftpins
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+// Main runs msftpins: list Microsoft's certificate pin rules
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	jsonOutput := flag.Bool("json", false, "Output the pin rules as JSON")
+	input := flag.String("input", "", "Read the pin rules from a local pinrulesstl.cab or pinrules.stl `FILE` instead of downloading it")
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	var ctl *authrootstl.CTL
+	var err error
+	if *input == "" {
+		ctl, err = clientFromFlags().FetchPinRulesCTL(context.Background())
+	} else {
+		ctl, err = cmdutil.ReadCTL(*input)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := authrootstl.ParsePinRules(ctl)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *jsonOutput {
+		if err := printJSON(rules); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printText(rules)
+}
+
+// decodedAttributes are the attributes which ParsePinRules decodes into PinRule fields
+var decodedAttributes = map[string]bool{
+	"1.3.6.1.4.1.311.10.3.34":   true, // domain names
+	"1.3.6.1.4.1.311.10.11.124": true, // pinned SHA-256 hashes
+}
+
+func printText(rules []authrootstl.PinRule) {
+	for i, rule := range rules {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Rule:    %s\n", rule.Name)
+		fmt.Printf("Domains: %s\n", strings.Join(rule.Domains, ", "))
+		for _, hash := range rule.PinnedSHA256 {
+			fmt.Printf("Pinned:  %s\n", hex.EncodeToString(hash[:]))
+		}
+		for _, attribute := range rule.Entry.Attributes {
+			if decodedAttributes[attribute.Type.String()] {
+				continue
+			}
+			for _, value := range attribute.Values {
+				fmt.Printf("Other:   %s = %s\n", attribute.Type, hex.EncodeToString(value))
+			}
+		}
+	}
+}
+
+type jsonAttribute struct {
+	Type   string   `json:"type"`
+	Values [][]byte `json:"values"`
+}
+
+type jsonRule struct {
+	Name         string          `json:"name"`
+	Domains      []string        `json:"domains"`
+	PinnedSHA256 []string        `json:"pinned_sha256"`
+	Attributes   []jsonAttribute `json:"attributes"`
+}
+
+func printJSON(rules []authrootstl.PinRule) error {
+	jsonRules := []jsonRule{}
+	for _, rule := range rules {
+		jsonRule := jsonRule{
+			Name:         rule.Name,
+			Domains:      append([]string{}, rule.Domains...),
+			PinnedSHA256: []string{},
+			Attributes:   []jsonAttribute{},
+		}
+		for _, hash := range rule.PinnedSHA256 {
+			jsonRule.PinnedSHA256 = append(jsonRule.PinnedSHA256, hex.EncodeToString(hash[:]))
+		}
+		for _, attribute := range rule.Entry.Attributes {
+			jsonRule.Attributes = append(jsonRule.Attributes, jsonAttribute{Type: attribute.Type.String(), Values: attribute.Values})
+		}
+		jsonRules = append(jsonRules, jsonRule)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(jsonRules)
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftquery implements the msftquery command.
+package msftquery
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+	"software.sslmate.com/src/authrootstl/internal/ctljson"
+)
+
+// query identifies the certificate being looked up.  Either sha1 or sha256 is
+// set if only a fingerprint is known; cert is set if the certificate is known.
+type query struct {
+	sha1   authrootstl.SHA1Fingerprint
+	sha256 authrootstl.SHA256Fingerprint
+	cert   *x509.Certificate
+}
+
+type result struct {
+	SHA1               string         `json:"sha1,omitempty"`
+	SHA256             string         `json:"sha256,omitempty"`
+	Subject            string         `json:"subject,omitempty"`
+	Authroot           *ctljson.Entry `json:"authroot"`
+	Disallowed         *ctljson.Entry `json:"disallowed"`
+	DisallowedKey      *ctljson.Entry `json:"disallowed_key"`
+	KeyChecked         bool           `json:"key_checked"`
+	AuthrootSequence   string         `json:"authroot_sequence_number"`
+	DisallowedSequence string         `json:"disallowed_sequence_number"`
+}
+
+// Main runs msftquery: look up a certificate's status in Microsoft's trust lists
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	jsonOutput := flag.Bool("json", false, "Output the result as JSON")
+	input := cmdutil.InputFlag()
+	disallowedInput := flag.String("disallowed-input", "", "Read the disallowed list from a local disallowedcertstl.cab or disallowedcert.stl `FILE` instead of downloading it")
+	clientFromFlags := cmdutil.ClientFlags()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] CERTFILE|FINGERPRINT\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "CERTFILE is a PEM or DER certificate; FINGERPRINT is a hex SHA-1 or SHA-256 hash.\n")
+		flag.PrintDefaults()
+	}
+	cmdutil.ParseFlags()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	q, err := parseQuery(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := clientFromFlags()
+	ctl, err := cmdutil.LoadCTL(context.Background(), client, *input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var disallowed *authrootstl.CTL
+	if *disallowedInput == "" {
+		disallowed, err = client.FetchDisallowedCTL(context.Background())
+	} else {
+		disallowed, err = cmdutil.ReadCTL(*disallowedInput)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	res := result{
+		SHA1:               sha1Hex(q.sha1),
+		SHA256:             ctljson.SHA256Hex(q.sha256),
+		AuthrootSequence:   fmt.Sprintf("%X", &ctl.SequenceNumber),
+		DisallowedSequence: fmt.Sprintf("%X", &disallowed.SequenceNumber),
+	}
+	if entry := q.find(ctl); entry != nil {
+		res.Authroot = jsonEntry(entry)
+	}
+	if entry := q.find(disallowed); entry != nil {
+		res.Disallowed = jsonEntry(entry)
+	}
+	if q.cert != nil {
+		res.Subject = q.cert.Subject.String()
+		res.KeyChecked = true
+		if entry := q.findKey(disallowed); entry != nil {
+			res.DisallowedKey = jsonEntry(entry)
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(res); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printText(&res)
+}
+
+func parseQuery(arg string) (*query, error) {
+	if fingerprint, err := authrootstl.ParseSHA1Fingerprint(arg); err == nil {
+		return &query{sha1: fingerprint}, nil
+	}
+	if fingerprint, err := authrootstl.ParseSHA256Fingerprint(arg); err == nil {
+		return &query{sha256: fingerprint}, nil
+	}
+	certBytes, err := os.ReadFile(arg)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(certBytes); block != nil {
+		certBytes = block.Bytes
+	}
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	return &query{sha1: sha1.Sum(cert.Raw), sha256: sha256.Sum256(cert.Raw), cert: cert}, nil
+}
+
+// find returns the entry for the certificate, or nil if there is none
+func (q *query) find(ctl *authrootstl.CTL) *authrootstl.Entry {
+	if !q.sha1.IsZero() {
+		if entry := ctl.FindBySHA1(q.sha1); entry != nil {
+			return entry
+		}
+	}
+	if !q.sha256.IsZero() {
+		return ctl.FindBySHA256(q.sha256)
+	}
+	return nil
+}
+
+// findKey returns an entry identifying the certificate's public key, or nil if there is none.
+// Entries may identify a key by the SHA-1 hash of the SubjectPublicKeyInfo, the SHA-1 hash of
+// the public key bits, or the subject key identifier.
+func (q *query) findKey(ctl *authrootstl.CTL) *authrootstl.Entry {
+	spkiHash := sha1.Sum(q.cert.RawSubjectPublicKeyInfo)
+	var keyIDs [][]byte
+	keyIDs = append(keyIDs, spkiHash[:])
+	if publicKeyBits, err := subjectPublicKeyBits(q.cert.RawSubjectPublicKeyInfo); err == nil {
+		bitsHash := sha1.Sum(publicKeyBits)
+		keyIDs = append(keyIDs, bitsHash[:])
+	}
+	if q.cert.SubjectKeyId != nil {
+		keyIDs = append(keyIDs, q.cert.SubjectKeyId)
+	}
+	for i := range ctl.Entries {
+		entry := &ctl.Entries[i]
+		for _, keyID := range keyIDs {
+			if bytes.Equal(entry.SubjectIdentifier, keyID) || bytes.Equal(entry.KeyID, keyID) {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+func sha1Hex(fingerprint authrootstl.SHA1Fingerprint) string {
+	if fingerprint.IsZero() {
+		return ""
+	}
+	return fingerprint.Hex()
+}
+
+func jsonEntry(entry *authrootstl.Entry) *ctljson.Entry {
+	jsonEntry := ctljson.NewEntry(entry)
+	return &jsonEntry
+}
+
+func printText(res *result) {
+	if res.Subject != "" {
+		fmt.Printf("Subject: %s\n", res.Subject)
+	}
+	if res.SHA1 != "" {
+		fmt.Printf("SHA-1: %s\n", res.SHA1)
+	}
+	if res.SHA256 != "" {
+		fmt.Printf("SHA-256: %s\n", res.SHA256)
+	}
+
+	fmt.Printf("Authroot list (sequence number %s): ", res.AuthrootSequence)
+	if res.Authroot == nil {
+		fmt.Println("not present")
+	} else {
+		fmt.Printf("present as %q\n", res.Authroot.FriendlyName)
+		fmt.Printf("\tEKUs: %s\n", ekusString(res.Authroot.EKUs))
+		if res.Authroot.DisallowedDate != nil {
+			fmt.Printf("\tDisallowed as of %s for %s\n", res.Authroot.DisallowedDate.Format(time.RFC3339), ekusString(res.Authroot.DisallowedEKUs))
+		}
+		if res.Authroot.NotBeforeDate != nil {
+			fmt.Printf("\tCertificates issued after %s distrusted for %s\n", res.Authroot.NotBeforeDate.Format(time.RFC3339), ekusString(res.Authroot.NotBeforeEKUs))
+		}
+	}
+
+	fmt.Printf("Disallowed list (sequence number %s): ", res.DisallowedSequence)
+	switch {
+	case res.Disallowed != nil:
+		fmt.Printf("certificate is disallowed (entry %s)\n", res.Disallowed.SHA1)
+	case res.DisallowedKey != nil:
+		fmt.Printf("key is disallowed (entry %s)\n", res.DisallowedKey.SHA1)
+	case !res.KeyChecked:
+		fmt.Println("certificate not present (key not checked; specify a certificate file to check the key)")
+	default:
+		fmt.Println("neither certificate nor key present")
+	}
+}
+
+func ekusString(ekus []string) string {
+	if len(ekus) == 0 {
+		return "all usages"
+	}
+	return strings.Join(ekus, ", ")
+}
+
+func subjectPublicKeyBits(spki cryptobyte.String) ([]byte, error) {
+	var sequence cryptobyte.String
+	var bits asn1.BitString
+	if !spki.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) ||
+		!sequence.SkipASN1(cryptobyte_asn1.SEQUENCE) ||
+		!sequence.ReadASN1BitString(&bits) {
+		return nil, fmt.Errorf("malformed SubjectPublicKeyInfo")
+	}
+	return bits.Bytes, nil
+}
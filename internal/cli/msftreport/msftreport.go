@@ -0,0 +1,416 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftreport implements the msftreport command.
+package msftreport
+
+import (
+	"cmp"
+	"context"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+var lineage *authrootstl.Lineage // nil unless -history was given
+
+// Main runs msftreport: report on the root certificates trusted by Microsoft
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	report := flag.String("report", "keys", "Report to produce (keys, expiring, consistency, stats, usage)")
+	days := flag.Int("days", 0, "For the expiring report, also list roots which expire within this many days")
+	details := flag.Bool("details", false, "List each root, not just the totals")
+	jsonOutput := flag.Bool("json", false, "Output the report as JSON")
+	certDir := flag.String("cert-dir", "", "Cache downloaded certificates in `DIR` (default: a temporary directory)")
+	parallel := flag.Int("parallel", 4, "Number of certificates to download concurrently")
+	historyPath := flag.String("history", "", "Annotate roots with when they first appeared and last changed, according to the msfthistory history `PATH` (a directory, or an SQLite database ending in .db)")
+	input := cmdutil.InputFlag()
+	disallowedInput := flag.String("disallowed-input", "", "For the consistency report, read the disallowed list from a local disallowedcertstl.cab or disallowedcert.stl `FILE` instead of downloading it")
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	if *historyPath != "" {
+		h, err := cmdutil.OpenHistory(*historyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if lineage, err = authrootstl.LoadLineage(context.Background(), h); err != nil {
+			log.Fatalf("%s: %s", *historyPath, err)
+		}
+	}
+
+	client := clientFromFlags()
+	loadRoots := func(ctl *authrootstl.CTL) []authrootstl.ExportRoot {
+		roots, err := cmdutil.LoadRoots(client, ctl.Entries, *certDir, *parallel)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return roots
+	}
+	var run func(*authrootstl.CTL) any
+	switch *report {
+	case "keys":
+		run = func(ctl *authrootstl.CTL) any { return keysReport(loadRoots(ctl), *details) }
+	case "expiring":
+		run = func(ctl *authrootstl.CTL) any { return expiringReport(loadRoots(ctl), *days) }
+	case "consistency":
+		run = func(ctl *authrootstl.CTL) any {
+			var disallowed *authrootstl.CTL
+			var err error
+			if *disallowedInput == "" {
+				disallowed, err = client.FetchDisallowedCTL(context.Background())
+			} else {
+				disallowed, err = cmdutil.ReadCTL(*disallowedInput)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			return consistencyReport(ctl, disallowed)
+		}
+	case "stats":
+		run = func(ctl *authrootstl.CTL) any { return (*statsOutput)(ctl.Stats()) }
+	case "usage":
+		run = func(ctl *authrootstl.CTL) any { return usageReport(ctl, *details) }
+	default:
+		log.Fatalf("unknown report %q", *report)
+	}
+
+	ctl, err := cmdutil.LoadCTL(context.Background(), client, *input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctl = ctl.Sorted()
+
+	result := run(ctl)
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(result); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		fmt.Print(result)
+	}
+}
+
+type keysRoot struct {
+	entry              *authrootstl.Entry
+	SHA1               string    `json:"sha1"`
+	FriendlyName       string    `json:"friendly_name"`
+	Key                string    `json:"key"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	ValidityYears      int       `json:"validity_years"`
+	appearance
+}
+
+type keysOutput struct {
+	Keys                map[string]int `json:"keys"`
+	SignatureAlgorithms map[string]int `json:"signature_algorithms"`
+	ValidityYears       map[int]int    `json:"validity_years"`
+	Unparseable         []string       `json:"unparseable,omitempty"`
+	Roots               []keysRoot     `json:"roots,omitempty"`
+}
+
+func keysReport(roots []authrootstl.ExportRoot, details bool) *keysOutput {
+	report := authrootstl.NewKeyReport(roots)
+	output := &keysOutput{
+		Keys:                report.Keys,
+		SignatureAlgorithms: report.SignatureAlgorithms,
+		ValidityYears:       report.ValidityYears,
+	}
+	for _, entry := range report.Unparseable {
+		output.Unparseable = append(output.Unparseable, entry.SHA1.Hex())
+	}
+	if details {
+		for _, info := range report.Roots {
+			output.Roots = append(output.Roots, keysRoot{
+				entry:              info.Entry,
+				SHA1:               info.Entry.SHA1.Hex(),
+				FriendlyName:       info.Entry.FriendlyName,
+				Key:                info.Key(),
+				SignatureAlgorithm: info.SignatureAlgorithm.String(),
+				NotBefore:          info.NotBefore,
+				NotAfter:           info.NotAfter,
+				ValidityYears:      info.ValidityYears(),
+				appearance:         appearanceOf(info.Entry),
+			})
+		}
+	}
+	return output
+}
+
+func (output *keysOutput) String() string {
+	var s strings.Builder
+	s.WriteString("Keys:\n")
+	writeCounts(&s, output.Keys)
+	s.WriteString("Signature algorithms:\n")
+	writeCounts(&s, output.SignatureAlgorithms)
+	s.WriteString("Validity periods:\n")
+	for _, years := range slices.Sorted(maps.Keys(output.ValidityYears)) {
+		fmt.Fprintf(&s, "\t%d years: %d\n", years, output.ValidityYears[years])
+	}
+	for _, sha1 := range output.Unparseable {
+		fmt.Fprintf(&s, "Unparseable: %s\n", sha1)
+	}
+	if len(output.Roots) > 0 {
+		s.WriteString("Roots:\n")
+	}
+	for _, root := range output.Roots {
+		fmt.Fprintf(&s, "\t%s: %s, %s, %s to %s%s\n", root.entry, root.Key, root.SignatureAlgorithm, root.NotBefore.Format(time.DateOnly), root.NotAfter.Format(time.DateOnly), root.appearance)
+	}
+	return s.String()
+}
+
+// writeCounts writes counts to s, most common first
+func writeCounts(s *strings.Builder, counts map[string]int) {
+	keys := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	for _, key := range keys {
+		fmt.Fprintf(s, "\t%s: %d\n", key, counts[key])
+	}
+}
+
+type expiringRoot struct {
+	entry        *authrootstl.Entry
+	SHA1         string    `json:"sha1"`
+	FriendlyName string    `json:"friendly_name"`
+	NotAfter     time.Time `json:"not_after"`
+	Expired      bool      `json:"expired"`
+	appearance
+}
+
+type expiringOutput []expiringRoot
+
+func expiringReport(roots []authrootstl.ExportRoot, days int) expiringOutput {
+	output := expiringOutput{}
+	for _, root := range authrootstl.ExpiringRoots(roots, time.Now(), time.Duration(days)*24*time.Hour) {
+		output = append(output, expiringRoot{
+			entry:        root.Entry,
+			SHA1:         root.Entry.SHA1.Hex(),
+			FriendlyName: root.Entry.FriendlyName,
+			NotAfter:     root.NotAfter,
+			Expired:      root.Expired,
+			appearance:   appearanceOf(root.Entry),
+		})
+	}
+	return output
+}
+
+func (output expiringOutput) String() string {
+	var s strings.Builder
+	for _, root := range output {
+		verb := "expires"
+		if root.Expired {
+			verb = "expired"
+		}
+		fmt.Fprintf(&s, "%s: %s %s%s\n", root.entry, verb, root.NotAfter.Format(time.DateOnly), root.appearance)
+	}
+	fmt.Fprintf(&s, "%d trusted roots have expired or are expiring\n", len(output))
+	return s.String()
+}
+
+// appearance is when a root first appeared and last changed, according to -history
+type appearance struct {
+	FirstSeen   time.Time `json:"first_seen,omitzero"`
+	LastChanged time.Time `json:"last_changed,omitzero"`
+}
+
+func appearanceOf(entry *authrootstl.Entry) appearance {
+	if lineage == nil {
+		return appearance{}
+	}
+	a, _ := lineage.Root(entry.SubjectIdentifier)
+	return appearance{FirstSeen: a.FirstSeen, LastChanged: a.LastChanged}
+}
+
+func (a appearance) String() string {
+	switch {
+	case a.FirstSeen.IsZero():
+		return ""
+	case a.LastChanged.IsZero():
+		return fmt.Sprintf(" (first seen %s)", a.FirstSeen.Format(time.DateOnly))
+	default:
+		return fmt.Sprintf(" (first seen %s, last changed %s)", a.FirstSeen.Format(time.DateOnly), a.LastChanged.Format(time.DateOnly))
+	}
+}
+
+type consistencyAnomaly struct {
+	root         *authrootstl.Entry
+	Kind         string `json:"kind"`
+	SHA1         string `json:"sha1"`
+	FriendlyName string `json:"friendly_name"`
+	Disallowed   string `json:"disallowed"` // subject identifier of the disallowed entry
+	StillTrusted bool   `json:"still_trusted"`
+	appearance
+}
+
+type consistencyOutput []consistencyAnomaly
+
+func consistencyReport(authroot, disallowed *authrootstl.CTL) consistencyOutput {
+	output := consistencyOutput{}
+	for _, anomaly := range authrootstl.CheckConsistency(authroot, disallowed, time.Now()) {
+		output = append(output, consistencyAnomaly{
+			root:         anomaly.Root,
+			Kind:         anomaly.Kind.String(),
+			SHA1:         anomaly.Root.SHA1.Hex(),
+			FriendlyName: anomaly.Root.FriendlyName,
+			Disallowed:   hex.EncodeToString(anomaly.Disallowed.SubjectIdentifier),
+			StillTrusted: anomaly.StillTrusted,
+			appearance:   appearanceOf(anomaly.Root),
+		})
+	}
+	return output
+}
+
+func (output consistencyOutput) String() string {
+	var s strings.Builder
+	for _, anomaly := range output {
+		trust := "disallowed or restricted by authroot"
+		if anomaly.StillTrusted {
+			trust = "STILL TRUSTED"
+		}
+		fmt.Fprintf(&s, "%s: %s as disallowed entry %s; %s%s\n", anomaly.root, anomaly.Kind, strings.ToUpper(anomaly.Disallowed), trust, anomaly.appearance)
+	}
+	fmt.Fprintf(&s, "%d roots are also in the disallowed list\n", len(output))
+	return s.String()
+}
+
+type statsOutput authrootstl.Stats
+
+func (output *statsOutput) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "Roots: %d\n", output.Roots)
+	s.WriteString("Roots by EKU:\n")
+	ekus := make(map[string]int, len(output.RootsByEKU))
+	for oid, count := range output.RootsByEKU {
+		if parsed, err := cmdutil.ParseOID(oid); err == nil {
+			oid = cmdutil.OIDString(parsed)
+		}
+		ekus[oid] = count
+	}
+	writeCounts(&s, ekus)
+	fmt.Fprintf(&s, "Roots without EKUs: %d\n", output.RootsWithoutEKUs)
+	fmt.Fprintf(&s, "Disallowed roots: %d (%d more scheduled)\n", output.Disallowed, output.ScheduledDisallows)
+	fmt.Fprintf(&s, "Roots with NotBefore dates: %d (%d in the future)\n", output.NotBefore, output.ScheduledNotBefore)
+	fmt.Fprintf(&s, "CT logs: %d\n", output.CTLogs)
+	writeCounts(&s, output.CTLogKeys)
+	return s.String()
+}
+
+type usageGroup struct {
+	entries     []*authrootstl.Entry
+	Description string   `json:"description"`
+	All         bool     `json:"all"`
+	EKUs        []string `json:"ekus"`
+	Except      []string `json:"except,omitempty"`
+	Count       int      `json:"count"`
+	Roots       []string `json:"roots,omitempty"` // SHA-1 hashes, with -details
+}
+
+type usageOutput []usageGroup
+
+func usageReport(ctl *authrootstl.CTL, details bool) usageOutput {
+	output := usageOutput{}
+	for _, group := range authrootstl.GroupByUsage(ctl.Entries, time.Now()) {
+		g := usageGroup{
+			Description: usageDescription(&group),
+			All:         group.All,
+			EKUs:        oidStrings(group.EKUs),
+			Except:      oidStrings(group.Except),
+			Count:       len(group.Entries),
+		}
+		if details {
+			g.entries = group.Entries
+			for _, entry := range group.Entries {
+				g.Roots = append(g.Roots, entry.SHA1.Hex())
+			}
+		}
+		output = append(output, g)
+	}
+	return output
+}
+
+func usageDescription(group *authrootstl.UsageGroup) string {
+	switch {
+	case group.All && len(group.Except) == 0:
+		return "all usages"
+	case group.All:
+		return "all usages except " + oidNames(group.Except)
+	case len(group.EKUs) == 0:
+		return "no usages"
+	default:
+		return oidNames(group.EKUs)
+	}
+}
+
+func oidStrings(oids []asn1.ObjectIdentifier) []string {
+	strs := make([]string, len(oids))
+	for i, oid := range oids {
+		strs[i] = oid.String()
+	}
+	return strs
+}
+
+// oidNames returns the names of the OIDs, or their dotted forms if they have none
+func oidNames(oids []asn1.ObjectIdentifier) string {
+	names := make([]string, len(oids))
+	for i, oid := range oids {
+		if name, ok := authrootstl.LookupOID(oid); ok {
+			names[i] = name
+		} else {
+			names[i] = oid.String()
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func (output usageOutput) String() string {
+	var s strings.Builder
+	for _, group := range output {
+		fmt.Fprintf(&s, "%s: %d\n", group.Description, group.Count)
+		for _, entry := range group.entries {
+			fmt.Fprintf(&s, "\t%s%s\n", entry, appearanceOf(entry))
+		}
+	}
+	return s.String()
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftroots implements the msftroots command.
+package msftroots
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+	"software.sslmate.com/src/authrootstl/internal/ctljson"
+)
+
+// Main runs msftroots: list the root certificates trusted by Microsoft
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	format := flag.String("format", "text", "Output format (text, json, csv, html)")
+	var filters []authrootstl.EntryFilter
+	flag.Func("eku", "Only list roots currently trusted for the extended key usage `OID`", func(value string) error {
+		eku, err := cmdutil.ParseOID(value)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, authrootstl.TrustedForAt(eku, time.Now()))
+		return nil
+	})
+	active := flag.Bool("active", false, "Only list roots which are currently trusted for at least one usage")
+	list := flag.String("list", "authroot", "Which trust list to download: authroot (trusted roots) or disallowed (distrusted certificates)")
+	previous := flag.String("previous", "", "With -format html, highlight changes since the trust list in `FILE`")
+	input := cmdutil.InputFlag()
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	output, ok := outputFormats[*format]
+	if !ok && *format != "html" {
+		log.Fatalf("unknown format %q", *format)
+	}
+	if *previous != "" && *format != "html" {
+		log.Fatal("-previous requires -format html")
+	}
+
+	var ctl *authrootstl.CTL
+	var err error
+	switch {
+	case *input != "":
+		ctl, err = cmdutil.ReadCTL(*input)
+	case *list == "authroot":
+		ctl, err = clientFromFlags().FetchCTL(context.Background())
+	case *list == "disallowed":
+		ctl, err = clientFromFlags().FetchDisallowedCTL(context.Background())
+	default:
+		log.Fatalf("unknown list %q", *list)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *active {
+		filters = append(filters, authrootstl.ActiveAt(time.Now()))
+	}
+	if *format == "html" {
+		options := authrootstl.HTMLReportOptions{Filters: filters}
+		if *previous != "" {
+			options.Previous, err = cmdutil.ReadCTL(*previous)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := authrootstl.WriteHTMLReport(os.Stdout, ctl, options); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := output(authrootstl.FilterEntries(ctl.Entries, filters...)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+var outputFormats = map[string]func([]authrootstl.Entry) error{
+	"text": printText,
+	"json": printJSON,
+	"csv":  printCSV,
+}
+
+func printText(entries []authrootstl.Entry) error {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Friendly name: %s\n", entry.FriendlyName)
+		fmt.Printf("SHA-1:         %s\n", hex.EncodeToString(entry.SubjectIdentifier))
+		fmt.Printf("SHA-256:       %s\n", ctljson.SHA256Hex(entry.SHA256))
+		fmt.Printf("EKUs:          %s\n", formatEKUs(entry.EKUs))
+		if !entry.DisallowedDate.IsZero() {
+			fmt.Printf("Disallowed:    %s for %s\n", entry.DisallowedDate.Format(time.RFC3339), formatEKUs(entry.DisallowedEKUs))
+		}
+		if !entry.NotBeforeDate.IsZero() {
+			fmt.Printf("Not before:    %s for %s\n", entry.NotBeforeDate.Format(time.RFC3339), formatEKUs(entry.NotBeforeEKUs))
+		}
+	}
+	return nil
+}
+
+func printJSON(entries []authrootstl.Entry) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(ctljson.NewEntries(entries))
+}
+
+func printCSV(entries []authrootstl.Entry) error {
+	return cmdutil.WriteEntriesCSV(os.Stdout, entries)
+}
+
+func formatEKUs(ekus []asn1.ObjectIdentifier) string {
+	if len(ekus) == 0 {
+		return "all usages"
+	}
+	var strs []string
+	for _, eku := range ekus {
+		strs = append(strs, cmdutil.OIDString(eku))
+	}
+	return strings.Join(strs, ", ")
+}
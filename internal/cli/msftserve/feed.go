@@ -0,0 +1,162 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package msftserve
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/history"
+)
+
+// maxFeedEntries is the number of most recent changes included in the feed
+const maxFeedEntries = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// handleFeed serves an Atom feed of changes to the authroot trust list
+func (srv *server) handleFeed(w http.ResponseWriter, req *http.Request) {
+	var records []history.Record
+	if srv.history != nil {
+		var err error
+		if records, err = srv.history.Records(); err != nil {
+			slog.Error("reading history failed", "error", err)
+			http.Error(w, "Error reading history", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		srv.mu.RLock()
+		records = slices.Clone(srv.changes)
+		srv.mu.RUnlock()
+	}
+	if len(records) > maxFeedEntries {
+		records = records[len(records)-maxFeedEntries:]
+	}
+	slices.Reverse(records)
+
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	feedURL := scheme + "://" + req.Host + req.URL.Path
+	feed := atomFeed{
+		ID:      feedURL,
+		Title:   "Microsoft trusted root program changes",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Rel: "self", Href: feedURL},
+	}
+	if len(records) > 0 {
+		feed.Updated = records[0].ObservedAt.Format(time.RFC3339)
+	}
+	for i := range records {
+		feed.Entries = append(feed.Entries, newAtomEntry(&records[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(feed); err != nil {
+		slog.Warn("writing response failed", "error", err)
+	}
+}
+
+func newAtomEntry(record *history.Record) atomEntry {
+	diff := &record.Diff
+	var content strings.Builder
+	fmt.Fprintf(&content, "Sequence number %s, effective %s, contains %d roots.\n", record.SequenceNumber, record.EffectiveDate.Format(time.RFC3339), record.Roots)
+	for _, root := range diff.AddedRoots {
+		fmt.Fprintf(&content, "\nAdded root %s", describeRoot(&root))
+	}
+	for _, root := range diff.RemovedRoots {
+		fmt.Fprintf(&content, "\nRemoved root %s", describeRoot(&root))
+	}
+	for _, root := range diff.ChangedRoots {
+		fmt.Fprintf(&content, "\nChanged root %s: %s", describeRoot(&root), strings.Join(root.Changes, "; "))
+	}
+	for _, ctLog := range diff.AddedCTLogs {
+		fmt.Fprintf(&content, "\nAdded CT log %x", ctLog.LogID)
+	}
+	for _, ctLog := range diff.RemovedCTLogs {
+		fmt.Fprintf(&content, "\nRemoved CT log %x", ctLog.LogID)
+	}
+
+	observed := record.ObservedAt.Format(time.RFC3339)
+	return atomEntry{
+		ID: "urn:x-authrootstl:sequence:" + record.SequenceNumber,
+		Title: fmt.Sprintf("Sequence number %s: %d roots added, %d removed, %d changed; %d CT logs added, %d removed",
+			record.SequenceNumber, len(diff.AddedRoots), len(diff.RemovedRoots), len(diff.ChangedRoots), len(diff.AddedCTLogs), len(diff.RemovedCTLogs)),
+		Updated:   observed,
+		Published: observed,
+		Author:    atomAuthor{Name: "Microsoft"},
+		Content:   atomContent{Type: "text", Body: content.String()},
+	}
+}
+
+func describeRoot(root *authrootstl.JSONDiffRoot) string {
+	if root.FriendlyName == "" {
+		return root.SHA1
+	}
+	return root.SHA1 + " (" + root.FriendlyName + ")"
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package msftserve
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// handleMetrics serves metrics in the Prometheus text exposition format
+func (srv *server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	srv.mu.RLock()
+	lists := []trustList{srv.authroot, srv.disallowed}
+	srv.mu.RUnlock()
+
+	now := time.Now()
+	var buf bytes.Buffer
+	writeMetric(&buf, "authrootstl_last_fetch_success", "gauge", "Whether the most recent download of the trust list succeeded", lists, func(list *trustList) (float64, bool) {
+		return boolFloat(list.lastFetchErr == nil), !list.lastFetch.IsZero()
+	})
+	writeMetric(&buf, "authrootstl_last_fetch_timestamp_seconds", "gauge", "Time of the most recent download attempt", lists, func(list *trustList) (float64, bool) {
+		return unixSeconds(list.lastFetch), !list.lastFetch.IsZero()
+	})
+	writeMetric(&buf, "authrootstl_last_fetch_duration_seconds", "gauge", "Duration of the most recent download attempt", lists, func(list *trustList) (float64, bool) {
+		return list.lastFetchDuration.Seconds(), !list.lastFetch.IsZero()
+	})
+	writeMetric(&buf, "authrootstl_sequence_number", "gauge", "Sequence number of the loaded trust list", lists, func(list *trustList) (float64, bool) {
+		if list.ctl == nil {
+			return 0, false
+		}
+		sequenceNumber, _ := new(big.Float).SetInt(&list.ctl.SequenceNumber).Float64()
+		return sequenceNumber, true
+	})
+	writeMetric(&buf, "authrootstl_effective_date_timestamp_seconds", "gauge", "Effective date of the loaded trust list", lists, func(list *trustList) (float64, bool) {
+		if list.ctl == nil {
+			return 0, false
+		}
+		return unixSeconds(list.ctl.EffectiveDate), true
+	})
+	writeMetric(&buf, "authrootstl_effective_date_age_seconds", "gauge", "Time since the effective date of the loaded trust list", lists, func(list *trustList) (float64, bool) {
+		if list.ctl == nil {
+			return 0, false
+		}
+		return now.Sub(list.ctl.EffectiveDate).Seconds(), true
+	})
+	writeMetric(&buf, "authrootstl_entries", "gauge", "Number of entries in the loaded trust list", lists, func(list *trustList) (float64, bool) {
+		if list.ctl == nil {
+			return 0, false
+		}
+		return float64(len(list.ctl.Entries)), true
+	})
+	writeMetric(&buf, "authrootstl_ct_logs", "gauge", "Number of CT logs recognized by the loaded trust list", lists, func(list *trustList) (float64, bool) {
+		if list.ctl == nil {
+			return 0, false
+		}
+		return float64(len(list.ctl.CTLogs)), true
+	})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
+
+// writeMetric writes a metric with a sample for each list for which value returns true
+func writeMetric(buf *bytes.Buffer, name, metricType, help string, lists []trustList, value func(*trustList) (float64, bool)) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, metricType)
+	for i := range lists {
+		if v, ok := value(&lists[i]); ok {
+			fmt.Fprintf(buf, "%s{list=%q} %g\n", name, lists[i].name, v)
+		}
+	}
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftserve implements the msftserve command.
+package msftserve
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/history"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+	"software.sslmate.com/src/authrootstl/internal/ctljson"
+)
+
+type server struct {
+	mu         sync.RWMutex
+	authroot   trustList
+	disallowed trustList
+
+	history history.Store    // nil if no history
+	changes []history.Record // changes seen since startup, if history is nil
+
+	policy *cmdutil.VerifyPolicy
+	health *cmdutil.Health // reflects the authroot list
+}
+
+type trustList struct {
+	name  string
+	fetch func(context.Context) (*authrootstl.CTL, error)
+
+	ctl               *authrootstl.CTL
+	lastFetch         time.Time
+	lastFetchDuration time.Duration
+	lastFetchErr      error
+}
+
+// Main runs msftserve: serve Microsoft's trust lists as JSON over HTTP, keeping them up to date
+func Main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	listen := flag.String("listen", ":8080", "Listen on `ADDRESS`")
+	interval := flag.Duration("interval", authrootstl.DefaultWatchInterval, "Time between checks for new trust lists")
+	historyPath := flag.String("history", "", "Record each trust list seen in the history `PATH` (a directory, or an SQLite database ending in .db; see msfthistory), which is used for the change feed")
+	clientFromFlags := cmdutil.ClientFlags()
+	policyFromFlags := cmdutil.VerifyFlags()
+	cmdutil.ParseFlags()
+
+	client := clientFromFlags()
+	client.Cache = authrootstl.NewLRUCache(3) // one for each trust list, which usually hasn't changed since the last refresh
+	policy := policyFromFlags()
+	policy.Client = client
+	srv := &server{
+		authroot:   trustList{name: "authroot", fetch: client.FetchCTL},
+		disallowed: trustList{name: "disallowed", fetch: client.FetchDisallowedCTL},
+		policy:     policy,
+		health:     cmdutil.NewHealth(*interval, policy),
+	}
+	if *historyPath != "" {
+		var err error
+		if srv.history, err = cmdutil.OpenHistory(*historyPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	go srv.refreshLoop(context.Background(), *interval)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ctl", srv.handleCTL)
+	mux.HandleFunc("GET /roots", srv.handleRoots)
+	mux.HandleFunc("GET /roots/{fingerprint}", srv.handleRoot)
+	mux.HandleFunc("GET /ctlogs", srv.handleCTLogs)
+	mux.HandleFunc("GET /disallowed", srv.handleDisallowed)
+	mux.HandleFunc("GET /metrics", srv.handleMetrics)
+	mux.HandleFunc("GET /feed.atom", srv.handleFeed)
+	mux.HandleFunc("GET /healthz", srv.health.HandleHealthz)
+	mux.HandleFunc("GET /readyz", srv.health.HandleReadyz)
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
+
+func (srv *server) refreshLoop(ctx context.Context, interval time.Duration) {
+	for {
+		srv.refresh(ctx)
+		time.Sleep(interval)
+	}
+}
+
+func (srv *server) refresh(ctx context.Context) {
+	srv.refreshList(ctx, &srv.authroot)
+	srv.refreshList(ctx, &srv.disallowed)
+}
+
+func (srv *server) refreshList(ctx context.Context, list *trustList) {
+	start := time.Now()
+	ctl, err := list.fetch(ctx)
+	duration := time.Since(start)
+	if err != nil {
+		slog.Warn("refresh failed", "list", list.name, "error", err)
+	} else if err = srv.policy.CheckSignature(ctl); err != nil {
+		slog.Warn("trust list rejected", "list", list.name, "sequence_number", fmt.Sprintf("%X", &ctl.SequenceNumber), "error", err)
+	}
+
+	var previous *authrootstl.CTL
+	var changed bool
+	srv.mu.Lock()
+	list.lastFetch = start
+	list.lastFetchDuration = duration
+	if err == nil && list.ctl != nil {
+		if err = ctl.CheckRollback(&list.ctl.SequenceNumber, list.ctl.EffectiveDate); err != nil {
+			slog.Warn("trust list rejected", "list", list.name, "error", err)
+		}
+	}
+	list.lastFetchErr = err
+	if err == nil {
+		if list.ctl == nil || list.ctl.SequenceNumber.Cmp(&ctl.SequenceNumber) != 0 {
+			slog.Info("trust list loaded", "list", list.name, "sequence_number", fmt.Sprintf("%X", &ctl.SequenceNumber))
+			previous, changed = list.ctl, true
+		}
+		list.ctl = ctl
+	}
+	current := list.ctl
+	srv.mu.Unlock()
+
+	if list == &srv.authroot {
+		srv.health.Polled(current, err)
+	}
+
+	if changed && previous != nil {
+		slog.Info("trust list changed", "list", list.name, "diff", authrootstl.Diff(previous, ctl))
+	}
+	if changed && list == &srv.authroot {
+		srv.recordChange(previous, ctl, start)
+	}
+}
+
+// recordChange records a new authroot CTL in the history, or in memory if there
+// is no history, for the change feed
+func (srv *server) recordChange(previous, ctl *authrootstl.CTL, observedAt time.Time) {
+	if srv.history != nil {
+		if _, err := srv.history.Put(context.Background(), ctl, observedAt); err != nil {
+			slog.Error("recording history failed", "error", err)
+		}
+		return
+	}
+	if previous == nil {
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.changes = append(srv.changes, history.NewRecord(previous, ctl, observedAt))
+}
+
+func (srv *server) getCTL() *authrootstl.CTL {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	return srv.authroot.ctl
+}
+
+func (srv *server) getDisallowed() *authrootstl.CTL {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	return srv.disallowed.ctl
+}
+
+func (srv *server) handleCTL(w http.ResponseWriter, req *http.Request) {
+	if ctl := srv.getCTL(); ctl == nil {
+		notLoaded(w)
+	} else {
+		writeJSON(w, ctljson.NewCTL(ctl))
+	}
+}
+
+func (srv *server) handleRoots(w http.ResponseWriter, req *http.Request) {
+	if ctl := srv.getCTL(); ctl == nil {
+		notLoaded(w)
+	} else {
+		writeJSON(w, ctljson.NewEntries(ctl.Entries))
+	}
+}
+
+func (srv *server) handleRoot(w http.ResponseWriter, req *http.Request) {
+	ctl := srv.getCTL()
+	if ctl == nil {
+		notLoaded(w)
+		return
+	}
+	var entry *authrootstl.Entry
+	if fingerprint, err := authrootstl.ParseSHA1Fingerprint(req.PathValue("fingerprint")); err == nil {
+		entry = ctl.FindBySHA1(fingerprint)
+	} else if fingerprint, err := authrootstl.ParseSHA256Fingerprint(req.PathValue("fingerprint")); err == nil {
+		entry = ctl.FindBySHA256(fingerprint)
+	} else {
+		http.Error(w, "Fingerprint must be a hex-encoded SHA-1 or SHA-256 hash", http.StatusBadRequest)
+		return
+	}
+	if entry != nil {
+		writeJSON(w, ctljson.NewEntry(entry))
+		return
+	}
+	http.Error(w, "Root not found", http.StatusNotFound)
+}
+
+func (srv *server) handleCTLogs(w http.ResponseWriter, req *http.Request) {
+	if ctl := srv.getCTL(); ctl == nil {
+		notLoaded(w)
+	} else {
+		writeJSON(w, ctljson.NewLogs(ctl.CTLogs))
+	}
+}
+
+func (srv *server) handleDisallowed(w http.ResponseWriter, req *http.Request) {
+	if disallowed := srv.getDisallowed(); disallowed == nil {
+		notLoaded(w)
+	} else {
+		writeJSON(w, ctljson.NewEntries(disallowed.Entries))
+	}
+}
+
+func notLoaded(w http.ResponseWriter) {
+	http.Error(w, "Trust list has not been loaded yet", http.StatusServiceUnavailable)
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Warn("writing response failed", "error", err)
+	}
+}
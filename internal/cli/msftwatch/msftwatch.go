@@ -0,0 +1,160 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package msftwatch implements the msftwatch command.
+package msftwatch
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+	"software.sslmate.com/src/authrootstl/notify"
+)
+
+// Main runs msftwatch: watch for changes to Microsoft's trust list and send notifications
+func Main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	var notifiers []notify.Notifier
+	interval := flag.Duration("interval", authrootstl.DefaultWatchInterval, "Time between checks for a new trust list")
+	stateFile := flag.String("state", "", "Remember the most recently seen trust list in `FILE`, so changes made while not running are reported")
+	historyPath := flag.String("history", "", "Record each trust list seen in the history `PATH` (a directory, or an SQLite database ending in .db; see msfthistory)")
+	flag.Func("webhook", "POST a JSON summary of each change to `URL` (may be repeated)", func(url string) error {
+		notifiers = append(notifiers, &notify.Webhook{URL: url})
+		return nil
+	})
+	flag.Func("exec", "Run `PROGRAM` with a JSON summary of each change on standard input (may be repeated)", func(path string) error {
+		notifiers = append(notifiers, &notify.Command{Path: path})
+		return nil
+	})
+	var email notify.Email
+	flag.StringVar(&email.Server, "smtp-server", "", "Send email notifications through the SMTP server at `HOST:PORT`")
+	flag.StringVar(&email.Username, "smtp-username", "", "Authenticate to the SMTP server as `USER`, with the password from $SMTP_PASSWORD")
+	flag.StringVar(&email.From, "email-from", "", "Send email notifications from `ADDRESS`")
+	flag.Func("email-to", "Send email notifications to `ADDRESS` (may be repeated)", func(address string) error {
+		email.To = append(email.To, address)
+		return nil
+	})
+	emailSubject := flag.String("email-subject", notify.DefaultEmailSubject, "Go `TEMPLATE` for the subject of email notifications")
+	emailBody := flag.String("email-template", "", "Read the Go template for the body of email notifications from `FILE`")
+	listen := flag.String("listen", "", "Serve /healthz and /readyz on `ADDRESS`")
+	clientFromFlags := cmdutil.ClientFlags()
+	policyFromFlags := cmdutil.VerifyFlags()
+	cmdutil.ParseFlags()
+
+	client := clientFromFlags()
+	policy := policyFromFlags()
+	policy.Client = client
+	health := cmdutil.NewHealth(*interval, policy)
+	if *listen != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /healthz", health.HandleHealthz)
+		mux.HandleFunc("GET /readyz", health.HandleReadyz)
+		go func() { log.Fatal(http.ListenAndServe(*listen, mux)) }()
+	}
+
+	if email.Server != "" {
+		if email.From == "" || len(email.To) == 0 {
+			log.Fatal("-email-from and -email-to are required with -smtp-server")
+		}
+		email.Password = os.Getenv("SMTP_PASSWORD")
+		var err error
+		if email.Subject, email.Body, err = notify.ParseEmailTemplates(*emailSubject, *emailBody); err != nil {
+			log.Fatal(err)
+		}
+		notifiers = append(notifiers, &email)
+	}
+
+	var initial *authrootstl.CTL
+	if *stateFile != "" {
+		var err error
+		initial, err = cmdutil.ReadCTL(*stateFile)
+		if errors.Is(err, os.ErrNotExist) {
+			initial = nil
+		} else if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var store authrootstl.HistoryStore
+	if *historyPath != "" {
+		hist, err := cmdutil.OpenHistory(*historyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if initial == nil {
+			if initial, err = hist.Latest(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		store = hist
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher := &authrootstl.Watcher{
+		Client:   client,
+		Interval: *interval,
+		OnChange: func(oldCTL, newCTL *authrootstl.CTL) {
+			diff := authrootstl.Diff(oldCTL, newCTL)
+			for _, warning := range newCTL.Warnings {
+				slog.Warn("trust list warning", "sequence_number", fmt.Sprintf("%X", &newCTL.SequenceNumber), "warning", warning)
+			}
+			notify.NotifyAll(ctx, notifiers, diff.Events(), func(notifier notify.Notifier, err error) {
+				slog.Error("notification failed", "notifier", fmt.Sprint(notifier), "error", err)
+			})
+			saveState(*stateFile, newCTL)
+		},
+		Verify:  policy.CheckSignature,
+		History: store,
+		OnPoll:  health.Polled,
+		Logger:  slog.Default(),
+	}
+	last, _ := watcher.Run(ctx, initial)
+	saveState(*stateFile, last)
+}
+
+func saveState(filename string, ctl *authrootstl.CTL) {
+	if filename == "" || ctl == nil {
+		return
+	}
+	if err := cmdutil.WriteFileAtomic(filename, ctl.Raw); err != nil {
+		slog.Error("saving state failed", "file", filename, "error", err)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package stldiff implements the stldiff command.
+package stldiff
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+var (
+	client  *authrootstl.Client
+	lineage *authrootstl.Lineage // nil unless -history was given
+)
+
+// Main runs stldiff: show the differences between two trust lists
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	jsonOutput := flag.Bool("json", false, "Output the differences as JSON")
+	eventsOutput := flag.Bool("events", false, "Output the differences as a stream of JSON change events, one per line")
+	changelog := flag.String("changelog", "", "Output a changelog in `FORMAT` (markdown or html), newest first; more than two files may be given")
+	failIfChanged := flag.Bool("fail-if-changed", false, "Exit with status 3 if any roots or CT logs changed")
+	failIfRootAdded := flag.Bool("fail-if-root-added", false, "Exit with status 3 if any roots were added")
+	failIfRootRemoved := flag.Bool("fail-if-root-removed", false, "Exit with status 3 if any roots were removed")
+	failIfRootChanged := flag.Bool("fail-if-root-changed", false, "Exit with status 3 if any roots' entries changed")
+	failIfLogAdded := flag.Bool("fail-if-log-added", false, "Exit with status 3 if any CT logs were added")
+	failIfLogRemoved := flag.Bool("fail-if-log-removed", false, "Exit with status 3 if any CT logs were removed")
+	historyPath := flag.String("history", "", "Annotate roots and CT logs with when they first appeared and last changed, according to the msfthistory history `PATH` (a directory, or an SQLite database ending in .db)")
+	clientFromFlags := cmdutil.ClientFlags()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] OLD NEW\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s -changelog FORMAT OLDEST ... NEWEST\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "OLD and NEW are CAB or STL files, or \"latest\" to download the current authrootstl.cab.\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Exit status is 0 on success, 1 on error, 2 on invalid usage, and 3 if a -fail-if condition is met.\n")
+		flag.PrintDefaults()
+	}
+	cmdutil.ParseFlags()
+	if flag.NArg() != 2 && (*changelog == "" || flag.NArg() < 2) {
+		flag.Usage()
+		os.Exit(cmdutil.ExitUsage)
+	}
+	client = clientFromFlags()
+	if *historyPath != "" {
+		h, err := cmdutil.OpenHistory(*historyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if lineage, err = authrootstl.LoadLineage(context.Background(), h); err != nil {
+			log.Fatalf("%s: %s", *historyPath, err)
+		}
+	}
+
+	var ctls []*authrootstl.CTL
+	for _, arg := range flag.Args() {
+		ctl, err := loadCTL(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctls = append(ctls, ctl)
+	}
+	diff := authrootstl.Diff(ctls[0], ctls[len(ctls)-1])
+
+	if *changelog != "" {
+		if err := writeChangelog(*changelog, ctls); err != nil {
+			log.Fatal(err)
+		}
+	} else if *eventsOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, event := range diff.Events() {
+			if err := encoder.Encode(event); err != nil {
+				log.Fatal(err)
+			}
+		}
+	} else if *jsonOutput {
+		output := diff.JSON()
+		if lineage != nil {
+			output.Annotate(lineage)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(output); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printText(diff)
+	}
+
+	failed := false
+	check := func(enabled bool, met bool, description string) {
+		if enabled && met {
+			log.Print(description)
+			failed = true
+		}
+	}
+	check(*failIfChanged, !diff.Empty(), "roots or CT logs changed")
+	check(*failIfRootAdded, len(diff.AddedRoots) > 0, "roots were added")
+	check(*failIfRootRemoved, len(diff.RemovedRoots) > 0, "roots were removed")
+	check(*failIfRootChanged, len(diff.ChangedRoots) > 0, "roots were changed")
+	check(*failIfLogAdded, len(diff.AddedCTLogs) > 0, "CT logs were added")
+	check(*failIfLogRemoved, len(diff.RemovedCTLogs) > 0, "CT logs were removed")
+	if failed {
+		os.Exit(cmdutil.ExitCheckFailed)
+	}
+}
+
+// writeChangelog writes a changelog of the differences between each consecutive pair of CTLs, newest first
+func writeChangelog(format string, ctls []*authrootstl.CTL) error {
+	var diffs []*authrootstl.CTLDiff
+	for i := len(ctls) - 1; i > 0; i-- {
+		diffs = append(diffs, authrootstl.Diff(ctls[i-1], ctls[i]))
+	}
+	switch format {
+	case "markdown":
+		return authrootstl.WriteMarkdownChangelog(os.Stdout, diffs)
+	case "html":
+		return authrootstl.WriteHTMLChangelog(os.Stdout, diffs)
+	default:
+		return fmt.Errorf("unknown changelog format %q", format)
+	}
+}
+
+func loadCTL(arg string) (*authrootstl.CTL, error) {
+	if arg == "latest" {
+		return client.FetchCTL(context.Background())
+	}
+	return cmdutil.ReadCTL(arg)
+}
+
+func printText(diff *authrootstl.CTLDiff) {
+	fmt.Printf("Sequence number: %X -> %X\n", diff.OldSequenceNumber, diff.NewSequenceNumber)
+	fmt.Printf("Effective date: %s -> %s\n", diff.OldEffectiveDate.Format(time.RFC3339), diff.NewEffectiveDate.Format(time.RFC3339))
+	if !diff.OldNextUpdate.Equal(diff.NewNextUpdate) {
+		fmt.Printf("Next update: %s -> %s\n", optionalTimeString(diff.OldNextUpdate), optionalTimeString(diff.NewNextUpdate))
+	}
+	if diff.OldVersion != diff.NewVersion {
+		fmt.Printf("Version: %d -> %d\n", diff.OldVersion, diff.NewVersion)
+	}
+	printRoots("Added roots", diff.AddedRoots)
+	printRoots("Removed roots", diff.RemovedRoots)
+	if len(diff.ChangedRoots) > 0 {
+		fmt.Println("Changed roots:")
+		for _, change := range diff.ChangedRoots {
+			fmt.Printf("\t%X\t%s%s\n", change.New.SubjectIdentifier, change.New.FriendlyName, rootAnnotation(&change.New))
+			for _, description := range change.Changes {
+				fmt.Printf("\t\t%s\n", description)
+			}
+		}
+	}
+	printLogs("Added CT logs", diff.AddedCTLogs)
+	printLogs("Removed CT logs", diff.RemovedCTLogs)
+}
+
+func optionalTimeString(t time.Time) string {
+	if t.IsZero() {
+		return "(none)"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func printRoots(heading string, entries []authrootstl.Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", heading)
+	for _, entry := range entries {
+		fmt.Printf("\t%X\t%s%s\n", entry.SubjectIdentifier, entry.FriendlyName, rootAnnotation(&entry))
+	}
+}
+
+func printLogs(heading string, logKeys []authrootstl.CTLogKey) {
+	if len(logKeys) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", heading)
+	for _, logKey := range logKeys {
+		fmt.Printf("\t%s%s\n", logKey, logAnnotation(logKey))
+	}
+}
+
+// rootAnnotation returns when the root first appeared and last changed, according to lineage
+func rootAnnotation(entry *authrootstl.Entry) string {
+	if lineage == nil {
+		return ""
+	}
+	appearance, ok := lineage.Root(entry.SubjectIdentifier)
+	if !ok {
+		return "\t(not in history)"
+	}
+	if appearance.LastChanged.IsZero() {
+		return fmt.Sprintf("\t(first seen %s)", appearance.FirstSeen.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("\t(first seen %s, last changed %s)", appearance.FirstSeen.Format(time.DateOnly), appearance.LastChanged.Format(time.DateOnly))
+}
+
+// logAnnotation returns when the CT log first appeared, according to lineage
+func logAnnotation(logKey authrootstl.CTLogKey) string {
+	if lineage == nil {
+		return ""
+	}
+	appearance, ok := lineage.CTLog(logKey)
+	if !ok {
+		return "\t(not in history)"
+	}
+	return fmt.Sprintf("\t(first seen %s)", appearance.FirstSeen.Format(time.DateOnly))
+}
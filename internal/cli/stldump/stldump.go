@@ -0,0 +1,256 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package stldump implements the stldump command.
+package stldump
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+// Main runs stldump: decode an STL file in human-readable form
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	input := flag.String("input", "", "Read the trust list from a local CAB or STL `FILE` instead of downloading it")
+	cabName := flag.String("cab", "authrootstl.cab", "Name of the CAB file to download (e.g. authrootstl.cab, disallowedcertstl.cab)")
+	clientFromFlags := cmdutil.ClientFlags()
+	cmdutil.ParseFlags()
+
+	der, err := cmdutil.LoadSTL(context.Background(), clientFromFlags(), *input, *cabName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signedData, err := authrootstl.ParseSignedData(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctl, err := authrootstl.ParseAuthrootstl(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dumpCTL(ctl)
+	dumpSignedData(signedData)
+}
+
+func dumpCTL(ctl *authrootstl.CTL) {
+	fmt.Println("Certificate Trust List:")
+	fmt.Printf("  Version: %d\n", ctl.Version)
+	fmt.Printf("  Kind: %s\n", ctl.Kind())
+	fmt.Println("  Subject usage:")
+	for _, usage := range ctl.SubjectUsage {
+		fmt.Printf("    %s\n", cmdutil.OIDString(usage))
+	}
+	fmt.Printf("  List identifier: %s\n", bytesString(ctl.ListIdentifier))
+	fmt.Printf("  Sequence number: %X\n", &ctl.SequenceNumber)
+	fmt.Printf("  Effective date: %s\n", timeString(ctl.EffectiveDate))
+	fmt.Printf("  Next update: %s\n", timeString(ctl.NextUpdate))
+	fmt.Printf("  Subject algorithm: %s\n", cmdutil.OIDString(ctl.SubjectAlgorithm))
+	if len(ctl.Warnings) > 0 {
+		fmt.Printf("  Warnings (%d):\n", len(ctl.Warnings))
+		for _, warning := range ctl.Warnings {
+			fmt.Printf("    %s\n", warning)
+		}
+	}
+
+	fmt.Printf("  Extensions (%d):\n", len(ctl.Extensions))
+	for _, extension := range ctl.Extensions {
+		critical := ""
+		if extension.Critical {
+			critical = " [critical]"
+		}
+		fmt.Printf("    %s%s:\n", cmdutil.OIDString(extension.ID), critical)
+		if extension.ID.String() == "1.3.6.1.4.1.311.10.3.52" {
+			fmt.Printf("      Version: %s\n", versionString(ctl.CTLogsVersion))
+			for _, logKey := range ctl.CTLogs {
+				logID := sha256.Sum256(logKey)
+				fmt.Printf("      Log %x\n", logID)
+				fmt.Printf("        Key: %x\n", logKey)
+			}
+		} else {
+			fmt.Printf("      %x\n", extension.Value)
+		}
+	}
+
+	fmt.Printf("  Entries (%d):\n", len(ctl.Entries))
+	for i, entry := range ctl.Entries {
+		fmt.Printf("    [%d] %X\n", i, entry.SubjectIdentifier)
+		for _, attribute := range entry.Attributes {
+			fmt.Printf("      %s:\n", cmdutil.OIDString(attribute.Type))
+			for _, value := range attribute.Values {
+				for _, line := range attributeValueLines(attribute.Type, value) {
+					fmt.Printf("        %s\n", line)
+				}
+			}
+		}
+	}
+}
+
+func dumpSignedData(signedData *authrootstl.SignedData) {
+	fmt.Println("PKCS#7 SignedData:")
+	fmt.Printf("  Version: %d\n", signedData.Version)
+	fmt.Printf("  Content type: %s\n", cmdutil.OIDString(signedData.ContentType))
+
+	fmt.Printf("  Certificates (%d):\n", len(signedData.Certificates))
+	for i, certBytes := range signedData.Certificates {
+		fmt.Printf("    [%d] SHA-256 %x\n", i, sha256.Sum256(certBytes))
+		cert, err := x509.ParseCertificate(certBytes)
+		if err != nil {
+			fmt.Printf("      Unparseable: %s\n", err)
+			continue
+		}
+		fmt.Printf("      Subject: %s\n", cert.Subject)
+		fmt.Printf("      Issuer: %s\n", cert.Issuer)
+		fmt.Printf("      Serial number: %X\n", cert.SerialNumber)
+		fmt.Printf("      Validity: %s to %s\n", timeString(cert.NotBefore), timeString(cert.NotAfter))
+		fmt.Printf("      Signature algorithm: %s\n", cert.SignatureAlgorithm)
+	}
+
+	fmt.Printf("  Signer infos (%d):\n", len(signedData.SignerInfos))
+	for i, signerInfo := range signedData.SignerInfos {
+		fmt.Printf("    [%d] Version %d\n", i, signerInfo.Version)
+		if signerInfo.Issuer != nil {
+			fmt.Printf("      Issuer: %s\n", nameString(signerInfo.Issuer))
+			fmt.Printf("      Serial number: %X\n", signerInfo.SerialNumber)
+		} else {
+			fmt.Printf("      Subject key ID: %x\n", signerInfo.SubjectKeyID)
+		}
+		fmt.Printf("      Digest algorithm: %s\n", cmdutil.OIDString(signerInfo.DigestAlgorithm))
+		dumpSignerAttributes("Authenticated attributes", signerInfo.AuthenticatedAttributes)
+		fmt.Printf("      Signature algorithm: %s\n", cmdutil.OIDString(signerInfo.SignatureAlgorithm))
+		fmt.Printf("      Signature: %x\n", signerInfo.Signature)
+		dumpSignerAttributes("Unauthenticated attributes", signerInfo.UnauthenticatedAttributes)
+	}
+}
+
+func dumpSignerAttributes(heading string, attributes []authrootstl.SignerAttribute) {
+	fmt.Printf("      %s (%d):\n", heading, len(attributes))
+	for _, attribute := range attributes {
+		fmt.Printf("        %s:\n", cmdutil.OIDString(attribute.Type))
+		for _, value := range attribute.Values {
+			fmt.Printf("          %s\n", signerAttributeValueString(value))
+		}
+	}
+}
+
+func signerAttributeValueString(value []byte) string {
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(value, &raw); err != nil {
+		return hex.EncodeToString(value)
+	}
+	switch {
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagOID:
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(value, &oid); err == nil {
+			return cmdutil.OIDString(oid)
+		}
+	case raw.Class == asn1.ClassUniversal && (raw.Tag == asn1.TagUTCTime || raw.Tag == asn1.TagGeneralizedTime):
+		var t time.Time
+		if _, err := asn1.Unmarshal(value, &t); err == nil {
+			return timeString(t)
+		}
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagOctetString:
+		return hex.EncodeToString(raw.Bytes)
+	}
+	return fmt.Sprintf("%x (%d bytes)", value, len(value))
+}
+
+func attributeValueLines(attributeType asn1.ObjectIdentifier, value []byte) []string {
+	switch attributeType.String() {
+	case "1.3.6.1.4.1.311.10.11.9", "1.3.6.1.4.1.311.10.11.122", "1.3.6.1.4.1.311.10.11.127":
+		var ekus []asn1.ObjectIdentifier
+		if rest, err := asn1.Unmarshal(value, &ekus); err == nil && len(rest) == 0 {
+			lines := make([]string, len(ekus))
+			for i, eku := range ekus {
+				lines[i] = cmdutil.OIDString(eku)
+			}
+			return lines
+		}
+	case "1.3.6.1.4.1.311.10.11.11":
+		if len(value)%2 == 0 {
+			units := make([]uint16, len(value)/2)
+			for i := range units {
+				units[i] = binary.LittleEndian.Uint16(value[2*i:])
+			}
+			return []string{fmt.Sprintf("%q", strings.TrimRight(string(utf16.Decode(units)), "\x00"))}
+		}
+	case "1.3.6.1.4.1.311.10.11.104", "1.3.6.1.4.1.311.10.11.126":
+		if t, err := authrootstl.ParseFiletime(value); err == nil {
+			return []string{timeString(t)}
+		}
+	}
+	return []string{hex.EncodeToString(value)}
+}
+
+func bytesString(value []byte) string {
+	for _, b := range value {
+		if b < 0x20 || b > 0x7e {
+			return hex.EncodeToString(value)
+		}
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+func nameString(der []byte) string {
+	var rdns pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(der, &rdns); err != nil || len(rest) != 0 {
+		return hex.EncodeToString(der)
+	}
+	return rdns.String()
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return "(not present)"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func versionString(version []int32) string {
+	parts := make([]string, len(version))
+	for i, n := range version {
+		parts[i] = fmt.Sprint(n)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package stlverify implements the stlverify command.
+package stlverify
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/cmdutil"
+)
+
+// Main runs stlverify: verify the signature and freshness of an STL file
+func Main() {
+	log.SetFlags(0)
+	log.SetPrefix(os.Args[0] + ": ")
+
+	input := flag.String("input", "", "Read the trust list from a local CAB or STL `FILE` instead of downloading it")
+	cabName := flag.String("cab", "authrootstl.cab", "Name of the CAB file to download (e.g. authrootstl.cab, disallowedcertstl.cab)")
+	rootsFile := flag.String("roots", "", "Trust the PEM-encoded root certificates in `FILE` instead of Microsoft Root Certificate Authority 2010, which is downloaded and checked against its pinned fingerprint")
+	maxAge := flag.Duration("max-age", 0, "Fail if the CTL's effective date is older than this")
+	requireTimestamp := flag.Bool("require-timestamp", false, "Fail if the signature has no timestamp countersignature")
+	clockSkewFromFlag := cmdutil.ClockSkewFlag()
+	revocationFromFlag := cmdutil.RevocationFlag()
+	clientFromFlags := cmdutil.ClientFlags()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Exit status is 0 if the STL is valid, 1 on error, 2 on invalid usage, and 3 if the STL is invalid.\n")
+		flag.PrintDefaults()
+	}
+	cmdutil.ParseFlags()
+
+	opts := authrootstl.VerifyOptions{ClockSkew: clockSkewFromFlag(), Revocation: revocationFromFlag()}
+	if *rootsFile != "" {
+		pemBytes, err := os.ReadFile(*rootsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Roots = x509.NewCertPool()
+		if !opts.Roots.AppendCertsFromPEM(pemBytes) {
+			log.Fatalf("%s: no certificates found", *rootsFile)
+		}
+	}
+
+	client := clientFromFlags()
+	der, err := cmdutil.LoadSTL(context.Background(), client, *input, *cabName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.Roots == nil {
+		// on failure, Verify uses the root in the SignedData, if present
+		opts.Roots, _ = client.FetchMicrosoftRoot(context.Background())
+	}
+	signedData, err := authrootstl.ParseSignedData(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctl, err := authrootstl.ParseAuthrootstl(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ok := true
+	verification, err := signedData.Verify(opts)
+	if err != nil {
+		fmt.Printf("Signature: FAILED: %s\n", err)
+		ok = false
+	} else {
+		fmt.Printf("Signature: OK\n")
+		fmt.Printf("Chain: %s\n", chainString(verification.Chain))
+		if !verification.SigningTime.IsZero() {
+			fmt.Printf("Signing time: %s\n", verification.SigningTime.Format(time.RFC3339))
+		}
+		if verification.Timestamp.IsZero() {
+			fmt.Printf("Timestamp: none\n")
+			if *requireTimestamp {
+				ok = false
+			}
+		} else {
+			fmt.Printf("Timestamp: OK: %s by %s\n", verification.Timestamp.Format(time.RFC3339), verification.Timestamper.Subject)
+		}
+		for _, result := range verification.Revocation {
+			if result.Status == authrootstl.RevocationUnknown {
+				fmt.Printf("Revocation: %s: unknown: %s\n", result.Certificate.Subject, result.Err)
+			} else {
+				fmt.Printf("Revocation: %s: %s according to %s\n", result.Certificate.Subject, result.Status, result.Source)
+			}
+		}
+	}
+
+	if err := ctl.CheckFreshnessWithSkew(time.Now(), *maxAge, opts.ClockSkew); err != nil {
+		fmt.Printf("Freshness: FAILED: %s\n", err)
+		ok = false
+	} else {
+		fmt.Printf("Freshness: OK: effective %s, sequence number %X\n", ctl.EffectiveDate.Format(time.RFC3339), &ctl.SequenceNumber)
+	}
+
+	if !ok {
+		fmt.Println("Verdict: INVALID")
+		os.Exit(cmdutil.ExitCheckFailed)
+	}
+	fmt.Println("Verdict: VALID")
+}
+
+func chainString(chain []*x509.Certificate) string {
+	names := make([]string, len(chain))
+	for i, cert := range chain {
+		names[i] = cert.Subject.String()
+	}
+	return strings.Join(names, " -> ")
+}
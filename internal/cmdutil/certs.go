@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"context"
+	"encoding/pem"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// CertificateCounts summarizes the result of DownloadCertificates
+type CertificateCounts struct {
+	Downloaded int64
+	Present    int64 // already present and matching
+	Failed     int64
+}
+
+// DownloadCertificates downloads the certificate for each entry into dir, in the
+// given format ("der" or "pem"), skipping certificates which are already present.
+// Failures are logged.
+func DownloadCertificates(ctx context.Context, client *authrootstl.Client, entries []authrootstl.Entry, dir string, format string, parallel int) CertificateCounts {
+	var downloaded, present, failed atomic.Int64
+	entryChan := make(chan *authrootstl.Entry)
+	var wg sync.WaitGroup
+	for range max(parallel, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entryChan {
+				filename := filepath.Join(dir, CertificateFilename(entry, format))
+				if HaveCertificate(filename, entry, format) {
+					present.Add(1)
+					continue
+				}
+				if err := downloadCertificate(ctx, client, entry, filename, format); err != nil {
+					log.Printf("%X: %s", entry.SHA1, err)
+					failed.Add(1)
+					continue
+				}
+				downloaded.Add(1)
+			}
+		}()
+	}
+	for i := range entries {
+		entryChan <- &entries[i]
+	}
+	close(entryChan)
+	wg.Wait()
+	return CertificateCounts{Downloaded: downloaded.Load(), Present: present.Load(), Failed: failed.Load()}
+}
+
+// CertificateFilename returns the name of the file in which to store the entry's
+// certificate in the given format
+func CertificateFilename(entry *authrootstl.Entry, format string) string {
+	filename := authrootstl.CertificateFilename(entry)
+	if format == "pem" {
+		filename = strings.TrimSuffix(filename, ".crt") + ".pem"
+	}
+	return filename
+}
+
+// HaveCertificate returns true if filename already contains the entry's certificate
+func HaveCertificate(filename string, entry *authrootstl.Entry, format string) bool {
+	certBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+	if format == "pem" {
+		block, _ := pem.Decode(certBytes)
+		if block == nil {
+			return false
+		}
+		certBytes = block.Bytes
+	}
+	return entry.CheckCertificate(certBytes) == nil
+}
+
+func downloadCertificate(ctx context.Context, client *authrootstl.Client, entry *authrootstl.Entry, filename string, format string) error {
+	certBytes, err := client.FetchCertificate(ctx, entry)
+	if err != nil {
+		return err
+	}
+	if format == "pem" {
+		certBytes = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	}
+	return WriteFileAtomic(filename, certBytes)
+}
+
+// WriteFileAtomic writes data to a temporary file and renames it to filename,
+// so an interrupted write never leaves a partial file behind
+func WriteFileAtomic(filename string, data []byte) error {
+	tempFilename := filename + ".tmp"
+	if err := os.WriteFile(tempFilename, data, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tempFilename, filename)
+}
+
+// LoadRoots downloads the certificate for each entry into certDir (or a temporary
+// directory if certDir is empty) and returns the roots
+func LoadRoots(client *authrootstl.Client, entries []authrootstl.Entry, certDir string, parallel int) ([]authrootstl.ExportRoot, error) {
+	if certDir == "" {
+		tempDir, err := os.MkdirTemp("", "authrootstl")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tempDir)
+		certDir = tempDir
+	} else if err := os.MkdirAll(certDir, 0777); err != nil {
+		return nil, err
+	}
+
+	counts := DownloadCertificates(context.Background(), client, entries, certDir, "der", parallel)
+	if counts.Failed > 0 {
+		log.Printf("%d certificates could not be downloaded and will be omitted", counts.Failed)
+	}
+
+	roots := make([]authrootstl.ExportRoot, 0, len(entries))
+	for i := range entries {
+		filename := filepath.Join(certDir, CertificateFilename(&entries[i], "der"))
+		if !HaveCertificate(filename, &entries[i], "der") {
+			continue
+		}
+		certBytes, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, authrootstl.ExportRoot{Entry: &entries[i], Certificate: certBytes})
+	}
+	return roots, nil
+}
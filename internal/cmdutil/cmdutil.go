@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package cmdutil contains code shared by the commands
+package cmdutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// Exit statuses, documented in the usage message of commands which check conditions
+const (
+	ExitError       = 1 // the command failed, e.g. because a file could not be downloaded or parsed
+	ExitUsage       = 2 // the command line was invalid
+	ExitCheckFailed = 3 // the command ran successfully but a requested check failed
+)
+
+// ClientFlags registers the -url, -timeout, -retries, -proxy, -tls-roots, -roots,
+// -clock-skew, -check-revocation, -insecure-skip-verify, and -require-integrity flags.  After flag parsing, call the returned function to
+// get a Client configured according to the flags.
+func ClientFlags() func() *authrootstl.Client {
+	baseURL := flag.String("url", authrootstl.DefaultBaseURL, "Base `URL` from which to download authrootstl.cab")
+	timeout := flag.Duration("timeout", authrootstl.DefaultTimeout, "Time limit for each download attempt")
+	retries := flag.Int("retries", 0, "Number of times to retry failed downloads")
+	proxy := flag.String("proxy", "", "Download through the HTTP proxy at `URL` (default: from $HTTP_PROXY)")
+	tlsRoots := flag.String("tls-roots", "", "Verify the server's TLS certificate using the PEM-encoded root certificates in `FILE` instead of the system roots")
+	rootsFromFlag := RootsFlag()
+	clockSkewFromFlag := ClockSkewFlag()
+	revocationFromFlag := RevocationFlag()
+	insecureSkipVerify := flag.Bool("insecure-skip-verify", false, "Don't verify the signatures of downloaded trust lists")
+	var integrityPolicy authrootstl.IntegrityPolicy
+	flag.Func("require-integrity", "Reject downloaded trust lists unless `POLICY` (https, signature, https-or-signature, or https-and-signature) is satisfied", func(value string) (err error) {
+		integrityPolicy, err = authrootstl.ParseIntegrityPolicy(value)
+		return err
+	})
+	return func() *authrootstl.Client {
+		client := &authrootstl.Client{
+			BaseURL:            *baseURL,
+			Timeout:            *timeout,
+			Retries:            *retries,
+			VerifyOptions:      authrootstl.VerifyOptions{Roots: rootsFromFlag(), ClockSkew: clockSkewFromFlag(), Revocation: revocationFromFlag()},
+			InsecureSkipVerify: *insecureSkipVerify,
+			IntegrityPolicy:    integrityPolicy,
+			Logger:             slog.Default(),
+		}
+		if *tlsRoots != "" {
+			client.TLSRoots = ReadCertPool(*tlsRoots)
+		}
+		if *proxy != "" {
+			proxyURL, err := url.Parse(*proxy)
+			if err != nil {
+				log.Fatalf("-proxy: %s", err)
+			}
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.Proxy = http.ProxyURL(proxyURL)
+			client.HTTPClient = &http.Client{Transport: transport}
+		}
+		return client
+	}
+}
+
+// InputFlag registers the -input flag
+func InputFlag() *string {
+	return flag.String("input", "", "Read the trust list from a local authrootstl.cab or authroot.stl `FILE` instead of downloading it")
+}
+
+// LoadCTL reads the CTL from the given file, or downloads it using client if filename is empty
+func LoadCTL(ctx context.Context, client *authrootstl.Client, filename string) (*authrootstl.CTL, error) {
+	if filename == "" {
+		return client.FetchCTL(ctx)
+	}
+	return ReadCTL(filename)
+}
+
+// ReadCTL reads a CTL from either a CAB file or a bare STL file
+func ReadCTL(filename string) (*authrootstl.CTL, error) {
+	der, err := ReadSTL(filename)
+	if err != nil {
+		return nil, err
+	}
+	ctl, err := authrootstl.ParseAuthrootstl(der, authrootstl.WithZeroCopy())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return ctl, nil
+}
+
+// LoadSTL returns the DER-encoded STL from the given CAB or STL file, or downloads
+// the named CAB file using client if filename is empty
+func LoadSTL(ctx context.Context, client *authrootstl.Client, filename string, cabName string) ([]byte, error) {
+	if filename == "" {
+		cabBytes, err := client.Fetch(ctx, cabName)
+		if err != nil {
+			return nil, err
+		}
+		return authrootstl.ExtractSTL(bytes.NewReader(cabBytes))
+	}
+	return ReadSTL(filename)
+}
+
+// ReadSTL returns the DER-encoded STL from either a CAB file or a bare STL file
+func ReadSTL(filename string) ([]byte, error) {
+	fileBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(fileBytes, []byte("MSCF")) {
+		return fileBytes, nil
+	}
+	der, err := authrootstl.ExtractSTL(bytes.NewReader(fileBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return der, nil
+}
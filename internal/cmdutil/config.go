@@ -0,0 +1,186 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Command is the name of the running command, which selects its section of the
+// configuration file.  The authrootstl multi-tool sets it to the name of the
+// standalone command that implements each subcommand (e.g. "msftlogs" for
+// "authrootstl logs"), so that both share a configuration.
+var Command = filepath.Base(os.Args[0])
+
+// ListFlags, if true, makes ParseFlags print the names of the command's flags,
+// one per line, and exit instead of parsing the command line.  It is used for
+// shell completion.
+var ListFlags bool
+
+// ParseFlags registers the -config, -log-level, and -log-format flags and parses
+// the command line, then sets each flag which was not given on the command line
+// from the configuration file, and installs the default slog logger.
+//
+// The configuration file is a JSON object mapping flag names to values, which
+// apply to every command that has a flag with that name.  A value may be an
+// array for flags that can be repeated.  A key naming a command (e.g. "msftwatch")
+// may map to a nested object of flags which apply only to that command and take
+// precedence over the top-level values.  For example:
+//
+//	{
+//		"url": "http://mirror.example.com/trustedr/",
+//		"retries": 3,
+//		"msftwatch": {"webhook": ["https://hooks.example.com/a", "https://hooks.example.com/b"]}
+//	}
+//
+// A file whose name ends in .toml is instead read as TOML, with a table for each
+// command.  Only strings, numbers, booleans, arrays, and tables are supported:
+//
+//	url = "http://mirror.example.com/trustedr/"
+//	retries = 3
+//
+//	[msftwatch]
+//	webhook = ["https://hooks.example.com/a", "https://hooks.example.com/b"]
+func ParseFlags() {
+	setupLogging := logFlags()
+	configFile := flag.String("config", "", "Read flag defaults from the JSON or TOML `FILE` (default: authrootstl/config.toml or authrootstl/config.json in the user configuration directory, if it exists)")
+	if ListFlags {
+		flag.VisitAll(func(f *flag.Flag) { fmt.Println("-" + f.Name) })
+		os.Exit(0)
+	}
+	flag.Parse()
+	if err := applyConfig(*configFile, Command); err != nil {
+		log.Fatal(err)
+	}
+	setupLogging()
+}
+
+// DefaultConfigFile returns the location of the configuration file used when -config
+// is not specified: config.toml if it exists, and otherwise config.json
+func DefaultConfigFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	tomlFile := filepath.Join(dir, "authrootstl", "config.toml")
+	if _, err := os.Stat(tomlFile); err == nil {
+		return tomlFile, nil
+	}
+	return filepath.Join(dir, "authrootstl", "config.json"), nil
+}
+
+func readConfig(filename string, configBytes []byte) (map[string]any, error) {
+	if strings.HasSuffix(filename, ".toml") {
+		return parseTOML(string(configBytes))
+	}
+	decoder := json.NewDecoder(bytes.NewReader(configBytes))
+	decoder.UseNumber()
+	var config map[string]any
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return config, nil
+}
+
+func applyConfig(filename string, command string) error {
+	explicit := filename != ""
+	if !explicit {
+		var err error
+		if filename, err = DefaultConfigFile(); err != nil {
+			return nil
+		}
+	}
+	configBytes, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	config, err := readConfig(filename, configBytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	values := make(map[string]any)
+	for name, value := range config {
+		if _, isTable := value.(map[string]any); !isTable {
+			values[name] = value
+		}
+	}
+	if commandConfig, ok := config[command]; ok {
+		commandValues, isTable := commandConfig.(map[string]any)
+		if !isTable {
+			return fmt.Errorf("%s: %s: value must be an object", filename, command)
+		}
+		for name, value := range commandValues {
+			values[name] = value
+		}
+	}
+
+	onCommandLine := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { onCommandLine[f.Name] = true })
+	for name, value := range values {
+		if flag.Lookup(name) == nil || onCommandLine[name] {
+			continue
+		}
+		if err := setFlagFromConfig(name, value); err != nil {
+			return fmt.Errorf("%s: %s: %w", filename, name, err)
+		}
+	}
+	return nil
+}
+
+func setFlagFromConfig(name string, value any) error {
+	values, isArray := value.([]any)
+	if !isArray {
+		values = []any{value}
+	}
+	for _, v := range values {
+		switch v.(type) {
+		case string, json.Number, bool:
+		default:
+			return fmt.Errorf("value must be a string, number, boolean, or array of those")
+		}
+		if err := flag.Set(name, fmt.Sprint(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+	"software.sslmate.com/src/authrootstl/internal/ctljson"
+)
+
+// WriteEntriesCSV writes the entries to w as CSV, with a header row
+func WriteEntriesCSV(out io.Writer, entries []authrootstl.Entry) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"SHA-1", "SHA-256", "Friendly Name", "EKUs", "Disallowed Date", "Disallowed EKUs", "Not Before Date", "Not Before EKUs"})
+	for _, entry := range entries {
+		w.Write([]string{
+			hex.EncodeToString(entry.SubjectIdentifier),
+			ctljson.SHA256Hex(entry.SHA256),
+			entry.FriendlyName,
+			strings.Join(ctljson.OIDStrings(entry.EKUs), ";"),
+			formatOptionalTime(entry.DisallowedDate),
+			strings.Join(ctljson.OIDStrings(entry.DisallowedEKUs), ";"),
+			formatOptionalTime(entry.NotBeforeDate),
+			strings.Join(ctljson.OIDStrings(entry.NotBeforeEKUs), ";"),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
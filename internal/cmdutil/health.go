@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// VerifyPolicy determines which CTLs a daemon accepts and considers ready to serve
+type VerifyPolicy struct {
+	Verify  bool // whether the signature must be valid
+	Options authrootstl.VerifyOptions
+	MaxAge  time.Duration // passed to CTL.CheckFreshnessWithSkew, along with Options.ClockSkew
+
+	// Client downloads Microsoft's root when Options.Roots is nil, and may be nil
+	Client *authrootstl.Client
+}
+
+// VerifyFlags registers the -verify, -roots, -clock-skew, -check-revocation, and -max-age flags.  After flag parsing,
+// call the returned function to get the VerifyPolicy specified by the flags.
+func VerifyFlags() func() *VerifyPolicy {
+	verify := flag.Bool("verify", false, "Ignore trust lists whose signature is invalid")
+	rootsFromFlag := RootsFlag()
+	clockSkewFromFlag := ClockSkewFlag()
+	revocationFromFlag := RevocationFlag()
+	maxAge := flag.Duration("max-age", 0, "Consider the trust list stale if its effective date is older than this")
+	return func() *VerifyPolicy {
+		return &VerifyPolicy{
+			Verify:  *verify,
+			Options: authrootstl.VerifyOptions{Roots: rootsFromFlag(), ClockSkew: clockSkewFromFlag(), Revocation: revocationFromFlag()},
+			MaxAge:  *maxAge,
+		}
+	}
+}
+
+// ClockSkewFlag registers the -clock-skew flag, unless it is already registered.
+// After flag parsing, call the returned function to get its value.
+func ClockSkewFlag() func() time.Duration {
+	if flag.Lookup("clock-skew") == nil {
+		flag.Duration("clock-skew", 0, "Tolerate the system clock being off by up to this much when checking certificate validity and freshness")
+	}
+	return func() time.Duration {
+		return flag.Lookup("clock-skew").Value.(flag.Getter).Get().(time.Duration)
+	}
+}
+
+// RevocationFlag registers the -check-revocation flag, unless it is already registered.
+// After flag parsing, call the returned function to get the RevocationChecker
+// specified by the flag, or nil if revocation checking is disabled.
+func RevocationFlag() func() *authrootstl.RevocationChecker {
+	if flag.Lookup("check-revocation") == nil {
+		flag.String("check-revocation", "", "Check the signer's certificate chain against OCSP and CRLs; with `MODE` soft, unknown status is tolerated, and with hard, it is not")
+	}
+	return func() *authrootstl.RevocationChecker {
+		switch mode := flag.Lookup("check-revocation").Value.String(); mode {
+		case "":
+			return nil
+		case "soft":
+			return new(authrootstl.RevocationChecker)
+		case "hard":
+			return &authrootstl.RevocationChecker{RequireStatus: true}
+		default:
+			log.Fatalf("-check-revocation: unknown mode %q", mode)
+			return nil
+		}
+	}
+}
+
+// RootsFlag registers the -roots flag, unless it is already registered.  After
+// flag parsing, call the returned function to get the roots specified by the flag,
+// or nil for Microsoft's root.
+func RootsFlag() func() *x509.CertPool {
+	if flag.Lookup("roots") == nil {
+		flag.String("roots", "", "Verify signatures using the PEM-encoded root certificates in `FILE` instead of Microsoft's root, which is downloaded and checked against its pinned fingerprint")
+	}
+	return func() *x509.CertPool {
+		rootsFile := flag.Lookup("roots").Value.String()
+		if rootsFile == "" {
+			return nil
+		}
+		return ReadCertPool(rootsFile)
+	}
+}
+
+// ReadCertPool reads PEM-encoded certificates from the given file, exiting
+// on failure
+func ReadCertPool(filename string) *x509.CertPool {
+	pemBytes, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		log.Fatalf("%s: no certificates found", filename)
+	}
+	return pool
+}
+
+// CheckSignature returns an error if signatures must be verified and ctl's is invalid
+func (policy *VerifyPolicy) CheckSignature(ctl *authrootstl.CTL) error {
+	if !policy.Verify {
+		return nil
+	}
+	signedData, err := authrootstl.ParseSignedData(ctl.Raw)
+	if err != nil {
+		return err
+	}
+	opts := policy.Options
+	if opts.Roots == nil && policy.Client != nil {
+		// on failure, Verify uses the root in the SignedData, if present
+		opts.Roots, _ = policy.Client.FetchMicrosoftRoot(context.Background())
+	}
+	_, err = signedData.Verify(opts)
+	return err
+}
+
+// Health tracks the state of a daemon's trust list and serves the /healthz and
+// /readyz endpoints.  The daemon is healthy as long as it has polled recently,
+// and ready when it has a CTL which passed verification and is fresh.
+type Health struct {
+	interval time.Duration
+	policy   *VerifyPolicy
+
+	mu       sync.Mutex
+	started  time.Time
+	lastPoll time.Time
+	lastErr  error
+	ctl      *authrootstl.CTL
+}
+
+// NewHealth returns a Health for a daemon which polls every interval
+func NewHealth(interval time.Duration, policy *VerifyPolicy) *Health {
+	return &Health{interval: interval, policy: policy, started: time.Now()}
+}
+
+// Polled records the result of a poll.  ctl is the CTL currently in use, which may be nil.
+func (health *Health) Polled(ctl *authrootstl.CTL, err error) {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	health.lastPoll = time.Now()
+	health.lastErr = err
+	health.ctl = ctl
+}
+
+// HandleHealthz responds with 200 unless the daemon has failed to complete a poll
+// in three times the polling interval, plus the download timeout
+func (health *Health) HandleHealthz(w http.ResponseWriter, req *http.Request) {
+	health.mu.Lock()
+	lastPoll := health.lastPoll
+	if lastPoll.IsZero() {
+		lastPoll = health.started
+	}
+	health.mu.Unlock()
+
+	if since := time.Since(lastPoll); since > 3*health.interval+authrootstl.DefaultTimeout {
+		http.Error(w, fmt.Sprintf("no poll completed in %s", since.Round(time.Second)), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// HandleReadyz responds with 200 if a fresh CTL is loaded
+func (health *Health) HandleReadyz(w http.ResponseWriter, req *http.Request) {
+	health.mu.Lock()
+	ctl, lastErr := health.ctl, health.lastErr
+	health.mu.Unlock()
+
+	if ctl == nil {
+		message := "trust list has not been loaded"
+		if lastErr != nil {
+			message += ": " + lastErr.Error()
+		}
+		http.Error(w, message, http.StatusServiceUnavailable)
+		return
+	}
+	if err := ctl.CheckFreshnessWithSkew(time.Now(), health.policy.MaxAge, health.policy.Options.ClockSkew); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintf(w, "ready: sequence number %X\n", &ctl.SequenceNumber)
+}
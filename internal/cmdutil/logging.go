@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+)
+
+// logFlags registers the -log-level and -log-format flags.  After flag parsing,
+// call the returned function to install the default slog logger.
+//
+// With the text format, messages from the log package are written to stderr
+// unchanged, as they always have been.  With the JSON format, they are converted
+// to records at level ERROR, so that every line on stderr is JSON.
+func logFlags() func() {
+	level := slog.LevelInfo
+	flag.Func("log-level", "Log records at or above `LEVEL` (debug, info, warn, or error) (default info)", func(value string) error {
+		return level.UnmarshalText([]byte(value))
+	})
+	format := flag.String("log-format", "text", "Write log records in `FORMAT` (text or json)")
+	return func() {
+		options := &slog.HandlerOptions{Level: level}
+		switch *format {
+		case "text":
+			slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, options)))
+			log.SetOutput(os.Stderr)
+		case "json":
+			slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, options)))
+			slog.SetLogLoggerLevel(slog.LevelError)
+		default:
+			log.Fatalf("-log-format: unknown format %q", *format)
+		}
+	}
+}
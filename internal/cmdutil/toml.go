@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML parses a TOML configuration file into the same form as a JSON one:
+// strings are returned as string, numbers as json.Number, booleans as bool,
+// arrays as []any, and tables as map[string]any.  Dates and times, infinity, and NaN
+// are rejected, since no flag accepts them.
+func parseTOML(input string) (map[string]any, error) {
+	var config map[string]any
+	if _, err := toml.Decode(input, &config); err != nil {
+		return nil, err
+	}
+	for key, value := range config {
+		converted, err := convertTOMLValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		config[key] = converted
+	}
+	return config, nil
+}
+
+func convertTOMLValue(value any) (any, error) {
+	switch value := value.(type) {
+	case int64:
+		return json.Number(strconv.FormatInt(value, 10)), nil
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return nil, fmt.Errorf("%v is not a valid number", value)
+		}
+		return json.Number(strconv.FormatFloat(value, 'g', -1, 64)), nil
+	case time.Time:
+		return nil, fmt.Errorf("dates and times are not supported; quote the value to pass it as a string")
+	case []any:
+		for i := range value {
+			var err error
+			if value[i], err = convertTOMLValue(value[i]); err != nil {
+				return nil, err
+			}
+		}
+		return value, nil
+	case []map[string]any: // array of tables
+		converted := make([]any, len(value))
+		for i := range value {
+			var err error
+			if converted[i], err = convertTOMLValue(value[i]); err != nil {
+				return nil, err
+			}
+		}
+		return converted, nil
+	case map[string]any:
+		for key := range value {
+			var err error
+			if value[key], err = convertTOMLValue(value[key]); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package cmdutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]any
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  map[string]any{},
+		},
+		{
+			name:  "strings",
+			input: "basic = \"a \\\"b\\\" \\u00e9\"\nliteral = 'C:\\path'\nmultiline = \"\"\"\none\ntwo\"\"\"\n",
+			want:  map[string]any{"basic": `a "b" é`, "literal": `C:\path`, "multiline": "one\ntwo"},
+		},
+		{
+			name:  "numbers and booleans",
+			input: "retries = 3\nnegative = -1\nhex = 0x10\nratio = 0.5\nexponent = 1e3\nverify = true\n",
+			want: map[string]any{
+				"retries":  json.Number("3"),
+				"negative": json.Number("-1"),
+				"hex":      json.Number("16"),
+				"ratio":    json.Number("0.5"),
+				"exponent": json.Number("1000"),
+				"verify":   true,
+			},
+		},
+		{
+			name:  "arrays",
+			input: "to = [\"a@example.com\", 'b@example.com',]\nnested = [[1, 2], [\"x\"]]\nempty = []\n",
+			want: map[string]any{
+				"to":     []any{"a@example.com", "b@example.com"},
+				"nested": []any{[]any{json.Number("1"), json.Number("2")}, []any{"x"}},
+				"empty":  []any{},
+			},
+		},
+		{
+			name:  "tables",
+			input: "timeout = \"10s\"\n[msftwatch]\ninterval = \"1h\"\n[msftserve]\nlisten = \":8080\"\n",
+			want: map[string]any{
+				"timeout":   "10s",
+				"msftwatch": map[string]any{"interval": "1h"},
+				"msftserve": map[string]any{"listen": ":8080"},
+			},
+		},
+		{
+			name:  "comments",
+			input: "# leading comment\nurl = \"http://example.com/#fragment\" # trailing comment\n\n[msftwatch] # table comment\n# retries = 5\n",
+			want: map[string]any{
+				"url":       "http://example.com/#fragment",
+				"msftwatch": map[string]any{},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseTOML(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing value", "key =\n"},
+		{"missing equals", "key \"value\"\n"},
+		{"unterminated string", "key = \"value\n"},
+		{"unterminated array", "key = [1, 2\n"},
+		{"unterminated table header", "[table\n"},
+		{"duplicate key", "key = 1\nkey = 2\n"},
+		{"duplicate table", "[table]\n[table]\n"},
+		{"bare word value", "key = value\n"},
+		{"invalid escape", "key = \"\\q\"\n"},
+		{"two values on one line", "a = 1 b = 2\n"},
+		{"inf", "key = inf\n"},
+		{"negative inf", "key = -inf\n"},
+		{"nan", "key = nan\n"},
+		{"Infinity", "key = Infinity\n"},
+		{"NaN", "key = NaN\n"},
+		{"nan in array", "key = [1, nan]\n"},
+		{"nan in table", "[table]\nkey = nan\n"},
+		{"leading zero", "key = 01\n"},
+		{"date", "key = 2025-01-01\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, err := parseTOML(test.input); err == nil {
+				t.Errorf("parseTOML(%q) = %#v, want error", test.input, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package ctljson defines the JSON representation of CTLs used by the commands
+package ctljson
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// CTL summarizes a CTL, without its entries
+type CTL struct {
+	Version        int        `json:"version"`
+	Kind           string     `json:"kind"` // authroot, disallowed, pinrules, or unknown
+	SubjectUsage   []string   `json:"subject_usage"`
+	SequenceNumber string     `json:"sequence_number"` // hex
+	EffectiveDate  time.Time  `json:"effective_date"`
+	NextUpdate     *time.Time `json:"next_update,omitempty"`
+	Entries        int        `json:"entries"`
+	CTLogs         int        `json:"ct_logs"`
+	ContentDigest  string     `json:"content_digest"` // hex CTL.Digest
+}
+
+// Entry is an entry of a CTL
+type Entry struct {
+	SHA1           string     `json:"sha1"`
+	SHA256         string     `json:"sha256,omitempty"`
+	FriendlyName   string     `json:"friendly_name"`
+	EKUs           []string   `json:"ekus"`
+	DisallowedDate *time.Time `json:"disallowed_date,omitempty"`
+	DisallowedEKUs []string   `json:"disallowed_ekus,omitempty"`
+	NotBeforeDate  *time.Time `json:"not_before_date,omitempty"`
+	NotBeforeEKUs  []string   `json:"not_before_ekus,omitempty"`
+}
+
+// Log is a CT log recognized by Microsoft
+type Log struct {
+	LogID []byte `json:"log_id"`
+	Key   []byte `json:"key"`
+}
+
+// NewCTL returns the summary of ctl
+func NewCTL(ctl *authrootstl.CTL) CTL {
+	digest := ctl.Digest()
+	return CTL{
+		Version:        ctl.Version,
+		Kind:           ctl.Kind().String(),
+		SubjectUsage:   OIDStrings(ctl.SubjectUsage),
+		SequenceNumber: fmt.Sprintf("%X", &ctl.SequenceNumber),
+		EffectiveDate:  ctl.EffectiveDate,
+		NextUpdate:     OptionalTime(ctl.NextUpdate),
+		Entries:        len(ctl.Entries),
+		CTLogs:         len(ctl.CTLogs),
+		ContentDigest:  hex.EncodeToString(digest[:]),
+	}
+}
+
+// NewEntry returns the JSON representation of entry
+func NewEntry(entry *authrootstl.Entry) Entry {
+	return Entry{
+		SHA1:           hex.EncodeToString(entry.SubjectIdentifier),
+		SHA256:         SHA256Hex(entry.SHA256),
+		FriendlyName:   entry.FriendlyName,
+		EKUs:           OIDStrings(entry.EKUs),
+		DisallowedDate: OptionalTime(entry.DisallowedDate),
+		DisallowedEKUs: OIDStrings(entry.DisallowedEKUs),
+		NotBeforeDate:  OptionalTime(entry.NotBeforeDate),
+		NotBeforeEKUs:  OIDStrings(entry.NotBeforeEKUs),
+	}
+}
+
+// NewEntries returns the JSON representation of entries, which is never nil
+func NewEntries(entries []authrootstl.Entry) []Entry {
+	jsonEntries := []Entry{}
+	for i := range entries {
+		jsonEntries = append(jsonEntries, NewEntry(&entries[i]))
+	}
+	return jsonEntries
+}
+
+// NewLogs returns the JSON representation of the given log SPKIs, which is never nil
+func NewLogs(logKeys []authrootstl.CTLogKey) []Log {
+	logs := []Log{}
+	for _, logKey := range logKeys {
+		logID := logKey.LogID()
+		logs = append(logs, Log{LogID: logID[:], Key: logKey})
+	}
+	return logs
+}
+
+// OIDStrings returns the dotted string form of each OID, and is never nil
+func OIDStrings(oids []asn1.ObjectIdentifier) []string {
+	strs := []string{}
+	for _, oid := range oids {
+		strs = append(strs, oid.String())
+	}
+	return strs
+}
+
+// OptionalTime returns nil if t is zero, and &t otherwise
+func OptionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// SHA256Hex returns the hex form of fingerprint, or the empty string if it is zero
+func SHA256Hex(fingerprint authrootstl.SHA256Fingerprint) string {
+	if fingerprint.IsZero() {
+		return ""
+	}
+	return fingerprint.Hex()
+}
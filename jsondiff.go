@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// JSONDiffRoot is the JSON representation of a root in a JSONDiff
+type JSONDiffRoot struct {
+	SHA1         string   `json:"sha1"`
+	SHA256       string   `json:"sha256,omitempty"`
+	FriendlyName string   `json:"friendly_name"`
+	Changes      []string `json:"changes,omitempty"`
+
+	// Set by Annotate
+	FirstSeen   time.Time `json:"first_seen,omitzero"`
+	LastChanged time.Time `json:"last_changed,omitzero"`
+}
+
+// JSONDiffLog is the JSON representation of a CT log in a JSONDiff
+type JSONDiffLog struct {
+	LogID     []byte    `json:"log_id"`
+	Key       []byte    `json:"key"`
+	FirstSeen time.Time `json:"first_seen,omitzero"` // set by Annotate
+}
+
+// JSONDiff is the JSON representation of a CTLDiff, as output by stldiff -json
+// and recorded in a history.  Sequence numbers and SHA-1 and SHA-256 hashes are
+// hex-encoded, and log IDs and keys are base64-encoded.
+type JSONDiff struct {
+	OldSequenceNumber string         `json:"old_sequence_number"`
+	NewSequenceNumber string         `json:"new_sequence_number"`
+	OldEffectiveDate  time.Time      `json:"old_effective_date"`
+	NewEffectiveDate  time.Time      `json:"new_effective_date"`
+	AddedRoots        []JSONDiffRoot `json:"added_roots"`
+	RemovedRoots      []JSONDiffRoot `json:"removed_roots"`
+	ChangedRoots      []JSONDiffRoot `json:"changed_roots"`
+	AddedCTLogs       []JSONDiffLog  `json:"added_ct_logs"`
+	RemovedCTLogs     []JSONDiffLog  `json:"removed_ct_logs"`
+}
+
+// JSON returns the JSON representation of the diff
+func (diff *CTLDiff) JSON() JSONDiff {
+	output := JSONDiff{
+		OldSequenceNumber: fmt.Sprintf("%X", diff.OldSequenceNumber),
+		NewSequenceNumber: fmt.Sprintf("%X", diff.NewSequenceNumber),
+		OldEffectiveDate:  diff.OldEffectiveDate,
+		NewEffectiveDate:  diff.NewEffectiveDate,
+		AddedRoots:        jsonDiffRoots(diff.AddedRoots),
+		RemovedRoots:      jsonDiffRoots(diff.RemovedRoots),
+		ChangedRoots:      []JSONDiffRoot{},
+		AddedCTLogs:       jsonDiffLogs(diff.AddedCTLogs),
+		RemovedCTLogs:     jsonDiffLogs(diff.RemovedCTLogs),
+	}
+	for _, change := range diff.ChangedRoots {
+		root := newJSONDiffRoot(&change.New)
+		root.Changes = change.Changes
+		output.ChangedRoots = append(output.ChangedRoots, root)
+	}
+	return output
+}
+
+func newJSONDiffRoot(entry *Entry) JSONDiffRoot {
+	root := JSONDiffRoot{
+		SHA1:         hex.EncodeToString(entry.SubjectIdentifier),
+		FriendlyName: entry.FriendlyName,
+	}
+	if !entry.SHA256.IsZero() {
+		root.SHA256 = entry.SHA256.Hex()
+	}
+	return root
+}
+
+func jsonDiffRoots(entries []Entry) []JSONDiffRoot {
+	roots := []JSONDiffRoot{}
+	for i := range entries {
+		roots = append(roots, newJSONDiffRoot(&entries[i]))
+	}
+	return roots
+}
+
+func jsonDiffLogs(logKeys []CTLogKey) []JSONDiffLog {
+	logs := []JSONDiffLog{}
+	for _, logKey := range logKeys {
+		logID := logKey.LogID()
+		logs = append(logs, JSONDiffLog{LogID: logID[:], Key: logKey})
+	}
+	return logs
+}
+
+// Annotate sets the FirstSeen and LastChanged fields of the roots and CT logs in
+// the diff from lineage
+func (output *JSONDiff) Annotate(lineage *Lineage) {
+	for _, roots := range [][]JSONDiffRoot{output.AddedRoots, output.RemovedRoots, output.ChangedRoots} {
+		for i := range roots {
+			subjectIdentifier, err := hex.DecodeString(roots[i].SHA1)
+			if err != nil {
+				continue
+			}
+			if appearance, ok := lineage.Root(subjectIdentifier); ok {
+				roots[i].FirstSeen, roots[i].LastChanged = appearance.FirstSeen, appearance.LastChanged
+			}
+		}
+	}
+	for _, logs := range [][]JSONDiffLog{output.AddedCTLogs, output.RemovedCTLogs} {
+		for i := range logs {
+			if appearance, ok := lineage.CTLog(logs[i].Key); ok {
+				logs[i].FirstSeen = appearance.FirstSeen
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// RootKeyInfo describes the key, signature algorithm, and validity period of a root
+type RootKeyInfo struct {
+	Entry              *Entry
+	KeyAlgorithm       x509.PublicKeyAlgorithm
+	KeySize            int    // in bits; for ECDSA, the size of the curve
+	Curve              string // for ECDSA, the name of the curve
+	SignatureAlgorithm x509.SignatureAlgorithm
+	NotBefore          time.Time
+	NotAfter           time.Time
+}
+
+// Key returns a short description of the key, such as "RSA-2048" or "ECDSA-P-384"
+func (info *RootKeyInfo) Key() string {
+	switch info.KeyAlgorithm {
+	case x509.RSA:
+		return fmt.Sprintf("RSA-%d", info.KeySize)
+	case x509.ECDSA:
+		return "ECDSA-" + info.Curve
+	default:
+		return info.KeyAlgorithm.String()
+	}
+}
+
+// ValidityYears returns the length of the validity period in whole years
+func (info *RootKeyInfo) ValidityYears() int {
+	return int(info.NotAfter.Sub(info.NotBefore) / (365*24*time.Hour + 6*time.Hour))
+}
+
+// KeyReport summarizes the keys, signature algorithms, and validity periods of a set of roots
+type KeyReport struct {
+	Roots               []RootKeyInfo  // in the order of the roots passed to NewKeyReport, omitting unparseable certificates
+	Keys                map[string]int // number of roots with each key, keyed by RootKeyInfo.Key
+	SignatureAlgorithms map[string]int // number of roots with each signature algorithm
+	ValidityYears       map[int]int    // number of roots with each validity period, keyed by RootKeyInfo.ValidityYears
+	Unparseable         []*Entry       // roots whose certificates couldn't be parsed
+}
+
+// NewKeyReport analyzes the given roots
+func NewKeyReport(roots []ExportRoot) *KeyReport {
+	report := &KeyReport{
+		Keys:                make(map[string]int),
+		SignatureAlgorithms: make(map[string]int),
+		ValidityYears:       make(map[int]int),
+	}
+	for _, root := range roots {
+		cert, err := x509.ParseCertificate(root.Certificate)
+		if err != nil {
+			report.Unparseable = append(report.Unparseable, root.Entry)
+			continue
+		}
+		info := RootKeyInfo{
+			Entry:              root.Entry,
+			KeyAlgorithm:       cert.PublicKeyAlgorithm,
+			SignatureAlgorithm: cert.SignatureAlgorithm,
+			NotBefore:          cert.NotBefore,
+			NotAfter:           cert.NotAfter,
+		}
+		switch key := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			info.KeySize = key.N.BitLen()
+		case *ecdsa.PublicKey:
+			info.KeySize = key.Curve.Params().BitSize
+			info.Curve = key.Curve.Params().Name
+		case ed25519.PublicKey:
+			info.KeySize = 256
+		}
+		report.Roots = append(report.Roots, info)
+		report.Keys[info.Key()]++
+		report.SignatureAlgorithms[info.SignatureAlgorithm.String()]++
+		report.ValidityYears[info.ValidityYears()]++
+	}
+	return report
+}
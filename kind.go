@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+)
+
+// CTLKind identifies which of Microsoft's trust lists a CTL is
+type CTLKind int
+
+const (
+	// A CTL with an unrecognized subject usage
+	UnknownCTL CTLKind = iota
+	// The trusted roots, from authroot.stl
+	AuthrootCTL
+	// The explicitly distrusted certificates, from disallowedcert.stl
+	DisallowedCTL
+	// The certificate pinning rules, from pinrules.stl
+	PinRulesCTL
+)
+
+var (
+	oidRootListSigner = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 9}
+	oidDisallowedList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 30}
+	oidPinRulesCTL    = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 32}
+)
+
+func (kind CTLKind) String() string {
+	switch kind {
+	case AuthrootCTL:
+		return "authroot"
+	case DisallowedCTL:
+		return "disallowed"
+	case PinRulesCTL:
+		return "pinrules"
+	default:
+		return "unknown"
+	}
+}
+
+// Kind returns the kind of trust list, as determined by its subject usage
+func (ctl *CTL) Kind() CTLKind {
+	for _, usage := range ctl.SubjectUsage {
+		switch {
+		case usage.Equal(oidRootListSigner):
+			return AuthrootCTL
+		case usage.Equal(oidDisallowedList):
+			return DisallowedCTL
+		case usage.Equal(oidPinRulesCTL):
+			return PinRulesCTL
+		}
+	}
+	return UnknownCTL
+}
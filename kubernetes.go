@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KubernetesBundleAnnotation is the annotation containing the hex-encoded SHA-256
+// hash of the PEM bundle, which can be used to trigger a rollout when it changes
+const KubernetesBundleAnnotation = "authrootstl.sslmate.com/bundle-sha256"
+
+// KubernetesOptions configures ExportKubernetes
+type KubernetesOptions struct {
+	Kind      string // "ConfigMap" or "Secret"
+	Name      string
+	Namespace string // omitted if empty
+	Key       string // key under which the bundle is stored; if empty, "ca-certificates.crt" is used
+}
+
+// ExportKubernetes writes a YAML manifest for a ConfigMap or Secret containing the
+// roots as a PEM bundle (as written by ExportPEM), annotated with the bundle's hash
+func ExportKubernetes(w io.Writer, roots []ExportRoot, opts KubernetesOptions) error {
+	if opts.Kind != "ConfigMap" && opts.Kind != "Secret" {
+		return fmt.Errorf("unsupported Kubernetes kind %q", opts.Kind)
+	}
+	if opts.Name == "" {
+		return fmt.Errorf("a name is required for the %s", opts.Kind)
+	}
+	key := opts.Key
+	if key == "" {
+		key = "ca-certificates.crt"
+	}
+	var bundle bytes.Buffer
+	if err := ExportPEM(&bundle, roots); err != nil {
+		return err
+	}
+	hash := sha256.Sum256(bundle.Bytes())
+
+	var buf bytes.Buffer
+	buf.WriteString("# Root certificates trusted by Microsoft, generated by software.sslmate.com/src/authrootstl\n")
+	buf.WriteString("apiVersion: v1\n")
+	fmt.Fprintf(&buf, "kind: %s\n", opts.Kind)
+	buf.WriteString("metadata:\n")
+	fmt.Fprintf(&buf, "  name: %s\n", strconv.Quote(opts.Name))
+	if opts.Namespace != "" {
+		fmt.Fprintf(&buf, "  namespace: %s\n", strconv.Quote(opts.Namespace))
+	}
+	buf.WriteString("  annotations:\n")
+	fmt.Fprintf(&buf, "    %s: %s\n", KubernetesBundleAnnotation, strconv.Quote(hex.EncodeToString(hash[:])))
+	if opts.Kind == "Secret" {
+		buf.WriteString("type: Opaque\n")
+		buf.WriteString("data:\n")
+		fmt.Fprintf(&buf, "  %s: %s\n", strconv.Quote(key), base64.StdEncoding.EncodeToString(bundle.Bytes()))
+	} else {
+		buf.WriteString("data:\n")
+		fmt.Fprintf(&buf, "  %s: |\n", strconv.Quote(key))
+		for line := range strings.Lines(bundle.String()) {
+			buf.WriteString("    ")
+			buf.WriteString(line)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
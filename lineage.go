@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"math/big"
+	"time"
+)
+
+// Appearance records when a root or CT log first appeared in the CTLs in a
+// HistoryStore and, for a root, when its entry last changed.  Times are the
+// effective dates of the CTLs concerned.
+type Appearance struct {
+	FirstSeen         time.Time
+	FirstSeenSequence *big.Int
+
+	// LastChanged is when the root's entry last changed, or when it was last
+	// re-added after being removed.  It is zero if neither has happened.
+	LastChanged         time.Time
+	LastChangedSequence *big.Int
+}
+
+// Lineage records the Appearance of every root and CT log in a HistoryStore
+type Lineage struct {
+	roots  map[string]*Appearance   // keyed by subject identifier
+	ctLogs map[[32]byte]*Appearance // keyed by log ID
+}
+
+// LoadLineage replays the diffs in store to find when each root and CT log first
+// appeared and when each root last changed
+func LoadLineage(ctx context.Context, store HistoryStore) (*Lineage, error) {
+	diffs, err := store.DiffRange(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	lineage := &Lineage{
+		roots:  make(map[string]*Appearance),
+		ctLogs: make(map[[32]byte]*Appearance),
+	}
+	for _, diff := range diffs {
+		for _, entry := range diff.AddedRoots {
+			if appearance, ok := lineage.roots[string(entry.SubjectIdentifier)]; ok {
+				appearance.LastChanged, appearance.LastChangedSequence = diff.NewEffectiveDate, diff.NewSequenceNumber
+			} else {
+				lineage.roots[string(entry.SubjectIdentifier)] = &Appearance{FirstSeen: diff.NewEffectiveDate, FirstSeenSequence: diff.NewSequenceNumber}
+			}
+		}
+		for _, change := range diff.ChangedRoots {
+			if appearance, ok := lineage.roots[string(change.New.SubjectIdentifier)]; ok {
+				appearance.LastChanged, appearance.LastChangedSequence = diff.NewEffectiveDate, diff.NewSequenceNumber
+			}
+		}
+		for _, logKey := range diff.AddedCTLogs {
+			if _, ok := lineage.ctLogs[logKey.LogID()]; !ok {
+				lineage.ctLogs[logKey.LogID()] = &Appearance{FirstSeen: diff.NewEffectiveDate, FirstSeenSequence: diff.NewSequenceNumber}
+			}
+		}
+	}
+	return lineage, nil
+}
+
+// Root returns the Appearance of the root with the given subject identifier
+func (lineage *Lineage) Root(subjectIdentifier []byte) (Appearance, bool) {
+	appearance, ok := lineage.roots[string(subjectIdentifier)]
+	if !ok {
+		return Appearance{}, false
+	}
+	return *appearance, true
+}
+
+// CTLog returns the Appearance of the given CT log
+func (lineage *Lineage) CTLog(logKey CTLogKey) (Appearance, bool) {
+	appearance, ok := lineage.ctLogs[logKey.LogID()]
+	if !ok {
+		return Appearance{}, false
+	}
+	return *appearance, true
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Lint checks root certificates for a problem.  Check returns a description
+// of the problem, or the empty string if the certificate doesn't have it.
+type Lint struct {
+	Name        string
+	Description string
+	Check       func(cert *x509.Certificate, entry *Entry, at time.Time) string
+}
+
+// LintFinding is a problem found by a lint
+type LintFinding struct {
+	Lint   string `json:"lint"` // name of the lint, or "parse" if the certificate couldn't be parsed
+	Detail string `json:"detail"`
+}
+
+// LintResult lists the problems found with one root
+type LintResult struct {
+	Entry    *Entry
+	Findings []LintFinding
+}
+
+// DefaultLints are the lints provided by this package.  They check for problems
+// which matter for a root certificate, and are not zlint's lints: this package
+// does not depend on zlint or zcrypto, and none of these names is a zlint lint.
+// To run zlint, supply a Lint whose Check runs whichever zlint lint you choose on
+// cert.Raw.
+var DefaultLints = []Lint{
+	{
+		Name:        "weak_signature",
+		Description: "Certificate is signed using MD5 or SHA-1",
+		Check: func(cert *x509.Certificate, entry *Entry, at time.Time) string {
+			switch cert.SignatureAlgorithm {
+			case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+				return cert.SignatureAlgorithm.String()
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "weak_key",
+		Description: "Public key is RSA with fewer than 2048 bits, or ECDSA with fewer than 256 bits",
+		Check: func(cert *x509.Certificate, entry *Entry, at time.Time) string {
+			switch key := cert.PublicKey.(type) {
+			case *rsa.PublicKey:
+				if bits := key.N.BitLen(); bits < 2048 {
+					return fmt.Sprintf("%d-bit RSA", bits)
+				}
+			case *ecdsa.PublicKey:
+				if bits := key.Curve.Params().BitSize; bits < 256 {
+					return fmt.Sprintf("%d-bit ECDSA", bits)
+				}
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "expired",
+		Description: "Certificate has expired but is still trusted",
+		Check: func(cert *x509.Certificate, entry *Entry, at time.Time) string {
+			if at.After(cert.NotAfter) && NotDisallowedAt(at)(entry) {
+				return "expired " + cert.NotAfter.Format(time.DateOnly)
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "not_ca",
+		Description: "Certificate lacks a basic constraints extension asserting that it is a CA",
+		Check: func(cert *x509.Certificate, entry *Entry, at time.Time) string {
+			if !cert.BasicConstraintsValid {
+				return "no basic constraints"
+			} else if !cert.IsCA {
+				return "cA is false"
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "no_cert_sign",
+		Description: "Certificate has a key usage extension without keyCertSign",
+		Check: func(cert *x509.Certificate, entry *Entry, at time.Time) string {
+			if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+				return fmt.Sprintf("key usage is %#x", int(cert.KeyUsage))
+			}
+			return ""
+		},
+	},
+	{
+		Name:        "not_self_signed",
+		Description: "Certificate's issuer differs from its subject",
+		Check: func(cert *x509.Certificate, entry *Entry, at time.Time) string {
+			if string(cert.RawIssuer) != string(cert.RawSubject) {
+				return "issued by " + cert.Issuer.String()
+			}
+			return ""
+		},
+	},
+}
+
+// LookupLint returns the lint in DefaultLints with the given name
+func LookupLint(name string) (Lint, bool) {
+	i := slices.IndexFunc(DefaultLints, func(lint Lint) bool { return lint.Name == name })
+	if i == -1 {
+		return Lint{}, false
+	}
+	return DefaultLints[i], true
+}
+
+// RunLints runs each lint over each root as of the given time and returns the
+// results for the roots with at least one finding, in the order of roots
+func RunLints(roots []ExportRoot, lints []Lint, at time.Time) []LintResult {
+	var results []LintResult
+	for _, root := range roots {
+		var findings []LintFinding
+		if cert, err := x509.ParseCertificate(root.Certificate); err != nil {
+			findings = append(findings, LintFinding{Lint: "parse", Detail: err.Error()})
+		} else {
+			for _, lint := range lints {
+				if detail := lint.Check(cert, root.Entry, at); detail != "" {
+					findings = append(findings, LintFinding{Lint: lint.Name, Detail: detail})
+				}
+			}
+		}
+		if len(findings) > 0 {
+			results = append(results, LintResult{Entry: root.Entry, Findings: findings})
+		}
+	}
+	return results
+}
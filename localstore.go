@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/sha1"
+)
+
+// LocalStoreComparison is the result of comparing a CTL to the certificates in a
+// local certificate store, such as a Windows machine's AuthRoot or Disallowed store
+type LocalStoreComparison struct {
+	OnlyLocal [][]byte // DER-encoded certificates in the local store but not in the CTL
+	OnlyCTL   []Entry  // entries in the CTL whose certificates are not in the local store
+}
+
+// CompareLocalCertificates compares the entries in the CTL to the DER-encoded
+// certificates in a local store, matching them by SHA-1 hash.  Windows adds roots
+// to its AuthRoot store only when they are first needed, so OnlyCTL is normally
+// large; OnlyLocal is more interesting, since it lists roots which Windows trusts
+// even though Microsoft no longer publishes them.
+func CompareLocalCertificates(ctl *CTL, certificates [][]byte) *LocalStoreComparison {
+	comparison := new(LocalStoreComparison)
+	local := make(map[SHA1Fingerprint]bool, len(certificates))
+	for _, cert := range certificates {
+		fingerprint := SHA1Fingerprint(sha1.Sum(cert))
+		local[fingerprint] = true
+		if ctl.FindBySHA1(fingerprint) == nil {
+			comparison.OnlyLocal = append(comparison.OnlyLocal, cert)
+		}
+	}
+	for _, entry := range ctl.Entries {
+		if !local[entry.SHA1] {
+			comparison.OnlyCTL = append(comparison.OnlyCTL, entry)
+		}
+	}
+	return comparison
+}
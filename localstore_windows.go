@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// cryptENotFound is CRYPT_E_NOT_FOUND, which ends the enumeration of a store
+const cryptENotFound = syscall.Errno(0x80092004)
+
+// ReadSystemStore returns the DER-encoded certificates in the named system
+// certificate store, such as "AuthRoot" or "Disallowed", as seen by the current
+// user (which includes the local machine's certificates)
+func ReadSystemStore(name string) ([][]byte, error) {
+	storeName, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	store, err := syscall.CertOpenSystemStore(0, storeName)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s store: %w", name, err)
+	}
+	defer syscall.CertCloseStore(store, 0)
+
+	var certificates [][]byte
+	var context *syscall.CertContext
+	for {
+		context, err = syscall.CertEnumCertificatesInStore(store, context)
+		if context == nil {
+			if errors.Is(err, cryptENotFound) {
+				return certificates, nil
+			}
+			return nil, fmt.Errorf("error enumerating %s store: %w", name, err)
+		}
+		encoded := unsafe.Slice(context.EncodedCert, context.Length)
+		certificates = append(certificates, bytes.Clone(encoded))
+	}
+}
+
+// CompareSystemStore compares the CTL to the certificates in the named system
+// certificate store, as CompareLocalCertificates does.  Compare the authroot CTL
+// to the "AuthRoot" store and the disallowed CTL to the "Disallowed" store.
+func CompareSystemStore(ctl *CTL, name string) (*LocalStoreComparison, error) {
+	certificates, err := ReadSystemStore(name)
+	if err != nil {
+		return nil, err
+	}
+	return CompareLocalCertificates(ctl, certificates), nil
+}
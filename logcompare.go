@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"slices"
+)
+
+// CTLogsComparison is the result of comparing the CT logs recognized by Microsoft to a log list
+type CTLogsComparison struct {
+	OnlyMicrosoft []CTLogKey // logs recognized by Microsoft but absent from the list
+	OnlyList      []KnownLog // logs in the list which are not recognized by Microsoft
+	Both          []KnownLog // logs in the list which are also recognized by Microsoft
+}
+
+// CompareCTLogs compares the CT logs recognized by Microsoft to the given log list.
+// Logs are matched by log ID (the SHA-256 hash of the SPKI).
+func CompareCTLogs(ctl *CTL, list []KnownLog) *CTLogsComparison {
+	comparison := new(CTLogsComparison)
+	microsoftLogs := make(map[[32]byte]bool)
+	for _, key := range ctl.CTLogs {
+		microsoftLogs[key.LogID()] = true
+	}
+	listedLogs := make(map[[32]byte]bool)
+	for _, log := range list {
+		listedLogs[log.LogID] = true
+		if microsoftLogs[log.LogID] {
+			comparison.Both = append(comparison.Both, log)
+		} else {
+			comparison.OnlyList = append(comparison.OnlyList, log)
+		}
+	}
+	for _, key := range ctl.CTLogs {
+		if !listedLogs[key.LogID()] {
+			comparison.OnlyMicrosoft = append(comparison.OnlyMicrosoft, key)
+		}
+	}
+	return comparison
+}
+
+// CTLogMembership records which log lists contain a particular CT log
+type CTLogMembership struct {
+	LogID     [32]byte
+	Key       []byte
+	Microsoft bool                // whether the log is recognized by Microsoft
+	Lists     map[string]KnownLog // the log as described by each list that contains it, keyed by list name
+}
+
+// CompareCTLogLists compares the CT logs recognized by Microsoft to several log lists at once,
+// keyed by name (e.g. "chrome" and "apple").  Every log which is recognized by Microsoft or
+// present in at least one list is returned exactly once.  Logs recognized by Microsoft come
+// first, in CTL order, followed by the remaining logs in order of list name.
+func CompareCTLogLists(ctl *CTL, lists map[string][]KnownLog) []CTLogMembership {
+	var memberships []CTLogMembership
+	index := make(map[[32]byte]int)
+	for _, key := range ctl.CTLogs {
+		logID := key.LogID()
+		if _, exists := index[logID]; exists {
+			continue
+		}
+		index[logID] = len(memberships)
+		memberships = append(memberships, CTLogMembership{
+			LogID:     logID,
+			Key:       key,
+			Microsoft: true,
+			Lists:     make(map[string]KnownLog),
+		})
+	}
+	listNames := make([]string, 0, len(lists))
+	for name := range lists {
+		listNames = append(listNames, name)
+	}
+	slices.Sort(listNames)
+	for _, name := range listNames {
+		for _, log := range lists[name] {
+			i, exists := index[log.LogID]
+			if !exists {
+				i = len(memberships)
+				index[log.LogID] = i
+				memberships = append(memberships, CTLogMembership{
+					LogID: log.LogID,
+					Key:   log.Key,
+					Lists: make(map[string]KnownLog),
+				})
+			}
+			memberships[i].Lists[name] = log
+		}
+	}
+	return memberships
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// ChromeLogListURL is the location of Chrome's v3 CT log list
+const ChromeLogListURL = "https://www.gstatic.com/ct/log_list/v3/log_list.json"
+
+// AppleLogListURL is the location of Apple's current CT log list
+const AppleLogListURL = "https://valid.apple.com/ct/log_list/current_log_list.json"
+
+// KnownLog is a CT log as described by a log list published by a root program
+type KnownLog struct {
+	LogID         [32]byte
+	Key           []byte // DER-encoded SubjectPublicKeyInfo
+	Description   string
+	Operator      string
+	URL           string // submission URL
+	MonitoringURL string // only set for static-ct-api logs
+	State         string // e.g. "usable", "readonly", "retired"
+}
+
+// v3LogList is a log list in the v3 JSON format, which both Chrome and Apple publish
+type v3LogList struct {
+	Operators []struct {
+		Name      string  `json:"name"`
+		Logs      []v3Log `json:"logs"`
+		TiledLogs []v3Log `json:"tiled_logs"`
+	} `json:"operators"`
+}
+
+type v3Log struct {
+	Description   string                     `json:"description"`
+	LogID         []byte                     `json:"log_id"`
+	Key           []byte                     `json:"key"`
+	URL           string                     `json:"url"`
+	SubmissionURL string                     `json:"submission_url"`
+	MonitoringURL string                     `json:"monitoring_url"`
+	State         map[string]json.RawMessage `json:"state"`
+}
+
+// ParseChromeLogList parses a log list in Chrome's v3 JSON format
+func ParseChromeLogList(data []byte) ([]KnownLog, error) {
+	return parseV3LogList(data, "Chrome")
+}
+
+// ParseAppleLogList parses a log list in the JSON format used by Apple's current_log_list.json,
+// which is the same as Chrome's v3 format
+func ParseAppleLogList(data []byte) ([]KnownLog, error) {
+	return parseV3LogList(data, "Apple")
+}
+
+func parseV3LogList(data []byte, program string) ([]KnownLog, error) {
+	var list v3LogList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error parsing %s log list: %w", program, err)
+	}
+	var logs []KnownLog
+	for _, operator := range list.Operators {
+		for _, log := range slices.Concat(operator.Logs, operator.TiledLogs) {
+			// RFC 6962 logs have a url; static-ct-api logs have submission and monitoring URLs
+			url := log.URL
+			if url == "" {
+				url = log.SubmissionURL
+			}
+			knownLog, err := newKnownLog(operator.Name, log.Description, log.LogID, log.Key, url, log.MonitoringURL, stateName(log.State))
+			if err != nil {
+				return nil, err
+			}
+			logs = append(logs, knownLog)
+		}
+	}
+	return logs, nil
+}
+
+func newKnownLog(operator, description string, logID, key []byte, url, monitoringURL, state string) (KnownLog, error) {
+	knownLog := KnownLog{
+		LogID:         sha256.Sum256(key),
+		Key:           key,
+		Description:   description,
+		Operator:      operator,
+		URL:           url,
+		MonitoringURL: monitoringURL,
+		State:         state,
+	}
+	if len(key) == 0 {
+		return KnownLog{}, fmt.Errorf("log %q has no key", description)
+	}
+	if logID != nil && !bytes.Equal(logID, knownLog.LogID[:]) {
+		return KnownLog{}, fmt.Errorf("log %q has a log ID which does not match its key", description)
+	}
+	return knownLog, nil
+}
+
+func stateName(state map[string]json.RawMessage) string {
+	for name := range state {
+		return name
+	}
+	return ""
+}
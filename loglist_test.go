@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func TestParseV3LogList(t *testing.T) {
+	key1, key2 := []byte("key one"), []byte("key two")
+	logID1 := sha256.Sum256(key1)
+	list := fmt.Sprintf(`{"operators": [{"name": "Operator",
+		"logs": [{"description": "RFC 6962", "log_id": %q, "key": %q, "url": "https://ct.example/", "state": {"usable": {}}}],
+		"tiled_logs": [{"description": "Tiled", "key": %q, "submission_url": "https://submit.example/", "monitoring_url": "https://monitor.example/", "state": {"readonly": {}}}]
+	}]}`, base64.StdEncoding.EncodeToString(logID1[:]), base64.StdEncoding.EncodeToString(key1), base64.StdEncoding.EncodeToString(key2))
+
+	want := []KnownLog{
+		{LogID: logID1, Key: key1, Description: "RFC 6962", Operator: "Operator", URL: "https://ct.example/", State: "usable"},
+		{LogID: sha256.Sum256(key2), Key: key2, Description: "Tiled", Operator: "Operator", URL: "https://submit.example/", MonitoringURL: "https://monitor.example/", State: "readonly"},
+	}
+	for name, parse := range map[string]func([]byte) ([]KnownLog, error){"Chrome": ParseChromeLogList, "Apple": ParseAppleLogList} {
+		logs, err := parse([]byte(list))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if fmt.Sprint(logs) != fmt.Sprint(want) {
+			t.Errorf("%s: got %v, want %v", name, logs, want)
+		}
+	}
+
+	mismatched := fmt.Sprintf(`{"operators": [{"name": "Operator", "logs": [{"description": "Bad", "log_id": %q, "key": %q}]}]}`,
+		base64.StdEncoding.EncodeToString(logID1[:]), base64.StdEncoding.EncodeToString(key2))
+	if _, err := ParseChromeLogList([]byte(mismatched)); err == nil {
+		t.Error("log ID not matching the key was accepted")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+)
+
+// MicrosoftRootSHA256 is the SHA-256 fingerprint of Microsoft Root Certificate
+// Authority 2010, the root of the certificates which sign Microsoft's trust lists
+// and their timestamps.  It is the trust anchor used when VerifyOptions.Roots is nil.
+var MicrosoftRootSHA256 = SHA256Fingerprint{
+	0xdf, 0x54, 0x5b, 0xf9, 0x19, 0xa2, 0x43, 0x9c, 0x36, 0x98, 0x3b, 0x54, 0xcd, 0xfc, 0x90, 0x3d,
+	0xfa, 0x4f, 0x37, 0xd3, 0x99, 0x6d, 0x8d, 0x84, 0xb4, 0xc3, 0x1e, 0xec, 0x6f, 0x3c, 0x16, 0x3e,
+}
+
+// microsoftRootSHA1 is the SHA-1 fingerprint of the same certificate, which
+// names the file containing it on Microsoft's CDN
+var microsoftRootSHA1 = SHA1Fingerprint{
+	0x3b, 0x1e, 0xfd, 0x3a, 0x66, 0xea, 0x28, 0xb1, 0x66, 0x97,
+	0x39, 0x47, 0x03, 0xa7, 0x2c, 0xa3, 0x40, 0xa0, 0x5b, 0xd5,
+}
+
+// MicrosoftRootPool returns a pool containing the certificate in der, which must
+// be Microsoft's root certificate (see MicrosoftRootSHA256)
+func MicrosoftRootPool(der []byte) (*x509.CertPool, error) {
+	if sha256.Sum256(der) != MicrosoftRootSHA256 {
+		return nil, fmt.Errorf("certificate is not Microsoft's root (SHA-256 %x)", sha256.Sum256(der))
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool, nil
+}
+
+// microsoftRootFrom returns a pool containing Microsoft's root certificate if it is among certificates
+func microsoftRootFrom(certificates []*x509.Certificate) *x509.CertPool {
+	for _, cert := range certificates {
+		if sha256.Sum256(cert.Raw) == MicrosoftRootSHA256 {
+			pool := x509.NewCertPool()
+			pool.AddCert(cert)
+			return pool
+		}
+	}
+	return nil
+}
+
+// FetchMicrosoftRoot downloads Microsoft's root certificate, which is published
+// alongside the roots it lists, and returns a pool containing it.  The certificate is
+// checked against MicrosoftRootSHA256, so it may come from an untrusted mirror.
+// The result is remembered, so later calls do not download it again.
+func (client *Client) FetchMicrosoftRoot(ctx context.Context) (*x509.CertPool, error) {
+	client.microsoftRootMu.Lock()
+	defer client.microsoftRootMu.Unlock()
+	if client.microsoftRoot != nil {
+		return client.microsoftRoot, nil
+	}
+	der, err := client.Fetch(ctx, fmt.Sprintf("%X.crt", microsoftRootSHA1[:]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoMicrosoftRoot, err)
+	}
+	pool, err := MicrosoftRootPool(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoMicrosoftRoot, err)
+	}
+	client.microsoftRoot = pool
+	return pool, nil
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/csv"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var mozillaPurposes = []asn1.ObjectIdentifier{oidServerAuth, oidEmailProtection}
+
+type certdataObject map[string]string
+
+// ParseMozillaCertdata parses Mozilla's root store from NSS's certdata.txt file.
+// Only certificates which are trusted as a delegator for at least one purpose are included.
+func ParseMozillaCertdata(r io.Reader) (*RootStore, error) {
+	objects, err := parseCertdataObjects(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certdata.txt: %w", err)
+	}
+	trustObjects := make(map[[20]byte]certdataObject)
+	for _, object := range objects {
+		if object["CKA_CLASS"] != "CKO_NSS_TRUST" {
+			continue
+		}
+		hash := object["CKA_CERT_SHA1_HASH"]
+		if len(hash) != sha1.Size {
+			return nil, fmt.Errorf("error parsing certdata.txt: trust object %q has malformed CKA_CERT_SHA1_HASH", object["CKA_LABEL"])
+		}
+		trustObjects[[20]byte([]byte(hash))] = object
+	}
+	store := &RootStore{Name: "Mozilla", Purposes: mozillaPurposes}
+	for _, object := range objects {
+		if object["CKA_CLASS"] != "CKO_CERTIFICATE" {
+			continue
+		}
+		certificate := []byte(object["CKA_VALUE"])
+		trust, ok := trustObjects[sha1.Sum(certificate)]
+		if !ok {
+			continue
+		}
+		root := StoreRoot{
+			SHA256:      SHA256Fingerprint(sha256.Sum256(certificate)),
+			Name:        object["CKA_LABEL"],
+			Certificate: certificate,
+		}
+		if trust["CKA_TRUST_SERVER_AUTH"] == "CKT_NSS_TRUSTED_DELEGATOR" {
+			root.EKUs = append(root.EKUs, oidServerAuth)
+		}
+		if trust["CKA_TRUST_EMAIL_PROTECTION"] == "CKT_NSS_TRUSTED_DELEGATOR" {
+			root.EKUs = append(root.EKUs, oidEmailProtection)
+		}
+		if len(root.EKUs) == 0 {
+			continue
+		}
+		if err := root.setMozillaDistrustAfter(object["CKA_NSS_SERVER_DISTRUST_AFTER"], object["CKA_NSS_EMAIL_DISTRUST_AFTER"], parseCertdataTime); err != nil {
+			return nil, fmt.Errorf("error parsing certdata.txt: certificate %q: %w", root.Name, err)
+		}
+		store.Roots = append(store.Roots, root)
+	}
+	return store, nil
+}
+
+// parseCertdataObjects returns the attributes of every object in certdata.txt.
+// Scalar attributes are mapped to their value (e.g. "CKT_NSS_TRUSTED_DELEGATOR")
+// and MULTILINE_OCTAL attributes are mapped to their decoded bytes.
+func parseCertdataObjects(r io.Reader) ([]certdataObject, error) {
+	var objects []certdataObject
+	var object certdataObject
+	var octalName string
+	var octalValue []byte
+	inData := false
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if octalName != "" {
+			if line == "END" {
+				object[octalName] = string(octalValue)
+				octalName, octalValue = "", nil
+				continue
+			}
+			for _, octet := range strings.Split(line, "\\")[1:] {
+				b, err := strconv.ParseUint(octet, 8, 8)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: malformed octal value", lineNumber)
+				}
+				octalValue = append(octalValue, byte(b))
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "BEGINDATA" {
+			inData = true
+			continue
+		}
+		if !inData {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: malformed attribute", lineNumber)
+		}
+		name, typ := fields[0], fields[1]
+		if name == "CKA_CLASS" {
+			object = make(certdataObject)
+			objects = append(objects, object)
+		} else if object == nil {
+			return nil, fmt.Errorf("line %d: attribute outside of object", lineNumber)
+		}
+		switch {
+		case typ == "MULTILINE_OCTAL":
+			octalName = name
+		case len(fields) < 3:
+			return nil, fmt.Errorf("line %d: attribute has no value", lineNumber)
+		case typ == "UTF8":
+			value, err := strconv.Unquote(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: malformed UTF8 value", lineNumber)
+			}
+			object[name] = value
+		default:
+			object[name] = fields[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if octalName != "" {
+		return nil, fmt.Errorf("unterminated MULTILINE_OCTAL value")
+	}
+	return objects, nil
+}
+
+func parseCertdataTime(value string) (time.Time, error) {
+	if value == "" || value == "CK_FALSE" {
+		return time.Time{}, nil
+	}
+	return time.Parse("060102150405Z", value)
+}
+
+// ParseMozillaCCADBCSV parses Mozilla's root store from one of the CCADB reports of
+// certificates included in Mozilla's root store (such as IncludedCACertificateReportPEMCSV).
+// The report must contain "SHA-256 Fingerprint" and "Trust Bits" columns.
+func ParseMozillaCCADBCSV(r io.Reader) (*RootStore, error) {
+	reader := csv.NewReader(r)
+	header, err := readCSVHeader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CCADB CSV header: %w", err)
+	}
+	if !header.has("SHA-256 Fingerprint") {
+		return nil, fmt.Errorf("CCADB CSV does not contain a SHA-256 Fingerprint column")
+	}
+	if !header.has("Trust Bits") {
+		return nil, fmt.Errorf("CCADB CSV does not contain a Trust Bits column")
+	}
+
+	store := &RootStore{Name: "Mozilla", Purposes: mozillaPurposes}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading CCADB CSV: %w", err)
+		}
+		fingerprint, err := ParseSHA256Fingerprint(header.field(row, "SHA-256 Fingerprint"))
+		if err != nil {
+			return nil, fmt.Errorf("CCADB CSV contains malformed SHA-256 fingerprint %q", header.field(row, "SHA-256 Fingerprint"))
+		}
+		root := StoreRoot{
+			SHA256: fingerprint,
+			Name:   header.field(row, "Common Name or Certificate Name"),
+		}
+		if root.Name == "" {
+			root.Name = header.field(row, "Certificate Name")
+		}
+		for _, trustBit := range strings.Split(header.field(row, "Trust Bits"), ";") {
+			switch strings.TrimSpace(trustBit) {
+			case "Websites":
+				root.EKUs = append(root.EKUs, oidServerAuth)
+			case "Email":
+				root.EKUs = append(root.EKUs, oidEmailProtection)
+			}
+		}
+		if block, _ := pem.Decode([]byte(strings.Trim(header.field(row, "PEM Info"), "'"))); block != nil && block.Type == "CERTIFICATE" {
+			root.Certificate = block.Bytes
+		}
+		if err := root.setMozillaDistrustAfter(header.field(row, "Distrust for TLS After Date"), header.field(row, "Distrust for S/MIME After Date"), parseCCADBDate); err != nil {
+			return nil, fmt.Errorf("CCADB CSV: certificate %q: %w", root.Name, err)
+		}
+		store.Roots = append(store.Roots, root)
+	}
+	return store, nil
+}
+
+func parseCCADBDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006.01.02", "2006-01-02", "2006/01/02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("malformed date %q", value)
+}
+
+// setMozillaDistrustAfter sets DistrustAfter from Mozilla's separate TLS and S/MIME
+// distrust-after dates.  Since StoreRoot has only one date, the TLS date takes
+// precedence if the two dates differ.
+func (root *StoreRoot) setMozillaDistrustAfter(serverValue, emailValue string, parse func(string) (time.Time, error)) error {
+	serverDate, err := parse(serverValue)
+	if err != nil {
+		return fmt.Errorf("malformed TLS distrust-after date: %w", err)
+	}
+	emailDate, err := parse(emailValue)
+	if err != nil {
+		return fmt.Errorf("malformed S/MIME distrust-after date: %w", err)
+	}
+	switch {
+	case !serverDate.IsZero() && serverDate.Equal(emailDate):
+		root.DistrustAfter = serverDate
+		root.DistrustAfterEKUs = []asn1.ObjectIdentifier{oidServerAuth, oidEmailProtection}
+	case !serverDate.IsZero():
+		root.DistrustAfter = serverDate
+		root.DistrustAfterEKUs = []asn1.ObjectIdentifier{oidServerAuth}
+	case !emailDate.IsZero():
+		root.DistrustAfter = emailDate
+		root.DistrustAfterEKUs = []asn1.ObjectIdentifier{oidEmailProtection}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+const commandTimeout = 5 * time.Minute
+
+// Command runs a program with the JSON array of events on its standard input.
+// The old and new sequence numbers from the SequenceAdvanced event are also passed
+// in the AUTHROOTSTL_OLD_SEQUENCE_NUMBER and AUTHROOTSTL_NEW_SEQUENCE_NUMBER
+// environment variables.
+type Command struct {
+	Path string
+	Args []string
+}
+
+func (command *Command) String() string { return "command " + command.Path }
+
+func (command *Command) Notify(ctx context.Context, events []authrootstl.Event) error {
+	input, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command.Path, command.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for _, event := range events {
+		if event.Type == authrootstl.SequenceAdvanced {
+			cmd.Env = append(cmd.Env,
+				fmt.Sprintf("AUTHROOTSTL_OLD_SEQUENCE_NUMBER=%X", event.OldSequenceNumber),
+				fmt.Sprintf("AUTHROOTSTL_NEW_SEQUENCE_NUMBER=%X", event.NewSequenceNumber),
+			)
+		}
+	}
+	return cmd.Run()
+}
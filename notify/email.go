@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// DefaultEmailSubject is the default template for the subject of email notifications
+const DefaultEmailSubject = `Microsoft trust list changed{{range .}}{{if eq .Type "sequence_advanced"}} to sequence number {{printf "%X" .NewSequenceNumber}}{{end}}{{end}}`
+
+// DefaultEmailBody is the default template for the body of email notifications
+const DefaultEmailBody = `Microsoft's trust list changed:
+{{range .}}{{if eq .Type "sequence_advanced"}}
+Sequence number advanced from {{printf "%X" .OldSequenceNumber}} to {{printf "%X" .NewSequenceNumber}}
+{{else if eq .Type "root_added"}}
+Root added: {{printf "%X" .Root.SubjectIdentifier}} {{.Root.FriendlyName}}
+{{else if eq .Type "root_removed"}}
+Root removed: {{printf "%X" .Root.SubjectIdentifier}} {{.Root.FriendlyName}}
+{{else if eq .Type "root_property_changed"}}
+Root changed: {{printf "%X" .Root.SubjectIdentifier}} {{.Root.FriendlyName}}
+{{range .Changes}}  {{.}}
+{{end}}{{else if eq .Type "log_added"}}
+CT log added: {{printf "%x" .CTLog.LogID}}
+{{else if eq .Type "log_removed"}}
+CT log removed: {{printf "%x" .CTLog.LogID}}
+{{end}}{{end}}`
+
+// Email sends notifications by email.  The subject and body templates are
+// executed with the []authrootstl.Event for the change (see DefaultEmailBody).
+type Email struct {
+	Server   string // host:port
+	Username string // if empty, no authentication
+	Password string
+	From     string
+	To       []string
+	Subject  *template.Template
+	Body     *template.Template
+}
+
+// ParseEmailTemplates parses the subject template and the body template from
+// bodyFile, or DefaultEmailBody if bodyFile is empty
+func ParseEmailTemplates(subject string, bodyFile string) (*template.Template, *template.Template, error) {
+	subjectTemplate, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing email subject template: %w", err)
+	}
+	body := DefaultEmailBody
+	if bodyFile != "" {
+		bodyBytes, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		body = string(bodyBytes)
+	}
+	bodyTemplate, err := template.New("body").Parse(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing email body template: %w", err)
+	}
+	return subjectTemplate, bodyTemplate, nil
+}
+
+func (email *Email) String() string { return "email to " + strings.Join(email.To, ", ") }
+
+func (email *Email) Notify(ctx context.Context, events []authrootstl.Event) error {
+	var subject, body bytes.Buffer
+	if err := email.Subject.Execute(&subject, events); err != nil {
+		return fmt.Errorf("error executing email subject template: %w", err)
+	}
+	if err := email.Body.Execute(&body, events); err != nil {
+		return fmt.Errorf("error executing email body template: %w", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", email.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&message, "Content-Transfer-Encoding: quoted-printable\r\n")
+	fmt.Fprintf(&message, "\r\n")
+	qp := quotedprintable.NewWriter(&message)
+	qp.Write(bytes.ReplaceAll(body.Bytes(), []byte("\n"), []byte("\r\n")))
+	if err := qp.Close(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if email.Username != "" {
+		host, _, err := net.SplitHostPort(email.Server)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", email.Username, email.Password, host)
+	}
+	return smtp.SendMail(email.Server, auth, email.From, email.To, message.Bytes())
+}
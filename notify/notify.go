@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+// Package notify sends notifications about changes to the trust list, by webhook,
+// email, or command.  Notifiers are given the events (see authrootstl.Event) for
+// one change, typically from CTLDiff.Events in authrootstl.Watcher's OnChange function.
+package notify
+
+import (
+	"context"
+
+	"software.sslmate.com/src/authrootstl"
+)
+
+// Notifier sends a notification about a change to the trust list
+type Notifier interface {
+	Notify(ctx context.Context, events []authrootstl.Event) error
+	String() string // describes the notifier in log messages
+}
+
+// NotifyAll sends the events to every notifier, calling onError for each failure
+func NotifyAll(ctx context.Context, notifiers []Notifier, events []authrootstl.Event, onError func(Notifier, error)) {
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, events); err != nil {
+			onError(notifier, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+)
+
+// oidNames maps OIDs found in CTLs, their signatures, and their entries' properties to canonical names
+var oidNames = map[string]string{
+	// PKCS#7 and PKCS#9
+	"1.2.840.113549.1.7.1": "data",
+	"1.2.840.113549.1.7.2": "signedData",
+	"1.2.840.113549.1.9.3": "contentType",
+	"1.2.840.113549.1.9.4": "messageDigest",
+	"1.2.840.113549.1.9.5": "signingTime",
+	"1.2.840.113549.1.9.6": "countersignature",
+
+	// Algorithms
+	"1.2.840.113549.2.5":     "md5",
+	"1.3.14.3.2.26":          "sha1",
+	"2.16.840.1.101.3.4.2.1": "sha256",
+	"2.16.840.1.101.3.4.2.2": "sha384",
+	"2.16.840.1.101.3.4.2.3": "sha512",
+	"1.2.840.113549.1.1.1":   "rsaEncryption",
+	"1.2.840.113549.1.1.5":   "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.11":  "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12":  "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13":  "sha512WithRSAEncryption",
+	"1.2.840.10045.2.1":      "ecPublicKey",
+	"1.2.840.10045.4.3.2":    "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":    "ecdsa-with-SHA384",
+
+	// Extended key usages
+	"1.3.6.1.5.5.7.3.1": "serverAuth",
+	"1.3.6.1.5.5.7.3.2": "clientAuth",
+	"1.3.6.1.5.5.7.3.3": "codeSigning",
+	"1.3.6.1.5.5.7.3.4": "emailProtection",
+	"1.3.6.1.5.5.7.3.5": "ipsecEndSystem",
+	"1.3.6.1.5.5.7.3.6": "ipsecTunnel",
+	"1.3.6.1.5.5.7.3.7": "ipsecUser",
+	"1.3.6.1.5.5.7.3.8": "timeStamping",
+	"1.3.6.1.5.5.7.3.9": "OCSPSigning",
+	"1.3.6.1.5.5.8.2.2": "IP security IKE intermediate",
+
+	// Microsoft
+	"1.3.6.1.4.1.311.2.1.4":     "SPC_INDIRECT_DATA",
+	"1.3.6.1.4.1.311.2.1.11":    "SPC_STATEMENT_TYPE",
+	"1.3.6.1.4.1.311.2.1.12":    "SPC_SP_OPUS_INFO",
+	"1.3.6.1.4.1.311.3.3.1":     "RFC 3161 timestamp",
+	"1.3.6.1.4.1.311.10.1":      "Certificate Trust List",
+	"1.3.6.1.4.1.311.10.3.1":    "Microsoft Trust List Signing",
+	"1.3.6.1.4.1.311.10.3.2":    "Microsoft Time Stamping",
+	"1.3.6.1.4.1.311.10.3.3":    "Server Gated Crypto",
+	"1.3.6.1.4.1.311.10.3.4":    "Encrypting File System",
+	"1.3.6.1.4.1.311.10.3.4.1":  "File Recovery",
+	"1.3.6.1.4.1.311.10.3.5":    "Windows Hardware Driver Verification",
+	"1.3.6.1.4.1.311.10.3.6":    "Windows System Component Verification",
+	"1.3.6.1.4.1.311.10.3.7":    "OEM Windows System Component Verification",
+	"1.3.6.1.4.1.311.10.3.8":    "Embedded Windows System Component Verification",
+	"1.3.6.1.4.1.311.10.3.9":    "Root List Signer",
+	"1.3.6.1.4.1.311.10.3.12":   "Document Signing",
+	"1.3.6.1.4.1.311.10.3.13":   "Lifetime Signing",
+	"1.3.6.1.4.1.311.10.3.30":   "Disallowed List",
+	"1.3.6.1.4.1.311.10.3.31":   "Pin Rules Signer",
+	"1.3.6.1.4.1.311.10.3.32":   "Pin Rules CTL",
+	"1.3.6.1.4.1.311.10.3.52":   "CT logs",
+	"1.3.6.1.4.1.311.10.5.1":    "Digital Rights",
+	"1.3.6.1.4.1.311.10.6.1":    "Key Pack Licenses",
+	"1.3.6.1.4.1.311.10.6.2":    "License Server Verification",
+	"1.3.6.1.4.1.311.20.2.2":    "Smart Card Logon",
+	"1.3.6.1.4.1.311.21.5":      "CA Encryption Certificate",
+	"1.3.6.1.4.1.311.21.6":      "Key Recovery Agent",
+	"1.3.6.1.4.1.311.61.1.1":    "Kernel Mode Code Signing",
+	"1.3.6.1.4.1.311.12.1.1":    "Catalog List",
+	"1.3.6.1.4.1.311.10.11.9":   "EKU property",
+	"1.3.6.1.4.1.311.10.11.11":  "friendly name property",
+	"1.3.6.1.4.1.311.10.11.20":  "key identifier property",
+	"1.3.6.1.4.1.311.10.11.29":  "subject name MD5 property",
+	"1.3.6.1.4.1.311.10.11.83":  "root program certificate policies property",
+	"1.3.6.1.4.1.311.10.11.98":  "SHA-256 property",
+	"1.3.6.1.4.1.311.10.11.104": "disallowed FILETIME property",
+	"1.3.6.1.4.1.311.10.11.105": "root program chain policies property",
+	"1.3.6.1.4.1.311.10.11.122": "disallowed EKU property",
+	"1.3.6.1.4.1.311.10.11.124": "pin SHA-256 property",
+	"1.3.6.1.4.1.311.10.11.126": "NotBefore FILETIME property",
+	"1.3.6.1.4.1.311.10.11.127": "NotBefore EKU property",
+}
+
+// LookupOID returns the canonical name of a Microsoft CTL, property, extension, or
+// EKU OID, or of another OID commonly found in trust lists.  ok is false if the OID
+// is not known.
+func LookupOID(oid asn1.ObjectIdentifier) (name string, ok bool) {
+	name, ok = oidNames[oid.String()]
+	return name, ok
+}
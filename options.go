@@ -0,0 +1,180 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+type parseOptions struct {
+	zeroCopy       bool
+	strictDER      bool
+	strictChecks   bool
+	withoutEntries bool
+	headerOnly     bool
+	entryFunc      func(*Entry) error
+	workers        int
+	cache          ParseCache
+	compact        bool
+	entryFields    EntryFields
+	limits         Limits
+	verify         *VerifyOptions
+	integrity      *Integrity
+}
+
+// ParseOption configures how a CTL is parsed.  Options are accepted by
+// ParseAuthrootstl, ParseAuthrootstlCab, and ParseSTLCab.
+type ParseOption func(*parseOptions)
+
+// Limits bounds the resources used to parse untrusted STL files.  A zero MaxSize or
+// MaxEntries means no limit, and a zero MaxDepth means DefaultMaxDepth.
+type Limits struct {
+	MaxSize    int // maximum size of the STL file, in bytes
+	MaxEntries int // maximum number of entries
+	MaxDepth   int // maximum nesting depth of constructed ASN.1 elements, including those in certificates and attributes
+}
+
+// DefaultMaxDepth is the default Limits.MaxDepth, and the limit applied by
+// ParseSignedData.  It is well above the depth of STL files, including the
+// certificates and timestamp tokens within them.
+const DefaultMaxDepth = 64
+
+func (limits *Limits) maxDepth() int {
+	if limits.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return limits.MaxDepth
+}
+
+// WithZeroCopy makes the parser use the input buffer directly instead of copying it.
+// Every byte slice in the returned CTL (including Raw, hashes, key IDs, attribute
+// values, extension values, and CT log keys) then aliases the input, which must not
+// be modified while the CTL is in use.  This avoids a copy of the input for
+// each parse, which matters when parsing many archived STL files.
+func WithZeroCopy() ParseOption {
+	return func(opts *parseOptions) { opts.zeroCopy = true }
+}
+
+// WithStrictDER rejects input with trailing bytes after the ContentInfo or inside
+// the SignedData, which the parser otherwise reports in CTL.Warnings.  (Non-minimal and
+// indefinite lengths are always rejected.)
+func WithStrictDER() ParseOption {
+	return func(opts *parseOptions) { opts.strictDER = true }
+}
+
+// WithStrictChecks makes the problems which are otherwise reported in CTL.Warnings,
+// such as implausible dates, duplicate entries, and unrecognized attributes, cause
+// parsing to fail
+func WithStrictChecks() ParseOption {
+	return func(opts *parseOptions) { opts.strictChecks = true }
+}
+
+// WithLimits rejects input which exceeds the given limits
+func WithLimits(limits Limits) ParseOption {
+	return func(opts *parseOptions) { opts.limits = limits }
+}
+
+// WithoutEntries parses everything but the entries, leaving Entries nil.  The
+// entries can then be decoded on demand with AllEntries, which is cheaper for
+// callers that only need a few of them.
+func WithoutEntries() ParseOption {
+	return func(opts *parseOptions) { opts.withoutEntries = true }
+}
+
+// WithHeaderOnly parses only Version, SubjectUsage, ListIdentifier, SequenceNumber,
+// EffectiveDate, and NextUpdate, leaving the other fields empty.  This is the
+// cheapest way to decide whether a CTL is newer than one already seen.
+// AllEntries yields no entries for a CTL parsed with this option.
+func WithHeaderOnly() ParseOption {
+	return func(opts *parseOptions) { opts.headerOnly = true }
+}
+
+// WithEntryFunc calls fn with each entry as it is decoded, instead of collecting
+// the entries into Entries, which is left nil.  This avoids holding every decoded
+// entry in memory at once.  If fn returns an error, parsing stops and the error is returned, wrapped.
+// When several files are parsed at once, as by ParseArchive, fn is called concurrently.
+func WithEntryFunc(fn func(*Entry) error) ParseOption {
+	return func(opts *parseOptions) { opts.entryFunc = fn }
+}
+
+// WithEntryFields reduces the memory retained by the CTL, for callers which only
+// need to check whether a certificate is listed.  Each entry retains only its
+// SubjectIdentifier, SHA1, and the decoded fields selected by fields; Attributes
+// and unselected fields are left empty.  Raw is left nil, and nothing in the CTL
+// refers to the input, so the input can be freed.
+func WithEntryFields(fields EntryFields) ParseOption {
+	return func(opts *parseOptions) { opts.compact, opts.entryFields = true, fields }
+}
+
+// WithConcurrency decodes entries using up to workers goroutines, which reduces
+// the time to parse large CTLs on multi-core machines.  The default, and any value
+// less than 2, decodes entries sequentially.  It has no effect with WithoutEntries,
+// WithEntryFunc, or WithEntryFields.
+func WithConcurrency(workers int) ParseOption {
+	return func(opts *parseOptions) { opts.workers = workers }
+}
+
+// WithCache returns the CTL stored in cache for the STL file and the options which
+// affect the result, if there is one, instead of parsing the file, and otherwise stores
+// the parsed CTL in cache.  CTLs from the cache are shared by every caller, so they
+// must not be modified.  With WithZeroCopy, a copy of the CTL is stored, so the
+// cache never refers to der.  With WithVerification, the signature is verified even when
+// the CTL comes from the cache.  WithCache has no effect with WithEntryFunc.
+func WithCache(cache ParseCache) ParseOption {
+	return func(opts *parseOptions) { opts.cache = cache }
+}
+
+// WithVerification verifies the signature of the STL file, as SignedData.Verify
+// does, and fails if it is invalid
+func WithVerification(verifyOpts VerifyOptions) ParseOption {
+	return func(opts *parseOptions) { opts.verify = &verifyOpts }
+}
+
+// withIntegrity sets CTL.Integrity; it is used by Client
+func withIntegrity(integrity *Integrity) ParseOption {
+	return func(opts *parseOptions) { opts.integrity = integrity }
+}
+
+// cacheKey returns the ParseCache key for the CTL parsed from der with these options:
+// the SHA-256 hash of der followed by the options which affect the parsed CTL
+func (opts *parseOptions) cacheKey(der []byte) SHA256Fingerprint {
+	hash := sha256.New()
+	hash.Write(der)
+	fmt.Fprintf(hash, "\x00%t %t %t %t %t %t %d %d %d %d", opts.zeroCopy, opts.strictDER, opts.strictChecks,
+		opts.withoutEntries, opts.headerOnly, opts.compact, opts.entryFields, opts.limits.MaxSize, opts.limits.MaxEntries, opts.limits.maxDepth())
+	return SHA256Fingerprint(hash.Sum(nil))
+}
+
+func newParseOptions(opts []ParseOption) *parseOptions {
+	options := new(parseOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// nestedSequences returns depth SEQUENCEs, each containing the next, around a NULL
+func nestedSequences(depth int) []byte {
+	der := []byte{0x05, 0x00}
+	for range depth {
+		var b cryptobyte.Builder
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) { b.AddBytes(der) })
+		der = b.BytesOrPanic()
+	}
+	return der
+}
+
+func TestMaxDepth(t *testing.T) {
+	deep := nestedSequences(1000)
+	if _, err := ParseAuthrootstl(deep); err == nil || !strings.Contains(err.Error(), "nested more than 64 deep") {
+		t.Errorf("ParseAuthrootstl returned %v, want a nesting error", err)
+	}
+	if _, err := ParseSignedData(deep); err == nil || !strings.Contains(err.Error(), "nested more than 64 deep") {
+		t.Errorf("ParseSignedData returned %v, want a nesting error", err)
+	}
+	if err := checkASN1Depth(nestedSequences(64), 64); err != nil {
+		t.Errorf("64 levels: %v", err)
+	}
+	if err := checkASN1Depth(nestedSequences(65), 64); err == nil {
+		t.Error("65 levels were accepted")
+	}
+	// sibling elements don't add to the depth
+	siblings := append(nestedSequences(3), nestedSequences(3)...)
+	if err := checkASN1Depth(siblings, 3); err != nil {
+		t.Errorf("siblings: %v", err)
+	}
+
+	der := signTestCTL(t, newTestCTL(1, []testEntry{{identifier: make([]byte, 20)}}), nil)
+	if _, err := ParseAuthrootstl(der); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAuthrootstl(der, WithLimits(Limits{MaxDepth: 4})); err == nil || !strings.Contains(err.Error(), "nested more than 4 deep") {
+		t.Errorf("MaxDepth 4: ParseAuthrootstl returned %v, want a nesting error", err)
+	}
+}
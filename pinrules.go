@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	oidPinRulesDomainName = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 34}
+	oidPinSHA256Property  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 11, 124}
+)
+
+// PinRule is a rule from Microsoft's pin rules list (pinrulesstl.cab), which restricts
+// the certificates that may be used for a set of domains.  Microsoft does not document
+// this format, so only the domain names and pinned hashes are decoded; everything
+// else is available in Entry.Attributes.
+type PinRule struct {
+	Name         string              // decoded from the subject identifier
+	Domains      []string            // domain names to which the rule applies
+	PinnedSHA256 []SHA256Fingerprint // SHA-256 hashes of the pinned certificates
+	Entry        Entry               // the underlying CTL entry
+}
+
+// FetchPinRulesCTL downloads and parses pinrulesstl.cab
+func (client *Client) FetchPinRulesCTL(ctx context.Context) (*CTL, error) {
+	return client.fetchCTL(ctx, "pinrulesstl.cab", ParseSTLCab)
+}
+
+// ParsePinRules decodes the pin rules in a CTL returned by FetchPinRulesCTL
+func ParsePinRules(ctl *CTL) ([]PinRule, error) {
+	rules := make([]PinRule, 0, len(ctl.Entries))
+	for _, entry := range ctl.Entries {
+		rule := PinRule{
+			Name:  decodeRuleName(entry.SubjectIdentifier),
+			Entry: entry,
+		}
+		for _, attribute := range entry.Attributes {
+			switch {
+			case attribute.Type.Equal(oidPinRulesDomainName):
+				for _, value := range attribute.Values {
+					domains, err := parseUTF16String(value)
+					if err != nil {
+						return nil, fmt.Errorf("pin rule %q has malformed domain name: %w", rule.Name, err)
+					}
+					for _, domain := range strings.Split(domains, "\x00") {
+						if domain != "" {
+							rule.Domains = append(rule.Domains, domain)
+						}
+					}
+				}
+			case attribute.Type.Equal(oidPinSHA256Property):
+				for _, value := range attribute.Values {
+					if len(value)%32 != 0 {
+						return nil, fmt.Errorf("pin rule %q has malformed SHA-256 hash list", rule.Name)
+					}
+					for i := 0; i < len(value); i += 32 {
+						rule.PinnedSHA256 = append(rule.PinnedSHA256, SHA256Fingerprint(value[i:i+32]))
+					}
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// decodeRuleName decodes a subject identifier which is expected to be
+// an ASCII or UTF-16 name, falling back to hex
+func decodeRuleName(identifier []byte) string {
+	if name := string(identifier); utf8.ValidString(name) && isPrintable(name) {
+		return name
+	}
+	if name, err := parseUTF16String(identifier); err == nil && isPrintable(name) {
+		return name
+	}
+	return fmt.Sprintf("%X", identifier)
+}
+
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+			return false
+		}
+	}
+	return s != ""
+}
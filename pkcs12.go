@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	oidData                  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidCertBag               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509CertificateType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidFriendlyNameAttribute = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidJavaTrustedKeyUsage   = asn1.ObjectIdentifier{2, 16, 840, 1, 113894, 746875, 1, 1}
+	oidAnyExtendedKeyUsage   = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+	oidSHA256                = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+const pkcs12MacIterations = 2048
+
+// ExportPKCS12 encodes the roots as a PKCS#12 trust store, such as a Java truststore.
+// Certificates are stored unencrypted and marked as trusted for any usage using
+// Java's trusted key usage attribute.  If password is non-empty, the file is
+// integrity-protected with an HMAC-SHA-256 MAC; otherwise it has no MAC, which
+// Java and OpenSSL accept as a passwordless truststore.
+func ExportPKCS12(roots []ExportRoot, password string) ([]byte, error) {
+	var safeContents cryptobyte.Builder
+	safeContents.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for _, root := range roots {
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1ObjectIdentifier(oidCertBag)
+				b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+					b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1ObjectIdentifier(oidX509CertificateType)
+						b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+							b.AddASN1OctetString(root.Certificate)
+						})
+					})
+				})
+				b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+					if root.Entry.FriendlyName != "" {
+						b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+							b.AddASN1ObjectIdentifier(oidFriendlyNameAttribute)
+							b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+								b.AddASN1(cryptobyte_asn1.Tag(30), func(b *cryptobyte.Builder) { // BMPString
+									b.AddBytes(encodeBMPString(root.Entry.FriendlyName))
+								})
+							})
+						})
+					}
+					b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1ObjectIdentifier(oidJavaTrustedKeyUsage)
+						b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+							b.AddASN1ObjectIdentifier(oidAnyExtendedKeyUsage)
+						})
+					})
+				})
+			})
+		}
+	})
+	safeContentsBytes, err := safeContents.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var authenticatedSafe cryptobyte.Builder
+	authenticatedSafe.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addDataContentInfo(b, safeContentsBytes)
+	})
+	authenticatedSafeBytes, err := authenticatedSafe.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var macData []byte
+	if password != "" {
+		if macData, err = pkcs12MacData(authenticatedSafeBytes, password); err != nil {
+			return nil, err
+		}
+	}
+
+	var pfx cryptobyte.Builder
+	pfx.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(3)
+		addDataContentInfo(b, authenticatedSafeBytes)
+		b.AddBytes(macData)
+	})
+	return pfx.Bytes()
+}
+
+func addDataContentInfo(b *cryptobyte.Builder, data []byte) {
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oidData)
+		b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddASN1OctetString(data)
+		})
+	})
+}
+
+func pkcs12MacData(authenticatedSafe []byte, password string) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := pkcs12KDF(3, encodeBMPPassword(password), salt, pkcs12MacIterations, sha256.Size)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(authenticatedSafe)
+
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1ObjectIdentifier(oidSHA256)
+				b.AddASN1NULL()
+			})
+			b.AddASN1OctetString(mac.Sum(nil))
+		})
+		b.AddASN1OctetString(salt)
+		b.AddASN1Int64(pkcs12MacIterations)
+	})
+	return b.Bytes()
+}
+
+// pkcs12KDF derives keying material from a password as specified in RFC 7292 Appendix B.2,
+// using SHA-256
+func pkcs12KDF(id byte, password, salt []byte, iterations, size int) []byte {
+	const u = sha256.Size      // hash output length
+	const v = sha256.BlockSize // hash block length
+
+	D := make([]byte, v)
+	for i := range D {
+		D[i] = id
+	}
+	I := append(repeatToMultiple(salt, v), repeatToMultiple(password, v)...)
+
+	var output []byte
+	for len(output) < size {
+		h := sha256.New()
+		h.Write(D)
+		h.Write(I)
+		A := h.Sum(nil)
+		for range iterations - 1 {
+			sum := sha256.Sum256(A)
+			A = sum[:]
+		}
+		output = append(output, A...)
+
+		B := new(big.Int).SetBytes(repeatToMultiple(A, v)[:v])
+		B.Add(B, big.NewInt(1))
+		modulus := new(big.Int).Lsh(big.NewInt(1), v*8)
+		for j := 0; j < len(I); j += v {
+			Ij := new(big.Int).SetBytes(I[j : j+v])
+			Ij.Add(Ij, B)
+			Ij.Mod(Ij, modulus)
+			Ij.FillBytes(I[j : j+v])
+		}
+	}
+	return output[:size]
+}
+
+// repeatToMultiple concatenates copies of b until the length is the smallest multiple
+// of v which is at least len(b), truncating the final copy
+func repeatToMultiple(b []byte, v int) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	n := v * ((len(b) + v - 1) / v)
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = b[i%len(b)]
+	}
+	return out
+}
+
+func encodeBMPString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	encoded := make([]byte, 2*len(units))
+	for i, unit := range units {
+		encoded[2*i] = byte(unit >> 8)
+		encoded[2*i+1] = byte(unit)
+	}
+	return encoded
+}
+
+// encodeBMPPassword encodes a password for the PKCS#12 KDF: a NUL-terminated BMPString
+func encodeBMPPassword(password string) []byte {
+	return append(encodeBMPString(password), 0, 0)
+}
@@ -0,0 +1,358 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// SignedData is the PKCS#7 SignedData structure in which STL files are wrapped
+type SignedData struct {
+	Version      int
+	ContentType  asn1.ObjectIdentifier
+	Content      []byte   // DER encoding of the content (for STL files, the CTL), or nil if the content is detached
+	Certificates [][]byte // DER encoding of each certificate
+	SignerInfos  []SignerInfo
+}
+
+// SignerInfo is a PKCS#7 SignerInfo structure
+type SignerInfo struct {
+	Version int
+
+	// The signer is identified either by Issuer and SerialNumber, or by SubjectKeyID
+	Issuer       []byte // DER-encoded Name
+	SerialNumber *big.Int
+	SubjectKeyID []byte
+
+	DigestAlgorithm asn1.ObjectIdentifier
+
+	// RawAuthenticatedAttributes is the DER encoding of the authenticated
+	// attributes with a SET tag, over which the signature is computed
+	RawAuthenticatedAttributes []byte
+	AuthenticatedAttributes    []SignerAttribute
+
+	SignatureAlgorithm        asn1.ObjectIdentifier
+	Signature                 []byte
+	UnauthenticatedAttributes []SignerAttribute // e.g. countersignatures and timestamps
+}
+
+// SignerAttribute is an attribute of a SignerInfo.  Each value is DER-encoded.
+type SignerAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values [][]byte
+}
+
+// ParseSignedData parses a PKCS#7 ContentInfo containing SignedData
+func ParseSignedData(der cryptobyte.String) (*SignedData, error) {
+	if err := checkASN1Depth(der, DefaultMaxDepth); err != nil {
+		return nil, err
+	}
+	return parseSignedData(der, false, nil)
+}
+
+// checkASN1Depth returns an error if der contains constructed ASN.1 elements nested
+// more than maxDepth deep.  It doesn't recurse, and it stops without an error at the
+// first malformed element, which is left for the parsers to report.
+func checkASN1Depth(der []byte, maxDepth int) error {
+	var ends []int // offset of the end of each enclosing constructed element
+	for offset := 0; offset < len(der); {
+		for len(ends) > 0 && offset >= ends[len(ends)-1] {
+			ends = ends[:len(ends)-1]
+		}
+		rest := cryptobyte.String(der[offset:])
+		var contents cryptobyte.String
+		var tag cryptobyte_asn1.Tag
+		if !rest.ReadAnyASN1(&contents, &tag) {
+			return nil
+		}
+		end := len(der) - len(rest)
+		if len(ends) > 0 && end > ends[len(ends)-1] {
+			return nil
+		}
+		if tag.Constructed() != tag {
+			offset = end
+			continue
+		}
+		if len(ends) == maxDepth {
+			return fmt.Errorf("ASN.1 elements are nested more than %d deep", maxDepth)
+		}
+		ends = append(ends, end)
+		offset = end - len(contents)
+	}
+	return nil
+}
+
+// parseSignedData parses a PKCS#7 ContentInfo containing SignedData.  If strict is true,
+// trailing bytes after the ContentInfo, or within the ContentInfo or SignedData, are an error.
+// Otherwise, they are appended to warnings, if it is non-nil.
+func parseSignedData(der cryptobyte.String, strict bool, warnings *[]error) (*SignedData, error) {
+	trailing := func(rest cryptobyte.String, where string) error {
+		switch {
+		case rest.Empty():
+			return nil
+		case strict:
+			return fmt.Errorf("trailing bytes %s", where)
+		case warnings != nil:
+			*warnings = append(*warnings, fmt.Errorf("%w: %d trailing bytes %s", ErrUnusualEncoding, len(rest), where))
+		}
+		return nil
+	}
+	if isTruncated(der) {
+		return nil, fmt.Errorf("%w: input is shorter than its ContentInfo SEQUENCE", ErrTruncated)
+	}
+	var contentInfo cryptobyte.String
+	if !der.ReadASN1(&contentInfo, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("%w: malformed ContentInfo SEQUENCE", ErrNotSignedData)
+	}
+	if err := trailing(der, "after ContentInfo"); err != nil {
+		return nil, err
+	}
+	var contentInfoType asn1.ObjectIdentifier
+	if !contentInfo.ReadASN1ObjectIdentifier(&contentInfoType) {
+		return nil, fmt.Errorf("%w: malformed ContentInfo OBJECT IDENTIFIER", ErrNotSignedData)
+	}
+	if !contentInfoType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: ContentInfo has type %s", ErrNotSignedData, contentInfoType)
+	}
+	var explicitSignedData cryptobyte.String
+	if !contentInfo.ReadASN1(&explicitSignedData, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed ContentInfo content")
+	}
+	if err := trailing(contentInfo, "in ContentInfo"); err != nil {
+		return nil, err
+	}
+	var sequence cryptobyte.String
+	if !explicitSignedData.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed SignedData SEQUENCE")
+	}
+	if err := trailing(explicitSignedData, "after SignedData SEQUENCE"); err != nil {
+		return nil, err
+	}
+
+	signedData := new(SignedData)
+	if !sequence.ReadASN1Integer(&signedData.Version) {
+		return nil, fmt.Errorf("malformed version INTEGER")
+	}
+	if !sequence.SkipASN1(cryptobyte_asn1.SET) {
+		return nil, fmt.Errorf("malformed digest algorithms SET")
+	}
+	var encapsulatedContentInfo cryptobyte.String
+	if !sequence.ReadASN1(&encapsulatedContentInfo, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed encapsulated ContentInfo SEQUENCE")
+	}
+	if !encapsulatedContentInfo.ReadASN1ObjectIdentifier(&signedData.ContentType) {
+		return nil, fmt.Errorf("malformed content OBJECT IDENTIFIER")
+	}
+	var explicitContent cryptobyte.String
+	var hasContent bool
+	if !encapsulatedContentInfo.ReadOptionalASN1(&explicitContent, &hasContent, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed explicit content")
+	}
+	if hasContent {
+		var content cryptobyte.String
+		var contentTag cryptobyte_asn1.Tag
+		if !explicitContent.ReadAnyASN1Element(&content, &contentTag) {
+			return nil, fmt.Errorf("malformed content element")
+		}
+		signedData.Content = content
+	}
+
+	var certificates cryptobyte.String
+	var hasCertificates bool
+	if !sequence.ReadOptionalASN1(&certificates, &hasCertificates, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed certificates")
+	}
+	for !certificates.Empty() {
+		var certificate cryptobyte.String
+		if !certificates.ReadASN1Element(&certificate, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed certificate SEQUENCE")
+		}
+		signedData.Certificates = append(signedData.Certificates, certificate)
+	}
+	if !sequence.SkipOptionalASN1(cryptobyte_asn1.Tag(1).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed CRLs")
+	}
+
+	var signerInfos cryptobyte.String
+	if !sequence.ReadASN1(&signerInfos, cryptobyte_asn1.SET) {
+		return nil, fmt.Errorf("malformed signer infos SET")
+	}
+	for !signerInfos.Empty() {
+		var signerInfoBytes cryptobyte.String
+		if !signerInfos.ReadASN1(&signerInfoBytes, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed SignerInfo SEQUENCE")
+		}
+		signerInfo, err := parseSignerInfo(signerInfoBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing SignerInfo: %w", err)
+		}
+		signedData.SignerInfos = append(signedData.SignerInfos, *signerInfo)
+	}
+	if err := trailing(sequence, "in SignedData SEQUENCE"); err != nil {
+		return nil, err
+	}
+	return signedData, nil
+}
+
+func parseSignerInfo(der cryptobyte.String) (*SignerInfo, error) {
+	signerInfo := new(SignerInfo)
+	if !der.ReadASN1Integer(&signerInfo.Version) {
+		return nil, fmt.Errorf("malformed version INTEGER")
+	}
+	if der.PeekASN1Tag(cryptobyte_asn1.SEQUENCE) {
+		var issuerAndSerialNumber cryptobyte.String
+		if !der.ReadASN1(&issuerAndSerialNumber, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed IssuerAndSerialNumber SEQUENCE")
+		}
+		var issuer cryptobyte.String
+		if !issuerAndSerialNumber.ReadASN1Element(&issuer, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed issuer SEQUENCE")
+		}
+		signerInfo.Issuer = issuer
+		signerInfo.SerialNumber = new(big.Int)
+		if !issuerAndSerialNumber.ReadASN1Integer(signerInfo.SerialNumber) {
+			return nil, fmt.Errorf("malformed serial number INTEGER")
+		}
+	} else {
+		var subjectKeyID cryptobyte.String
+		if !der.ReadASN1(&subjectKeyID, cryptobyte_asn1.Tag(0).ContextSpecific()) {
+			return nil, fmt.Errorf("malformed signer identifier")
+		}
+		signerInfo.SubjectKeyID = subjectKeyID
+	}
+	var err error
+	if signerInfo.DigestAlgorithm, err = readAlgorithmIdentifier(&der); err != nil {
+		return nil, fmt.Errorf("malformed digest algorithm: %w", err)
+	}
+	var authenticatedAttributes cryptobyte.String
+	if der.PeekASN1Tag(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+		var element cryptobyte.String
+		if !der.ReadASN1Element(&element, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+			return nil, fmt.Errorf("malformed authenticated attributes")
+		}
+		// The signature is computed over the attributes with an explicit SET tag
+		signerInfo.RawAuthenticatedAttributes = append([]byte{byte(cryptobyte_asn1.SET)}, element[1:]...)
+		if !element.ReadASN1(&authenticatedAttributes, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+			return nil, fmt.Errorf("malformed authenticated attributes")
+		}
+		if signerInfo.AuthenticatedAttributes, err = parseSignerAttributes(authenticatedAttributes); err != nil {
+			return nil, fmt.Errorf("error parsing authenticated attributes: %w", err)
+		}
+	}
+	if signerInfo.SignatureAlgorithm, err = readAlgorithmIdentifier(&der); err != nil {
+		return nil, fmt.Errorf("malformed signature algorithm: %w", err)
+	}
+	var signature cryptobyte.String
+	if !der.ReadASN1(&signature, cryptobyte_asn1.OCTET_STRING) {
+		return nil, fmt.Errorf("malformed signature OCTET STRING")
+	}
+	signerInfo.Signature = signature
+	var unauthenticatedAttributes cryptobyte.String
+	var hasUnauthenticatedAttributes bool
+	if !der.ReadOptionalASN1(&unauthenticatedAttributes, &hasUnauthenticatedAttributes, cryptobyte_asn1.Tag(1).Constructed().ContextSpecific()) {
+		return nil, fmt.Errorf("malformed unauthenticated attributes")
+	}
+	if signerInfo.UnauthenticatedAttributes, err = parseSignerAttributes(unauthenticatedAttributes); err != nil {
+		return nil, fmt.Errorf("error parsing unauthenticated attributes: %w", err)
+	}
+	return signerInfo, nil
+}
+
+func parseSignerAttributes(der cryptobyte.String) ([]SignerAttribute, error) {
+	var attributes []SignerAttribute
+	for !der.Empty() {
+		var attributeBytes cryptobyte.String
+		if !der.ReadASN1(&attributeBytes, cryptobyte_asn1.SEQUENCE) {
+			return nil, fmt.Errorf("malformed attribute SEQUENCE")
+		}
+		var attribute SignerAttribute
+		if !attributeBytes.ReadASN1ObjectIdentifier(&attribute.Type) {
+			return nil, fmt.Errorf("malformed attribute OBJECT IDENTIFIER")
+		}
+		var values cryptobyte.String
+		if !attributeBytes.ReadASN1(&values, cryptobyte_asn1.SET) {
+			return nil, fmt.Errorf("malformed attribute values SET")
+		}
+		for !values.Empty() {
+			var value cryptobyte.String
+			var tag cryptobyte_asn1.Tag
+			if !values.ReadAnyASN1Element(&value, &tag) {
+				return nil, fmt.Errorf("malformed attribute value")
+			}
+			attribute.Values = append(attribute.Values, value)
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes, nil
+}
+
+func readAlgorithmIdentifier(der *cryptobyte.String) (asn1.ObjectIdentifier, error) {
+	var algorithmIdentifier cryptobyte.String
+	if !der.ReadASN1(&algorithmIdentifier, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed AlgorithmIdentifier SEQUENCE")
+	}
+	var algorithm asn1.ObjectIdentifier
+	if !algorithmIdentifier.ReadASN1ObjectIdentifier(&algorithm) {
+		return nil, fmt.Errorf("malformed algorithm OBJECT IDENTIFIER")
+	}
+	return algorithm, nil
+}
+
+// isTruncated reports whether der begins with an ASN.1 header whose length
+// extends past the end of der
+func isTruncated(der []byte) bool {
+	if len(der) == 0 || der[0]&0x1f == 0x1f {
+		return false
+	}
+	if len(der) < 2 {
+		return true
+	}
+	length, headerLen := int(der[1]), 2
+	if length&0x80 != 0 {
+		lengthLen := length & 0x7f
+		if lengthLen == 0 || lengthLen > 4 {
+			return false
+		}
+		if len(der) < 2+lengthLen {
+			return true
+		}
+		length = 0
+		for _, b := range der[2 : 2+lengthLen] {
+			length = length<<8 | int(b)
+		}
+		headerLen += lengthLen
+	}
+	return headerLen+length > len(der)
+}
@@ -0,0 +1,282 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxRevocationResponseSize is the largest OCSP response or CRL which is downloaded
+const maxRevocationResponseSize = 32 << 20
+
+// RevocationStatus is whether a certificate has been revoked
+type RevocationStatus int
+
+const (
+	RevocationUnknown RevocationStatus = iota // no OCSP responder or CRL provided a usable answer
+	RevocationGood
+	RevocationRevoked
+)
+
+func (status RevocationStatus) String() string {
+	switch status {
+	case RevocationGood:
+		return "good"
+	case RevocationRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// Revocation reasons, from RFC 5280, which revoke a certificate regardless of
+// when the signature was timestamped
+const (
+	reasonKeyCompromise = 1
+	reasonCACompromise  = 2
+)
+
+// RevocationResult is the revocation status of one certificate in the signer's chain
+type RevocationResult struct {
+	Certificate *x509.Certificate
+	Status      RevocationStatus
+	RevokedAt   time.Time // if Status is RevocationRevoked
+	Reason      int       // if Status is RevocationRevoked, the RFC 5280 CRLReason
+	Source      string    // URL of the OCSP responder or CRL which provided the status
+	Err         error     // if Status is RevocationUnknown, why
+}
+
+// RevocationChecker checks certificates against their OCSP responders and CRLs,
+// caching responses until their next update.  The zero value is ready to use, and
+// a RevocationChecker is safe for concurrent use.  Set VerifyOptions.Revocation to
+// check the signer's certificate chain during verification.
+type RevocationChecker struct {
+	HTTPClient *http.Client  // if nil, http.DefaultClient is used
+	Timeout    time.Duration // time limit for each request; if zero, 30 seconds
+
+	// RequireStatus makes verification fail unless the status of every
+	// certificate in the chain (other than the root) is known
+	RequireStatus bool
+
+	mu   sync.Mutex
+	ocsp map[string]*ocsp.Response       // keyed by issuer key hash and serial number
+	crls map[string]*x509.RevocationList // keyed by URL
+}
+
+// Check returns the revocation status of cert, which was issued by issuer.  OCSP
+// responders are consulted first, then CRL distribution points.
+func (checker *RevocationChecker) Check(ctx context.Context, cert, issuer *x509.Certificate) RevocationResult {
+	result := RevocationResult{Certificate: cert}
+	var errs []error
+	for _, server := range cert.OCSPServer {
+		response, err := checker.ocspResponse(ctx, server, cert, issuer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		switch response.Status {
+		case ocsp.Good:
+			result.Status, result.Source = RevocationGood, server
+			return result
+		case ocsp.Revoked:
+			result.Status, result.Source = RevocationRevoked, server
+			result.RevokedAt, result.Reason = response.RevokedAt, response.RevocationReason
+			return result
+		default:
+			errs = append(errs, fmt.Errorf("%s: OCSP responder does not know the certificate", server))
+		}
+	}
+	for _, url := range cert.CRLDistributionPoints {
+		crl, err := checker.crl(ctx, url, issuer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Status, result.Source = RevocationGood, url
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				result.Status = RevocationRevoked
+				result.RevokedAt, result.Reason = entry.RevocationTime, entry.ReasonCode
+				break
+			}
+		}
+		return result
+	}
+	if len(errs) == 0 {
+		errs = append(errs, fmt.Errorf("certificate has no OCSP responder or CRL distribution point"))
+	}
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+func (checker *RevocationChecker) ocspResponse(ctx context.Context, server string, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	key := string(digest(crypto.SHA256, issuer.RawSubjectPublicKeyInfo)) + cert.SerialNumber.String()
+	checker.mu.Lock()
+	response, ok := checker.ocsp[key]
+	checker.mu.Unlock()
+	if ok && time.Now().Before(response.NextUpdate) {
+		return response, nil
+	}
+
+	request, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCSP request: %w", err)
+	}
+	body, err := checker.post(ctx, server, request)
+	if err != nil {
+		return nil, err
+	}
+	response, err = ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error parsing OCSP response: %w", server, err)
+	}
+	if !response.NextUpdate.IsZero() && time.Now().After(response.NextUpdate) {
+		return nil, fmt.Errorf("%s: OCSP response expired at %s", server, response.NextUpdate)
+	}
+	if !response.NextUpdate.IsZero() {
+		checker.mu.Lock()
+		if checker.ocsp == nil {
+			checker.ocsp = make(map[string]*ocsp.Response)
+		}
+		checker.ocsp[key] = response
+		checker.mu.Unlock()
+	}
+	return response, nil
+}
+
+func (checker *RevocationChecker) crl(ctx context.Context, url string, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	checker.mu.Lock()
+	crl, ok := checker.crls[url]
+	checker.mu.Unlock()
+	if ok && time.Now().Before(crl.NextUpdate) && bytes.Equal(crl.RawIssuer, issuer.RawSubject) {
+		return crl, nil
+	}
+
+	body, err := checker.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	crl, err = x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error parsing CRL: %w", url, err)
+	}
+	if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) {
+		return nil, fmt.Errorf("%s: CRL was not issued by %s", url, issuer.Subject)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("%s: invalid CRL signature: %w", url, err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		return nil, fmt.Errorf("%s: CRL expired at %s", url, crl.NextUpdate)
+	}
+	if !crl.NextUpdate.IsZero() {
+		checker.mu.Lock()
+		if checker.crls == nil {
+			checker.crls = make(map[string]*x509.RevocationList)
+		}
+		checker.crls[url] = crl
+		checker.mu.Unlock()
+	}
+	return crl, nil
+}
+
+func (checker *RevocationChecker) get(ctx context.Context, url string) ([]byte, error) {
+	return checker.do(ctx, http.MethodGet, url, nil)
+}
+
+func (checker *RevocationChecker) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	return checker.do(ctx, http.MethodPost, url, body)
+}
+
+func (checker *RevocationChecker) do(ctx context.Context, method string, url string, body []byte) ([]byte, error) {
+	timeout := checker.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+	}
+	httpClient := checker.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{url: url, status: resp.Status, code: resp.StatusCode}
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	} else if len(respBody) > maxRevocationResponseSize {
+		return nil, fmt.Errorf("%s: response is larger than %d bytes", url, maxRevocationResponseSize)
+	}
+	return respBody, nil
+}
+
+// checkRevocation checks every certificate in chain except the root, returning an
+// error if one was revoked before verificationTime (or, for key or CA compromise,
+// at any time), or if a status is unknown and the checker requires it
+func (checker *RevocationChecker) checkRevocation(chain []*x509.Certificate, verificationTime time.Time) ([]RevocationResult, error) {
+	var results []RevocationResult
+	for i := 0; i+1 < len(chain); i++ {
+		result := checker.Check(context.Background(), chain[i], chain[i+1])
+		results = append(results, result)
+		switch result.Status {
+		case RevocationRevoked:
+			if !result.RevokedAt.After(verificationTime) || result.Reason == reasonKeyCompromise || result.Reason == reasonCACompromise {
+				return results, fmt.Errorf("%w: %s was revoked at %s (according to %s)", ErrRevoked, chain[i].Subject, result.RevokedAt.Format(time.RFC3339), result.Source)
+			}
+		case RevocationUnknown:
+			if checker.RequireStatus {
+				return results, fmt.Errorf("revocation status of %s is unknown: %w", chain[i].Subject, result.Err)
+			}
+		}
+	}
+	return results, nil
+}
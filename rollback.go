@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// RollbackError is returned when a CTL is older than one seen previously, or
+// reuses its sequence number with a different effective date.  Either may mean
+// that the CTL came from a stale mirror or was tampered with.
+type RollbackError struct {
+	PreviousSequenceNumber *big.Int
+	PreviousEffectiveDate  time.Time
+	SequenceNumber         *big.Int
+	EffectiveDate          time.Time
+}
+
+func (err *RollbackError) Error() string {
+	switch err.SequenceNumber.Cmp(err.PreviousSequenceNumber) {
+	case -1:
+		return fmt.Sprintf("CTL sequence number went backwards from %X to %X", err.PreviousSequenceNumber, err.SequenceNumber)
+	case 0:
+		return fmt.Sprintf("CTL sequence number %X was reused with effective date %s instead of %s", err.SequenceNumber,
+			err.EffectiveDate.Format(time.RFC3339), err.PreviousEffectiveDate.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("CTL effective date went backwards from %s to %s", err.PreviousEffectiveDate.Format(time.RFC3339), err.EffectiveDate.Format(time.RFC3339))
+	}
+}
+
+// CheckRollback returns a *RollbackError if the CTL's sequence number is less than
+// previousSequenceNumber, if its sequence number is the same but its effective date is
+// different, or if its effective date is before previousEffectiveDate.  These are the
+// sequence number and effective date of the most recently seen CTL.
+func (ctl *CTL) CheckRollback(previousSequenceNumber *big.Int, previousEffectiveDate time.Time) error {
+	var rolledBack bool
+	switch ctl.SequenceNumber.Cmp(previousSequenceNumber) {
+	case -1:
+		rolledBack = true
+	case 0:
+		rolledBack = !ctl.EffectiveDate.Equal(previousEffectiveDate)
+	default:
+		rolledBack = ctl.EffectiveDate.Before(previousEffectiveDate)
+	}
+	if !rolledBack {
+		return nil
+	}
+	return &RollbackError{
+		PreviousSequenceNumber: previousSequenceNumber,
+		PreviousEffectiveDate:  previousEffectiveDate,
+		SequenceNumber:         &ctl.SequenceNumber,
+		EffectiveDate:          ctl.EffectiveDate,
+	}
+}
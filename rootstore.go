@@ -0,0 +1,229 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"slices"
+	"time"
+)
+
+var (
+	oidServerAuth      = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}
+	oidCodeSigning     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}
+	oidEmailProtection = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4}
+)
+
+// RootStore is a root store published by another root program, normalized
+// so that it can be compared to the CTL.  Trust bits are expressed as
+// extended key usage OIDs, the same as in an Entry.
+type RootStore struct {
+	Name     string
+	Purposes []asn1.ObjectIdentifier // the usages which this root store makes trust decisions about
+	Roots    []StoreRoot
+}
+
+// StoreRoot is a root certificate in a RootStore
+type StoreRoot struct {
+	SHA256      SHA256Fingerprint
+	Name        string
+	Certificate []byte                  // DER-encoded certificate, or nil if not known
+	EKUs        []asn1.ObjectIdentifier // usages for which the root is trusted
+
+	// If DistrustAfter is non-zero, certificates issued by this root after this date
+	// are distrusted for the usages in DistrustAfterEKUs (or for all usages if DistrustAfterEKUs is empty).
+	DistrustAfter     time.Time
+	DistrustAfterEKUs []asn1.ObjectIdentifier
+
+	// Constraints which could not be expressed using DistrustAfter, in the root program's own notation
+	Constraints []string
+}
+
+// RootStoreComparison is the result of comparing the CTL to another root store
+type RootStoreComparison struct {
+	OnlyMicrosoft []Entry          // entries with no counterpart in the other root store
+	OnlyOther     []StoreRoot      // roots in the other root store which are not in the CTL
+	Differing     []RootDifference // roots in both which are trusted for different usages
+}
+
+// RootDifference describes a root which is trusted differently by Microsoft and another root store.
+// Only the Purposes of the other root store are considered.
+type RootDifference struct {
+	Entry             Entry
+	Root              StoreRoot
+	OnlyMicrosoftEKUs []asn1.ObjectIdentifier // usages trusted by Microsoft but not by the other root store
+	OnlyOtherEKUs     []asn1.ObjectIdentifier // usages trusted by the other root store but not by Microsoft
+
+	// ConstraintsDiffer is true if, for a usage trusted by both, the root is
+	// subject to different date-based constraints (Microsoft's NotBeforeDate and
+	// DisallowedDate vs. the other root store's DistrustAfter), or if the
+	// other root store has Constraints which cannot be compared.
+	ConstraintsDiffer bool
+}
+
+// CompareRootStore compares the entries in the CTL to another root store.  Roots are
+// matched by the SHA-256 hash of the certificate, so entries without a SHA-256 hash
+// are always reported in OnlyMicrosoft.
+func CompareRootStore(ctl *CTL, store *RootStore) *RootStoreComparison {
+	comparison := new(RootStoreComparison)
+	storeRoots := make(map[SHA256Fingerprint]*StoreRoot)
+	for i := range store.Roots {
+		storeRoots[store.Roots[i].SHA256] = &store.Roots[i]
+	}
+	matched := make(map[SHA256Fingerprint]bool)
+	for _, entry := range ctl.Entries {
+		if entry.SHA256.IsZero() {
+			comparison.OnlyMicrosoft = append(comparison.OnlyMicrosoft, entry)
+			continue
+		}
+		fingerprint := entry.SHA256
+		root, ok := storeRoots[fingerprint]
+		if !ok {
+			comparison.OnlyMicrosoft = append(comparison.OnlyMicrosoft, entry)
+			continue
+		}
+		matched[fingerprint] = true
+		var difference RootDifference
+		for _, purpose := range store.Purposes {
+			microsoftTrusts := len(entry.EKUs) == 0 || containsOID(entry.EKUs, purpose)
+			otherTrusts := containsOID(root.EKUs, purpose)
+			if microsoftTrusts && !otherTrusts {
+				difference.OnlyMicrosoftEKUs = append(difference.OnlyMicrosoftEKUs, purpose)
+			} else if otherTrusts && !microsoftTrusts {
+				difference.OnlyOtherEKUs = append(difference.OnlyOtherEKUs, purpose)
+			} else if microsoftTrusts && otherTrusts && !sameConstraints(&entry, root, purpose) {
+				difference.ConstraintsDiffer = true
+			}
+		}
+		if len(difference.OnlyMicrosoftEKUs) > 0 || len(difference.OnlyOtherEKUs) > 0 || difference.ConstraintsDiffer {
+			difference.Entry = entry
+			difference.Root = *root
+			comparison.Differing = append(comparison.Differing, difference)
+		}
+	}
+	for _, root := range store.Roots {
+		if !matched[root.SHA256] {
+			comparison.OnlyOther = append(comparison.OnlyOther, root)
+		}
+	}
+	return comparison
+}
+
+// RootStoreMembership records which root stores contain a particular root
+type RootStoreMembership struct {
+	SHA256 SHA256Fingerprint
+	Entry  *Entry               // the entry in the CTL, or nil if the root is not in the CTL
+	Roots  map[string]StoreRoot // the root as described by each root store that contains it, keyed by RootStore.Name
+}
+
+// CompareRootStores compares the CTL to several root stores at once, producing a
+// matrix keyed by the SHA-256 hash of the certificate.  Every root in the CTL or in at
+// least one root store is returned exactly once.  Roots in the CTL come first, in CTL
+// order, followed by the remaining roots in the order of stores.  Entries without a
+// SHA-256 hash are omitted.
+func CompareRootStores(ctl *CTL, stores ...*RootStore) []RootStoreMembership {
+	var memberships []RootStoreMembership
+	index := make(map[SHA256Fingerprint]int)
+	for i := range ctl.Entries {
+		entry := &ctl.Entries[i]
+		if entry.SHA256.IsZero() {
+			continue
+		}
+		fingerprint := entry.SHA256
+		if _, exists := index[fingerprint]; exists {
+			continue
+		}
+		index[fingerprint] = len(memberships)
+		memberships = append(memberships, RootStoreMembership{
+			SHA256: fingerprint,
+			Entry:  entry,
+			Roots:  make(map[string]StoreRoot),
+		})
+	}
+	for _, store := range stores {
+		for _, root := range store.Roots {
+			i, exists := index[root.SHA256]
+			if !exists {
+				i = len(memberships)
+				index[root.SHA256] = i
+				memberships = append(memberships, RootStoreMembership{
+					SHA256: root.SHA256,
+					Roots:  make(map[string]StoreRoot),
+				})
+			}
+			memberships[i].Roots[store.Name] = root
+		}
+	}
+	return memberships
+}
+
+// UniqueToMicrosoft returns the entries in the CTL which are absent from every one of the
+// given root stores, in CTL order.  Entries without a SHA-256 hash cannot be matched
+// and are always returned.
+func UniqueToMicrosoft(ctl *CTL, stores ...*RootStore) []Entry {
+	otherRoots := make(map[SHA256Fingerprint]bool)
+	for _, store := range stores {
+		for _, root := range store.Roots {
+			otherRoots[root.SHA256] = true
+		}
+	}
+	var unique []Entry
+	for _, entry := range ctl.Entries {
+		if entry.SHA256.IsZero() || !otherRoots[entry.SHA256] {
+			unique = append(unique, entry)
+		}
+	}
+	return unique
+}
+
+func sameConstraints(entry *Entry, root *StoreRoot, purpose asn1.ObjectIdentifier) bool {
+	if len(root.Constraints) > 0 {
+		return false
+	}
+	if !entry.DisallowedDate.IsZero() && appliesTo(entry.DisallowedEKUs, purpose) {
+		return false
+	}
+	var microsoftDate, otherDate time.Time
+	if appliesTo(entry.NotBeforeEKUs, purpose) {
+		microsoftDate = entry.NotBeforeDate
+	}
+	if appliesTo(root.DistrustAfterEKUs, purpose) {
+		otherDate = root.DistrustAfter
+	}
+	return microsoftDate.Equal(otherDate)
+}
+
+// appliesTo reports whether a constraint restricted to the given usages
+// applies to purpose.  An empty list of usages means all usages.
+func appliesTo(ekus []asn1.ObjectIdentifier, purpose asn1.ObjectIdentifier) bool {
+	return len(ekus) == 0 || containsOID(ekus, purpose)
+}
+
+func containsOID(oids []asn1.ObjectIdentifier, oid asn1.ObjectIdentifier) bool {
+	return slices.ContainsFunc(oids, oid.Equal)
+}
@@ -0,0 +1,284 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// SCT is a signed certificate timestamp (RFC 6962, section 3.2)
+type SCT struct {
+	Version            uint8
+	LogID              [32]byte
+	Timestamp          uint64 // milliseconds since the Unix epoch
+	Extensions         []byte
+	HashAlgorithm      uint8 // TLS HashAlgorithm; 4 is SHA-256
+	SignatureAlgorithm uint8 // TLS SignatureAlgorithm; 1 is RSA and 3 is ECDSA
+	Signature          []byte
+}
+
+// Time returns the SCT's timestamp as a time.Time
+func (sct *SCT) Time() time.Time {
+	return time.UnixMilli(int64(sct.Timestamp))
+}
+
+// ParseSCT parses a TLS-encoded SCT
+func ParseSCT(b []byte) (*SCT, error) {
+	s := cryptobyte.String(b)
+	sct := new(SCT)
+	var logID, extensions, signature cryptobyte.String
+	if !s.ReadUint8(&sct.Version) {
+		return nil, fmt.Errorf("malformed SCT version")
+	} else if sct.Version != 0 {
+		return nil, fmt.Errorf("unsupported SCT version %d", sct.Version)
+	}
+	if !s.ReadBytes((*[]byte)(&logID), len(sct.LogID)) ||
+		!s.ReadUint64(&sct.Timestamp) ||
+		!s.ReadUint16LengthPrefixed(&extensions) ||
+		!s.ReadUint8(&sct.HashAlgorithm) ||
+		!s.ReadUint8(&sct.SignatureAlgorithm) ||
+		!s.ReadUint16LengthPrefixed(&signature) {
+		return nil, fmt.Errorf("malformed SCT")
+	} else if !s.Empty() {
+		return nil, fmt.Errorf("trailing bytes after SCT")
+	}
+	copy(sct.LogID[:], logID)
+	sct.Extensions = extensions
+	sct.Signature = signature
+	return sct, nil
+}
+
+// SCTEntry is the certificate or precertificate for which an SCT was issued
+type SCTEntry struct {
+	// Precert is true if the SCT was issued for a precertificate, in which case
+	// Certificate is the TBSCertificate without the poison or SCT list extensions
+	// and IssuerKeyHash is the SHA-256 hash of the issuer's SubjectPublicKeyInfo
+	Precert       bool
+	Certificate   []byte // DER-encoded certificate, or TBSCertificate if Precert is true
+	IssuerKeyHash [32]byte
+}
+
+// SCTVerifier verifies the signatures of SCTs issued by one CT log.  Its
+// VerifySCTSignature method corresponds to the method of the same name on
+// certificate-transparency-go's ct.SignatureVerifier; to get one of those
+// instead, pass CTLogKey.PublicKey to ct.NewSignatureVerifier.
+type SCTVerifier struct {
+	LogID     [32]byte
+	PublicKey crypto.PublicKey
+}
+
+// PublicKey parses the log's key
+func (key CTLogKey) PublicKey() (crypto.PublicKey, error) {
+	publicKey, err := x509.ParsePKIXPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("CT log %s has malformed key: %w", key, err)
+	}
+	return publicKey, nil
+}
+
+// NewSCTVerifier returns a verifier for SCTs issued by the log with the given key
+func NewSCTVerifier(key CTLogKey) (*SCTVerifier, error) {
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &SCTVerifier{LogID: key.LogID(), PublicKey: publicKey}, nil
+}
+
+// SCTVerifiers returns a verifier for each CT log recognized by the CTL, keyed by log ID
+func (ctl *CTL) SCTVerifiers() (map[[32]byte]*SCTVerifier, error) {
+	verifiers := make(map[[32]byte]*SCTVerifier, len(ctl.CTLogs))
+	for _, key := range ctl.CTLogs {
+		verifier, err := NewSCTVerifier(key)
+		if err != nil {
+			return nil, err
+		}
+		verifiers[verifier.LogID] = verifier
+	}
+	return verifiers, nil
+}
+
+// VerifySCTSignature returns an error wrapping ErrSignatureInvalid unless sct was
+// issued by the verifier's log for entry and its signature is valid
+func (verifier *SCTVerifier) VerifySCTSignature(sct *SCT, entry *SCTEntry) error {
+	if sct.LogID != verifier.LogID {
+		return fmt.Errorf("%w: SCT is from log %x, not %x", ErrSignatureInvalid, sct.LogID, verifier.LogID)
+	}
+	if sct.HashAlgorithm != 4 {
+		return fmt.Errorf("%w: SCT has unsupported hash algorithm %d", ErrSignatureInvalid, sct.HashAlgorithm)
+	}
+	signed, err := sctSignedData(sct, entry)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(signed)
+	switch publicKey := verifier.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if sct.SignatureAlgorithm != 3 || !ecdsa.VerifyASN1(publicKey, hash[:], sct.Signature) {
+			return fmt.Errorf("%w: bad ECDSA signature on SCT", ErrSignatureInvalid)
+		}
+	case *rsa.PublicKey:
+		if sct.SignatureAlgorithm != 1 {
+			return fmt.Errorf("%w: SCT has signature algorithm %d, but log key is RSA", ErrSignatureInvalid, sct.SignatureAlgorithm)
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], sct.Signature); err != nil {
+			return fmt.Errorf("%w: bad RSA signature on SCT: %w", ErrSignatureInvalid, err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported log key type %T", ErrSignatureInvalid, publicKey)
+	}
+	return nil
+}
+
+// sctSignedData returns the data covered by an SCT's signature (RFC 6962, section 3.2)
+func sctSignedData(sct *SCT, entry *SCTEntry) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8(sct.Version)
+	b.AddUint8(0) // signature_type = certificate_timestamp
+	b.AddUint64(sct.Timestamp)
+	if entry.Precert {
+		b.AddUint16(1) // entry_type = precert_entry
+		b.AddBytes(entry.IssuerKeyHash[:])
+	} else {
+		b.AddUint16(0) // entry_type = x509_entry
+	}
+	b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(entry.Certificate) })
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(sct.Extensions) })
+	return b.Bytes()
+}
+
+// EmbeddedSCTs returns the SCTs in the certificate's SCT list extension, if any
+func EmbeddedSCTs(cert *x509.Certificate) ([]*SCT, error) {
+	for _, extension := range cert.Extensions {
+		if extension.Id.Equal(oidSCTList) {
+			return parseSCTList(extension.Value)
+		}
+	}
+	return nil, nil
+}
+
+// parseSCTList parses the value of the SCT list extension: an OCTET STRING containing
+// a SignedCertificateTimestampList (RFC 6962, section 3.3)
+func parseSCTList(der cryptobyte.String) ([]*SCT, error) {
+	var list, scts cryptobyte.String
+	if !der.ReadASN1(&list, cryptobyte_asn1.OCTET_STRING) || !der.Empty() {
+		return nil, fmt.Errorf("malformed SCT list OCTET STRING")
+	}
+	if !list.ReadUint16LengthPrefixed(&scts) || !list.Empty() {
+		return nil, fmt.Errorf("malformed SCT list")
+	}
+	var result []*SCT
+	for !scts.Empty() {
+		var sctBytes cryptobyte.String
+		if !scts.ReadUint16LengthPrefixed(&sctBytes) {
+			return nil, fmt.Errorf("malformed SCT in list")
+		}
+		sct, err := ParseSCT(sctBytes)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sct)
+	}
+	return result, nil
+}
+
+// PrecertEntry returns the entry over which the log signed an SCT embedded in cert:
+// its TBSCertificate without the SCT list extension, with the hash of the issuer's key
+func PrecertEntry(cert, issuer *x509.Certificate) (*SCTEntry, error) {
+	tbs, err := removeExtension(cert.RawTBSCertificate, oidSCTList)
+	if err != nil {
+		return nil, fmt.Errorf("error reconstructing precertificate: %w", err)
+	}
+	return &SCTEntry{
+		Precert:       true,
+		Certificate:   tbs,
+		IssuerKeyHash: sha256.Sum256(issuer.RawSubjectPublicKeyInfo),
+	}, nil
+}
+
+// removeExtension returns a copy of the DER-encoded TBSCertificate without the given extension
+func removeExtension(rawTBS cryptobyte.String, oid asn1.ObjectIdentifier) ([]byte, error) {
+	var tbs cryptobyte.String
+	if !rawTBS.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, fmt.Errorf("malformed TBSCertificate SEQUENCE")
+	}
+	extensionsTag := cryptobyte_asn1.Tag(3).Constructed().ContextSpecific()
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for !tbs.Empty() {
+			var element, contents cryptobyte.String
+			var tag cryptobyte_asn1.Tag
+			if !tbs.ReadAnyASN1Element(&element, &tag) {
+				b.SetError(fmt.Errorf("malformed TBSCertificate element"))
+				return
+			}
+			if tag != extensionsTag {
+				b.AddBytes(element)
+				continue
+			}
+			var extensions cryptobyte.String
+			if !element.ReadASN1(&contents, extensionsTag) || !contents.ReadASN1(&extensions, cryptobyte_asn1.SEQUENCE) {
+				b.SetError(fmt.Errorf("malformed extensions"))
+				return
+			}
+			b.AddASN1(extensionsTag, func(b *cryptobyte.Builder) {
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					for !extensions.Empty() {
+						var extension, extensionContents cryptobyte.String
+						var id asn1.ObjectIdentifier
+						if !extensions.ReadASN1Element(&extension, cryptobyte_asn1.SEQUENCE) {
+							b.SetError(fmt.Errorf("malformed extension"))
+							return
+						}
+						extensionContents = extension
+						if !extensionContents.ReadASN1(&extensionContents, cryptobyte_asn1.SEQUENCE) || !extensionContents.ReadASN1ObjectIdentifier(&id) {
+							b.SetError(fmt.Errorf("malformed extension"))
+							return
+						}
+						if !id.Equal(oid) {
+							b.AddBytes(extension)
+						}
+					}
+				})
+			})
+		}
+	})
+	return b.Bytes()
+}
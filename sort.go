@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"slices"
+)
+
+// CompareEntries defines the canonical order of entries: by subject identifier
+// (the certificate's SHA-1 hash, for authroot.stl), compared as bytes.  It returns
+// -1, 0, or +1 like bytes.Compare.
+func CompareEntries(a, b *Entry) int {
+	return bytes.Compare(a.SubjectIdentifier, b.SubjectIdentifier)
+}
+
+// CompareCTLogKeys defines the canonical order of CT logs: by log ID, compared as
+// bytes, and then by key, should two keys have the same ID
+func CompareCTLogKeys(a, b CTLogKey) int {
+	aID, bID := a.LogID(), b.LogID()
+	if c := bytes.Compare(aID[:], bID[:]); c != 0 {
+		return c
+	}
+	return bytes.Compare(a, b)
+}
+
+// SortEntries sorts entries into the canonical order defined by CompareEntries
+func SortEntries(entries []Entry) {
+	slices.SortStableFunc(entries, func(a, b Entry) int { return CompareEntries(&a, &b) })
+}
+
+// SortCTLogs sorts logs into the canonical order defined by CompareCTLogKeys
+func SortCTLogs(logs []CTLogKey) {
+	slices.SortStableFunc(logs, CompareCTLogKeys)
+}
+
+// Sorted returns a copy of the CTL (see Clone) with its entries and CT logs in
+// canonical order, so that output derived from them doesn't depend on the order in
+// the STL file.  The CTL itself is not modified, so it may be shared.
+func (ctl *CTL) Sorted() *CTL {
+	sorted := ctl.Clone()
+	SortEntries(sorted.Entries)
+	SortCTLogs(sorted.CTLogs)
+	return sorted
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSortedDoesNotModifyCTL(t *testing.T) {
+	der := signTestCTL(t, newTestCTL(1, []testEntry{
+		{identifier: bytes.Repeat([]byte{2}, 20)},
+		{identifier: bytes.Repeat([]byte{1}, 20)},
+	}), nil)
+	cache := NewLRUCache(1)
+	ctl, err := ParseAuthrootstl(der, WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted := ctl.Sorted()
+	if sorted.Entries[0].SHA1[0] != 1 || sorted.Entries[1].SHA1[0] != 2 {
+		t.Errorf("Sorted did not sort the entries")
+	}
+	cached, err := ParseAuthrootstl(der, WithCache(cache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Entries[0].SHA1[0] != 2 || cached.Entries[1].SHA1[0] != 1 {
+		t.Errorf("Sorted modified the cached CTL")
+	}
+}
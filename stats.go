@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"time"
+)
+
+// Stats summarizes the composition of a CTL
+type Stats struct {
+	Roots              int            `json:"roots"`
+	RootsByEKU         map[string]int `json:"roots_by_eku"`         // number of roots trusted for each EKU (by OID), per the EKU property
+	RootsWithoutEKUs   int            `json:"roots_without_ekus"`   // roots with no EKU property
+	Disallowed         int            `json:"disallowed"`           // roots disallowed, for some or all usages, as of the time of the stats
+	NotBefore          int            `json:"not_before"`           // roots with a NotBefore date, past or future
+	ScheduledDisallows int            `json:"scheduled_disallows"`  // roots with a DisallowedDate after the time of the stats
+	ScheduledNotBefore int            `json:"scheduled_not_before"` // roots with a NotBeforeDate after the time of the stats
+	CTLogs             int            `json:"ct_logs"`
+	CTLogKeys          map[string]int `json:"ct_log_keys"` // number of CT logs with each type of key, e.g. "ECDSA-P-256"
+}
+
+// Stats returns statistics about the CTL's entries and CT logs as of the current time
+func (ctl *CTL) Stats() *Stats {
+	return ctl.StatsAt(time.Now())
+}
+
+// StatsAt returns statistics about the CTL's entries and CT logs as of the given time
+func (ctl *CTL) StatsAt(at time.Time) *Stats {
+	stats := &Stats{
+		Roots:      len(ctl.Entries),
+		RootsByEKU: make(map[string]int),
+		CTLogs:     len(ctl.CTLogs),
+		CTLogKeys:  make(map[string]int),
+	}
+	for i := range ctl.Entries {
+		entry := &ctl.Entries[i]
+		if len(entry.EKUs) == 0 {
+			stats.RootsWithoutEKUs++
+		}
+		for _, eku := range entry.EKUs {
+			stats.RootsByEKU[eku.String()]++
+		}
+		if !entry.DisallowedDate.IsZero() {
+			if entry.DisallowedDate.After(at) {
+				stats.ScheduledDisallows++
+			} else {
+				stats.Disallowed++
+			}
+		}
+		if !entry.NotBeforeDate.IsZero() {
+			stats.NotBefore++
+			if entry.NotBeforeDate.After(at) {
+				stats.ScheduledNotBefore++
+			}
+		}
+	}
+	for _, logKey := range ctl.CTLogs {
+		publicKey, err := logKey.PublicKey()
+		if err != nil {
+			stats.CTLogKeys["malformed"]++
+			continue
+		}
+		stats.CTLogKeys[publicKeyName(publicKey)]++
+	}
+	return stats
+}
+
+// publicKeyName returns a short description of publicKey, such as "RSA-2048" or "ECDSA-P-256"
+func publicKeyName(publicKey crypto.PublicKey) string {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", publicKey)
+	}
+}
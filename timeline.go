@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"encoding/asn1"
+	"slices"
+	"time"
+)
+
+// ScheduledChangeKind is the kind of a ScheduledChange
+type ScheduledChangeKind int
+
+const (
+	// The root becomes distrusted (Entry.DisallowedDate)
+	ScheduledDisallow ScheduledChangeKind = iota
+	// Certificates issued by the root after the date are distrusted (Entry.NotBeforeDate)
+	ScheduledNotBefore
+)
+
+func (kind ScheduledChangeKind) String() string {
+	switch kind {
+	case ScheduledDisallow:
+		return "disallow"
+	case ScheduledNotBefore:
+		return "not-before"
+	default:
+		return "unknown"
+	}
+}
+
+// ScheduledChange is a change in the trust of an entry which takes effect in the future
+type ScheduledChange struct {
+	Date  time.Time
+	Kind  ScheduledChangeKind
+	EKUs  []asn1.ObjectIdentifier // the affected usages, or empty if all usages are affected
+	Entry Entry
+}
+
+// ScheduledChanges returns the changes which Microsoft has scheduled to take effect after
+// the given time, in chronological order.  Changes which take effect on the same date are
+// in CTL order.
+func ScheduledChanges(ctl *CTL, after time.Time) []ScheduledChange {
+	var changes []ScheduledChange
+	for _, entry := range ctl.Entries {
+		if entry.DisallowedDate.After(after) {
+			changes = append(changes, ScheduledChange{
+				Date:  entry.DisallowedDate,
+				Kind:  ScheduledDisallow,
+				EKUs:  entry.DisallowedEKUs,
+				Entry: entry,
+			})
+		}
+		if entry.NotBeforeDate.After(after) {
+			changes = append(changes, ScheduledChange{
+				Date:  entry.NotBeforeDate,
+				Kind:  ScheduledNotBefore,
+				EKUs:  entry.NotBeforeEKUs,
+				Entry: entry,
+			})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b ScheduledChange) int {
+		return a.Date.Compare(b.Date)
+	})
+	return changes
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"cmp"
+	"encoding/asn1"
+	"slices"
+	"strings"
+	"time"
+)
+
+// UsageGroup is a set of roots which are trusted for the same usages
+type UsageGroup struct {
+	All     bool                    // the roots are trusted for all usages except those in Except
+	EKUs    []asn1.ObjectIdentifier // if All is false, the usages for which the roots are trusted; empty if none
+	Except  []asn1.ObjectIdentifier
+	Entries []*Entry
+}
+
+// GroupByUsage groups the entries by the usages for which they are trusted at the
+// given time, taking into account DisallowedDate and DisallowedEKUs but not NotBefore
+// restrictions.  Groups are ordered from largest to smallest.
+func GroupByUsage(entries []Entry, at time.Time) []UsageGroup {
+	groups := make(map[string]*UsageGroup)
+	for i := range entries {
+		entry := &entries[i]
+		group := permittedUsages(entry, at)
+		key := group.key()
+		if existing, ok := groups[key]; ok {
+			existing.Entries = append(existing.Entries, entry)
+		} else {
+			group.Entries = []*Entry{entry}
+			groups[key] = &group
+		}
+	}
+	result := make([]UsageGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	slices.SortFunc(result, func(a, b UsageGroup) int {
+		return cmp.Or(cmp.Compare(len(b.Entries), len(a.Entries)), cmp.Compare(a.key(), b.key()))
+	})
+	return result
+}
+
+// permittedUsages returns the usages for which entry is trusted at the given time, without Entries
+func permittedUsages(entry *Entry, at time.Time) UsageGroup {
+	var disallowed []asn1.ObjectIdentifier
+	if !NotDisallowedAt(at)(entry) {
+		if len(entry.DisallowedEKUs) == 0 {
+			return UsageGroup{}
+		}
+		disallowed = entry.DisallowedEKUs
+	}
+	if len(entry.EKUs) == 0 {
+		return UsageGroup{All: true, Except: sortedOIDs(disallowed)}
+	}
+	var ekus []asn1.ObjectIdentifier
+	for _, eku := range entry.EKUs {
+		if !containsOID(disallowed, eku) && !containsOID(ekus, eku) {
+			ekus = append(ekus, eku)
+		}
+	}
+	return UsageGroup{EKUs: sortedOIDs(ekus)}
+}
+
+func sortedOIDs(oids []asn1.ObjectIdentifier) []asn1.ObjectIdentifier {
+	oids = slices.Clone(oids)
+	slices.SortFunc(oids, func(a, b asn1.ObjectIdentifier) int { return slices.Compare(a, b) })
+	return slices.CompactFunc(oids, asn1.ObjectIdentifier.Equal)
+}
+
+func (group *UsageGroup) key() string {
+	var key strings.Builder
+	if group.All {
+		key.WriteString("all-")
+	}
+	for _, oid := range slices.Concat(group.EKUs, group.Except) {
+		key.WriteString(oid.String())
+		key.WriteByte(' ')
+	}
+	return key.String()
+}
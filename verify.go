@@ -0,0 +1,501 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	oidMessageDigest    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidCountersignature = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 6}
+	oidRFC3161Timestamp = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 3, 3, 1}
+	oidTrustListSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 1}
+)
+
+var digestAlgorithms = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	"1.2.840.113549.1.1.5":   crypto.SHA1, // sha1WithRSAEncryption, used by some signers as a digest algorithm
+	"1.2.840.113549.1.1.11":  crypto.SHA256,
+	"1.2.840.113549.1.1.12":  crypto.SHA384,
+	"1.2.840.113549.1.1.13":  crypto.SHA512,
+	"1.2.840.10045.4.1":      crypto.SHA1,
+	"1.2.840.10045.4.3.2":    crypto.SHA256,
+	"1.2.840.10045.4.3.3":    crypto.SHA384,
+	"1.2.840.10045.4.3.4":    crypto.SHA512,
+}
+
+// VerifyOptions contains the parameters for SignedData.Verify
+type VerifyOptions struct {
+	// Roots are the trusted roots to which the signer and timestamper must chain.
+	// If nil, only Microsoft's root (see MicrosoftRootSHA256) is trusted, and it
+	// must be among the certificates in the SignedData; otherwise verification
+	// fails with ErrNoMicrosoftRoot.  Client supplies it automatically.
+	Roots *x509.CertPool
+
+	// CurrentTime is the time at which certificates are validated if the
+	// signature has no timestamp.  If zero, the current time is used.
+	CurrentTime time.Time
+
+	// ClockSkew is how far CurrentTime (or the current time) may be from the true
+	// time.  If the signature has no timestamp, the signer's certificate chain is
+	// accepted if it is valid at any time within ClockSkew of CurrentTime.
+	ClockSkew time.Duration
+
+	// Revocation, if non-nil, is used to check whether the signer's certificate
+	// or an intermediate has been revoked.  The results are recorded in
+	// Verification.Revocation.
+	Revocation *RevocationChecker
+}
+
+// Verification is the result of successfully verifying a SignedData
+type Verification struct {
+	Signer      *x509.Certificate
+	Chain       []*x509.Certificate // from the signer to the root
+	SigningTime time.Time           // from the signingTime attribute, or zero if absent
+
+	// If the signature has a timestamp countersignature, Timestamp is the time
+	// it asserts and Timestamper is the certificate which signed it
+	Timestamp   time.Time
+	Timestamper *x509.Certificate
+
+	// Revocation contains the revocation status of each certificate in Chain
+	// other than the root, if VerifyOptions.Revocation was set
+	Revocation []RevocationResult
+}
+
+// Verify verifies the first signer's signature over the content, the signer's
+// certificate chain, and the timestamp countersignature, if any.  Certificates are
+// validated as of the timestamp, so an expired signing certificate is acceptable
+// if the signature was timestamped while it was valid.  The signer's certificate
+// must have the Microsoft Trust List Signing extended key usage, and so must every
+// intermediate certificate which restricts extended key usages.
+func (signedData *SignedData) Verify(opts VerifyOptions) (*Verification, error) {
+	if len(signedData.SignerInfos) == 0 {
+		return nil, fmt.Errorf("%w: SignedData has no signers", ErrSignatureInvalid)
+	}
+	certificates, err := parseCertificates(signedData.Certificates)
+	if err != nil {
+		return nil, err
+	}
+	roots := opts.Roots
+	if roots == nil {
+		if roots = microsoftRootFrom(certificates); roots == nil {
+			return nil, fmt.Errorf("%w: %w", ErrSignatureInvalid, ErrNoMicrosoftRoot)
+		}
+	}
+	signerInfo := &signedData.SignerInfos[0]
+	verification := new(Verification)
+	if verification.Signer, err = verifySignerInfo(signerInfo, certificates, contentCandidates(signedData.Content)...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	if verification.SigningTime, err = signingTime(signerInfo.AuthenticatedAttributes); err != nil {
+		return nil, err
+	}
+
+	verificationTime := opts.CurrentTime
+	if verificationTime.IsZero() {
+		verificationTime = time.Now()
+	}
+	skew := opts.ClockSkew
+	if err := verification.verifyTimestamp(signerInfo, certificates, roots); err != nil {
+		return nil, fmt.Errorf("%w: error verifying timestamp: %w", ErrSignatureInvalid, err)
+	}
+	if !verification.Timestamp.IsZero() {
+		verificationTime = verification.Timestamp
+		skew = 0 // the timestamp doesn't depend on our clock
+	}
+
+	// crypto/x509 can't check the Microsoft Trust List Signing EKU, so
+	// chains are checked for it below
+	chains, err := verifyWithSkew(verification.Signer, x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: certPool(certificates),
+		CurrentTime:   verificationTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}, skew)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error verifying signer certificate: %w", ErrSignatureInvalid, err)
+	}
+	if verification.Chain = trustListSigningChain(chains); verification.Chain == nil {
+		return nil, fmt.Errorf("%w: signer certificate is not valid for Microsoft Trust List Signing (%s)", ErrSignatureInvalid, oidTrustListSigning)
+	}
+	if opts.Revocation != nil {
+		if verification.Revocation, err = opts.Revocation.checkRevocation(verification.Chain, verificationTime); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+		}
+	}
+	return verification, nil
+}
+
+func (verification *Verification) verifyTimestamp(signerInfo *SignerInfo, certificates []*x509.Certificate, roots *x509.CertPool) error {
+	for _, attribute := range signerInfo.UnauthenticatedAttributes {
+		if len(attribute.Values) != 1 {
+			continue
+		}
+		var err error
+		switch {
+		case attribute.Type.Equal(oidCountersignature):
+			err = verification.verifyCountersignature(attribute.Values[0], signerInfo.Signature, certificates, roots)
+		case attribute.Type.Equal(oidRFC3161Timestamp):
+			err = verification.verifyRFC3161Timestamp(attribute.Values[0], signerInfo.Signature, roots)
+		default:
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
+// verifyCountersignature verifies a PKCS#9 countersignature over the given signature
+func (verification *Verification) verifyCountersignature(der cryptobyte.String, signature []byte, certificates []*x509.Certificate, roots *x509.CertPool) error {
+	var signerInfoBytes cryptobyte.String
+	if !der.ReadASN1(&signerInfoBytes, cryptobyte_asn1.SEQUENCE) {
+		return fmt.Errorf("malformed countersignature SEQUENCE")
+	}
+	counterSignerInfo, err := parseSignerInfo(signerInfoBytes)
+	if err != nil {
+		return fmt.Errorf("error parsing countersignature: %w", err)
+	}
+	timestamper, err := verifySignerInfo(counterSignerInfo, certificates, signature)
+	if err != nil {
+		return err
+	}
+	timestamp, err := signingTime(counterSignerInfo.AuthenticatedAttributes)
+	if err != nil {
+		return err
+	} else if timestamp.IsZero() {
+		return fmt.Errorf("countersignature has no signing time")
+	}
+	if err := verifyTimestamper(timestamper, certificates, roots, timestamp); err != nil {
+		return err
+	}
+	verification.Timestamp, verification.Timestamper = timestamp, timestamper
+	return nil
+}
+
+// verifyRFC3161Timestamp verifies an RFC 3161 timestamp token over the given signature
+func (verification *Verification) verifyRFC3161Timestamp(der []byte, signature []byte, roots *x509.CertPool) error {
+	token, err := ParseSignedData(der)
+	if err != nil {
+		return fmt.Errorf("error parsing timestamp token: %w", err)
+	} else if len(token.SignerInfos) == 0 {
+		return fmt.Errorf("timestamp token has no signers")
+	}
+	certificates, err := parseCertificates(token.Certificates)
+	if err != nil {
+		return err
+	}
+	candidates := contentCandidates(token.Content)
+	timestamper, err := verifySignerInfo(&token.SignerInfos[0], certificates, candidates...)
+	if err != nil {
+		return err
+	}
+	tstInfo := cryptobyte.String(candidates[len(candidates)-1])
+	imprintHash, imprint, timestamp, err := parseTSTInfo(tstInfo)
+	if err != nil {
+		return fmt.Errorf("error parsing TSTInfo: %w", err)
+	}
+	if !bytes.Equal(digest(imprintHash, signature), imprint) {
+		return fmt.Errorf("timestamp message imprint does not match signature")
+	}
+	if err := verifyTimestamper(timestamper, certificates, roots, timestamp); err != nil {
+		return err
+	}
+	verification.Timestamp, verification.Timestamper = timestamp, timestamper
+	return nil
+}
+
+func parseTSTInfo(der cryptobyte.String) (crypto.Hash, []byte, time.Time, error) {
+	var sequence cryptobyte.String
+	if !der.ReadASN1(&sequence, cryptobyte_asn1.SEQUENCE) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed SEQUENCE")
+	}
+	var version int
+	if !sequence.ReadASN1Integer(&version) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed version INTEGER")
+	}
+	if !sequence.SkipASN1(cryptobyte_asn1.OBJECT_IDENTIFIER) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed policy OBJECT IDENTIFIER")
+	}
+	var messageImprint cryptobyte.String
+	if !sequence.ReadASN1(&messageImprint, cryptobyte_asn1.SEQUENCE) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed message imprint SEQUENCE")
+	}
+	hashAlgorithm, err := readAlgorithmIdentifier(&messageImprint)
+	if err != nil {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed message imprint algorithm: %w", err)
+	}
+	hash, ok := digestAlgorithms[hashAlgorithm.String()]
+	if !ok {
+		return 0, nil, time.Time{}, fmt.Errorf("unsupported message imprint algorithm %s", hashAlgorithm)
+	}
+	var imprint cryptobyte.String
+	if !messageImprint.ReadASN1(&imprint, cryptobyte_asn1.OCTET_STRING) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed message imprint OCTET STRING")
+	}
+	if !sequence.SkipASN1(cryptobyte_asn1.INTEGER) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed serial number INTEGER")
+	}
+	var genTime time.Time
+	if !sequence.ReadASN1GeneralizedTime(&genTime) {
+		return 0, nil, time.Time{}, fmt.Errorf("malformed genTime")
+	}
+	return hash, imprint, genTime, nil
+}
+
+func verifyTimestamper(timestamper *x509.Certificate, certificates []*x509.Certificate, roots *x509.CertPool, timestamp time.Time) error {
+	_, err := timestamper.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: certPool(certificates),
+		CurrentTime:   timestamp,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return fmt.Errorf("error verifying timestamper certificate: %w", err)
+	}
+	return nil
+}
+
+// verifySignerInfo verifies signerInfo's signature and returns the signer's certificate.
+// The signed content must match one of the candidates.
+func verifySignerInfo(signerInfo *SignerInfo, certificates []*x509.Certificate, candidates ...[]byte) (*x509.Certificate, error) {
+	signer := findSigner(signerInfo, certificates)
+	if signer == nil {
+		return nil, fmt.Errorf("signer certificate not found")
+	}
+	hash, ok := digestAlgorithms[signerInfo.DigestAlgorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm %s", signerInfo.DigestAlgorithm)
+	}
+	var signed []byte
+	if signerInfo.RawAuthenticatedAttributes != nil {
+		messageDigest, err := messageDigest(signerInfo.AuthenticatedAttributes)
+		if err != nil {
+			return nil, err
+		}
+		if !matchesAnyDigest(hash, messageDigest, candidates) {
+			return nil, fmt.Errorf("message digest does not match content")
+		}
+		signed = signerInfo.RawAuthenticatedAttributes
+	} else {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no content to verify")
+		}
+		signed = candidates[len(candidates)-1]
+	}
+	if err := checkSignature(signer, hash, signed, signerInfo.Signature); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+func checkSignature(signer *x509.Certificate, hash crypto.Hash, signed, signature []byte) error {
+	hashed := digest(hash, signed)
+	switch publicKey := signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(publicKey, hash, hashed, signature); err != nil {
+			return fmt.Errorf("invalid RSA signature: %w", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(publicKey, hashed, signature) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
+
+func findSigner(signerInfo *SignerInfo, certificates []*x509.Certificate) *x509.Certificate {
+	for _, cert := range certificates {
+		if signerInfo.Issuer != nil {
+			if bytes.Equal(cert.RawIssuer, signerInfo.Issuer) && cert.SerialNumber.Cmp(signerInfo.SerialNumber) == 0 {
+				return cert
+			}
+		} else if bytes.Equal(cert.SubjectKeyId, signerInfo.SubjectKeyID) {
+			return cert
+		}
+	}
+	return nil
+}
+
+// contentCandidates returns the encodings of content over which a message digest
+// might have been computed: the full DER element, and just its contents octets
+// (which is what PKCS#7 specifies, and what is digested for an OCTET STRING eContent)
+func contentCandidates(content []byte) [][]byte {
+	element := cryptobyte.String(content)
+	var contents cryptobyte.String
+	var tag cryptobyte_asn1.Tag
+	if !element.ReadAnyASN1(&contents, &tag) {
+		return [][]byte{content}
+	}
+	return [][]byte{content, contents}
+}
+
+func matchesAnyDigest(hash crypto.Hash, expected []byte, candidates [][]byte) bool {
+	for _, candidate := range candidates {
+		if bytes.Equal(digest(hash, candidate), expected) {
+			return true
+		}
+	}
+	return false
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func messageDigest(attributes []SignerAttribute) ([]byte, error) {
+	for _, attribute := range attributes {
+		if attribute.Type.Equal(oidMessageDigest) && len(attribute.Values) == 1 {
+			value := cryptobyte.String(attribute.Values[0])
+			var messageDigest cryptobyte.String
+			if !value.ReadASN1(&messageDigest, cryptobyte_asn1.OCTET_STRING) {
+				return nil, fmt.Errorf("malformed messageDigest attribute")
+			}
+			return messageDigest, nil
+		}
+	}
+	return nil, fmt.Errorf("authenticated attributes lack messageDigest")
+}
+
+func signingTime(attributes []SignerAttribute) (time.Time, error) {
+	for _, attribute := range attributes {
+		if attribute.Type.Equal(oidSigningTime) && len(attribute.Values) == 1 {
+			value := cryptobyte.String(attribute.Values[0])
+			var t time.Time
+			if !readTime(&value, &t) {
+				return time.Time{}, fmt.Errorf("malformed signingTime attribute")
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func parseCertificates(ders [][]byte) ([]*x509.Certificate, error) {
+	certificates := make([]*x509.Certificate, 0, len(ders))
+	for i, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate %d: %w", i, err)
+		}
+		certificates = append(certificates, cert)
+	}
+	return certificates, nil
+}
+
+// verifyWithSkew is like cert.Verify, but if the chain is invalid only because
+// of the time, it is accepted if it is valid skew before or after opts.CurrentTime
+func verifyWithSkew(cert *x509.Certificate, opts x509.VerifyOptions, skew time.Duration) ([][]*x509.Certificate, error) {
+	chains, err := cert.Verify(opts)
+	if invalidErr := (x509.CertificateInvalidError{}); skew > 0 && errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired {
+		now := opts.CurrentTime
+		for _, t := range []time.Time{now.Add(-skew), now.Add(skew)} {
+			opts.CurrentTime = t
+			if skewedChains, skewedErr := cert.Verify(opts); skewedErr == nil {
+				return skewedChains, nil
+			}
+		}
+	}
+	return chains, err
+}
+
+// trustListSigningChain returns the first chain in which the signer (the first
+// certificate) has the Microsoft Trust List Signing EKU, and no other certificate
+// has EKUs which exclude it, or nil if there is none
+func trustListSigningChain(chains [][]*x509.Certificate) []*x509.Certificate {
+	for _, chain := range chains {
+		if !slices.ContainsFunc(chain[0].UnknownExtKeyUsage, oidTrustListSigning.Equal) {
+			continue
+		}
+		permitted := true
+		for _, cert := range chain[1:] {
+			if len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0 {
+				continue
+			}
+			if !slices.Contains(cert.ExtKeyUsage, x509.ExtKeyUsageAny) && !slices.ContainsFunc(cert.UnknownExtKeyUsage, oidTrustListSigning.Equal) {
+				permitted = false
+				break
+			}
+		}
+		if permitted {
+			return chain
+		}
+	}
+	return nil
+}
+
+func certPool(certificates []*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certificates {
+		pool.AddCert(cert)
+	}
+	return pool
+}
+
+// CheckFreshness returns an error if the CTL's next update time has passed as of now,
+// or if maxAge is non-zero and the CTL's effective date is more than maxAge before now
+func (ctl *CTL) CheckFreshness(now time.Time, maxAge time.Duration) error {
+	return ctl.CheckFreshnessWithSkew(now, maxAge, 0)
+}
+
+// CheckFreshnessWithSkew is like CheckFreshness, but tolerates now being up to
+// skew ahead of the true time
+func (ctl *CTL) CheckFreshnessWithSkew(now time.Time, maxAge, skew time.Duration) error {
+	now = now.Add(-skew)
+	if !ctl.NextUpdate.IsZero() && now.After(ctl.NextUpdate) {
+		return fmt.Errorf("%w: next update was due at %s", ErrStale, ctl.NextUpdate.Format(time.RFC3339))
+	}
+	if maxAge != 0 && now.Sub(ctl.EffectiveDate) > maxAge {
+		return fmt.Errorf("%w: effective date %s is more than %s old", ErrStale, ctl.EffectiveDate.Format(time.RFC3339), maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+type testChain struct {
+	root, intermediate, signer *testIssuer
+}
+
+func newTestChain(t *testing.T, signerEKUs []x509.ExtKeyUsage, signerUnknownEKUs ...asn1.ObjectIdentifier) testChain {
+	root := newTestCert(t, "Test Root", nil, true, nil)
+	intermediate := newTestCert(t, "Test Intermediate", root, true, nil)
+	signer := newTestCert(t, "Test Signer", intermediate, false, signerEKUs, signerUnknownEKUs...)
+	return testChain{root: root, intermediate: intermediate, signer: signer}
+}
+
+func (chain testChain) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(chain.root.cert)
+	return pool
+}
+
+func (chain testChain) sign(t *testing.T) *SignedData {
+	der := signTestCTL(t, newTestCTL(1, nil), chain.signer, chain.signer.cert, chain.intermediate.cert, chain.root.cert)
+	signedData, err := ParseSignedData(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedData
+}
+
+func TestVerify(t *testing.T) {
+	chain := newTestChain(t, nil, oidTrustListSigning)
+	verification, err := chain.sign(t).Verify(VerifyOptions{Roots: chain.roots(), CurrentTime: testTime})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Signer.Equal(chain.signer.cert) {
+		t.Errorf("wrong signer %s", verification.Signer.Subject)
+	}
+	if len(verification.Chain) != 3 {
+		t.Errorf("chain has %d certificates, want 3", len(verification.Chain))
+	}
+}
+
+func TestVerifyRejectsNonMicrosoftChain(t *testing.T) {
+	// without Roots, a chain which doesn't lead to Microsoft's root must be
+	// rejected, even though its root is in the SignedData
+	chain := newTestChain(t, nil, oidTrustListSigning)
+	_, err := chain.sign(t).Verify(VerifyOptions{CurrentTime: testTime})
+	if !errors.Is(err, ErrSignatureInvalid) || !errors.Is(err, ErrNoMicrosoftRoot) {
+		t.Fatalf("Verify returned %v, want ErrSignatureInvalid and ErrNoMicrosoftRoot", err)
+	}
+}
+
+func TestVerifyWithoutRoots(t *testing.T) {
+	// without Roots, a chain leading to the certificate pinned by
+	// MicrosoftRootSHA256 is accepted, and a modified copy is rejected
+	chain := newTestChain(t, nil, oidTrustListSigning)
+	savedPin := MicrosoftRootSHA256
+	MicrosoftRootSHA256 = sha256.Sum256(chain.root.cert.Raw)
+	defer func() { MicrosoftRootSHA256 = savedPin }()
+
+	der := signTestCTL(t, newTestCTL(1, nil), chain.signer, chain.signer.cert, chain.intermediate.cert, chain.root.cert)
+	if _, err := ParseAuthrootstl(der, WithVerification(VerifyOptions{CurrentTime: testTime})); err != nil {
+		t.Fatalf("unmodified CTL: %v", err)
+	}
+	modified := bytes.Replace(der, newTestCTL(1, nil), newTestCTL(2, nil), 1)
+	if bytes.Equal(modified, der) {
+		t.Fatal("failed to modify the CTL")
+	}
+	if _, err := ParseAuthrootstl(modified, WithVerification(VerifyOptions{CurrentTime: testTime})); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("modified CTL: ParseAuthrootstl returned %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func TestVerifyRequiresTrustListSigning(t *testing.T) {
+	tests := []struct {
+		name string
+		ekus []x509.ExtKeyUsage
+	}{
+		{name: "none"},
+		{name: "any", ekus: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}},
+		{name: "code signing", ekus: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain := newTestChain(t, test.ekus)
+			_, err := chain.sign(t).Verify(VerifyOptions{Roots: chain.roots(), CurrentTime: testTime})
+			if !errors.Is(err, ErrSignatureInvalid) {
+				t.Fatalf("Verify returned %v, want ErrSignatureInvalid", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIntermediateEKUs(t *testing.T) {
+	root := newTestCert(t, "Test Root", nil, true, nil)
+	intermediate := newTestCert(t, "Test Intermediate", root, true, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}, oidTrustListSigning)
+	signer := newTestCert(t, "Test Signer", intermediate, false, nil, oidTrustListSigning)
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	chain := testChain{root: root, intermediate: intermediate, signer: signer}
+	if _, err := chain.sign(t).Verify(VerifyOptions{Roots: roots, CurrentTime: testTime}); err != nil {
+		t.Errorf("intermediate permitting trust list signing: %v", err)
+	}
+
+	chain.intermediate = newTestCert(t, "Test Intermediate", root, true, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning})
+	chain.signer = newTestCert(t, "Test Signer", chain.intermediate, false, nil, oidTrustListSigning)
+	if _, err := chain.sign(t).Verify(VerifyOptions{Roots: roots, CurrentTime: testTime}); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("intermediate excluding trust list signing: Verify returned %v, want ErrSignatureInvalid", err)
+	}
+}
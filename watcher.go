@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2025 Opsmate, Inc.
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ *
+ * Except as contained in this notice, the name(s) of the above copyright
+ * holders shall not be used in advertising or otherwise to promote the
+ * sale, use or other dealings in this Software without prior written
+ * authorization
+ */
+
+package authrootstl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultWatchInterval is the default time between polls by a Watcher
+const DefaultWatchInterval = 10 * time.Minute
+
+// Watcher periodically downloads the CTL and reports when its sequence number changes.
+// A CTL which fails CTL.CheckRollback against the most recently seen CTL is ignored,
+// and the poll fails with a *RollbackError.
+type Watcher struct {
+	Client   *Client       // if nil, a zero Client is used
+	Interval time.Duration // time between polls; if zero, DefaultWatchInterval is used
+
+	// OnChange is called with the previous and new CTL each time the sequence number changes
+	OnChange func(oldCTL, newCTL *CTL)
+
+	// OnEvent, if non-nil, is called with each event in the Diff of the previous
+	// and new CTL each time the sequence number changes, after OnChange
+	OnEvent func(Event)
+
+	// OnError, if non-nil, is called each time a poll fails
+	OnError func(error)
+
+	// Verify, if non-nil, is called with each downloaded CTL.  If it returns an error,
+	// the CTL is ignored and the poll fails.
+	Verify func(*CTL) error
+
+	// History, if non-nil, is given each CTL which becomes the most recently seen
+	// CTL, including the first.  Errors storing a CTL are passed to OnError, but do
+	// not fail the poll.
+	History HistoryStore
+
+	// OnPoll, if non-nil, is called after each poll with the most recently seen CTL
+	// (which may be nil) and the error, if the poll failed
+	OnPoll func(current *CTL, err error)
+
+	// Logger, if non-nil, receives an info record for each change, a warning for
+	// each failed poll, and a debug record for each poll which found no change
+	Logger *slog.Logger
+}
+
+// Run polls for changes until ctx is done.  initial is the most recently seen CTL,
+// or nil if none has been seen, in which case the first CTL downloaded is taken as
+// the starting point and OnChange is not called for it.  Run returns the most
+// recently seen CTL (which may be nil) along with ctx.Err().
+func (watcher *Watcher) Run(ctx context.Context, initial *CTL) (*CTL, error) {
+	client := watcher.Client
+	if client == nil {
+		client = new(Client)
+	}
+	interval := watcher.Interval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+	logger := watcher.Logger
+	if logger == nil {
+		logger = discardLogger
+	}
+	current := initial
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctl, err := client.FetchCTL(ctx)
+		if err == nil && watcher.Verify != nil {
+			if verifyErr := watcher.Verify(ctl); verifyErr != nil {
+				err = fmt.Errorf("CTL with sequence number %X failed verification: %w", &ctl.SequenceNumber, verifyErr)
+			}
+		}
+		if err == nil && current != nil {
+			err = ctl.CheckRollback(&current.SequenceNumber, current.EffectiveDate)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("poll failed", "error", err)
+				if watcher.OnError != nil {
+					watcher.OnError(err)
+				}
+			}
+		} else if current == nil {
+			logger.Info("initial trust list", "sequence_number", fmt.Sprintf("%X", &ctl.SequenceNumber),
+				"effective_date", ctl.EffectiveDate)
+			current = ctl
+			watcher.store(ctx, ctl)
+		} else if ctl.SequenceNumber.Cmp(&current.SequenceNumber) != 0 {
+			previous := current
+			current = ctl
+			diff := Diff(previous, ctl)
+			logger.Info("trust list changed", "diff", diff)
+			watcher.store(ctx, ctl)
+			if watcher.OnChange != nil {
+				watcher.OnChange(previous, ctl)
+			}
+			if watcher.OnEvent != nil {
+				for _, event := range diff.Events() {
+					watcher.OnEvent(event)
+				}
+			}
+		} else {
+			logger.Debug("trust list unchanged", "sequence_number", fmt.Sprintf("%X", &ctl.SequenceNumber))
+		}
+		if watcher.OnPoll != nil && ctx.Err() == nil {
+			watcher.OnPoll(current, err)
+		}
+		select {
+		case <-ctx.Done():
+			return current, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (watcher *Watcher) store(ctx context.Context, ctl *CTL) {
+	if watcher.History == nil {
+		return
+	}
+	if _, err := watcher.History.Put(ctx, ctl, time.Now()); err != nil {
+		err = fmt.Errorf("error storing CTL with sequence number %X in history: %w", &ctl.SequenceNumber, err)
+		if watcher.Logger != nil {
+			watcher.Logger.Warn("history store failed", "error", err)
+		}
+		if watcher.OnError != nil {
+			watcher.OnError(err)
+		}
+	}
+}